@@ -0,0 +1,43 @@
+// Command api serves the HTTP API over the stored articles
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/api"
+	"github.com/tamboto2000/ivosight-crawler/internal/config"
+	"github.com/tamboto2000/ivosight-crawler/internal/infra"
+	"github.com/tamboto2000/ivosight-crawler/internal/repository"
+)
+
+func main() {
+	if err := run(); err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := infra.InitMongoDB(cfg.MongoDB)
+	if err != nil {
+		return err
+	}
+
+	defer client.Disconnect(context.Background())
+
+	repo := repository.NewArticleRepository(client.Database(cfg.MongoDB.Database))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return api.NewServer(repo).ListenAndServe(ctx, cfg.API.Addr)
+}
@@ -0,0 +1,147 @@
+// Command crawler crawls the news portals and stores the articles,
+// and with API_IN_CRAWLER, serves the HTTP API over them as well
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/api"
+	"github.com/tamboto2000/ivosight-crawler/internal/config"
+	"github.com/tamboto2000/ivosight-crawler/internal/crawler"
+	"github.com/tamboto2000/ivosight-crawler/internal/entity"
+	"github.com/tamboto2000/ivosight-crawler/internal/infra"
+	"github.com/tamboto2000/ivosight-crawler/internal/pubsub"
+	"github.com/tamboto2000/ivosight-crawler/internal/repository"
+	"github.com/tamboto2000/ivosight-crawler/internal/search"
+	"github.com/tamboto2000/ivosight-crawler/internal/sentiment"
+	"github.com/tamboto2000/ivosight-crawler/pkg/proxrotate"
+	"github.com/tamboto2000/ivosight-crawler/pkg/siterules"
+)
+
+func main() {
+	if err := run(); err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := infra.InitMongoDB(cfg.MongoDB)
+	if err != nil {
+		return err
+	}
+
+	defer client.Disconnect(context.Background())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	repo := repository.NewArticleRepository(client.Database(cfg.MongoDB.Database))
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	proxrot, err := proxyRotator(cfg.Crawler)
+	if err != nil {
+		return err
+	}
+
+	crawl := crawler.NewNewsCrawler(cfg.Crawler, repo, proxrot)
+
+	// the invalid rule files is skipped, the crawler still runs
+	// with the other sites
+	rules := siterules.NewRegistry(cfg.Crawler.SiteRulesDir)
+	if err := rules.Load(); err != nil {
+		slog.Error("some site rules is not loaded", "dir", cfg.Crawler.SiteRulesDir, "error", err.Error())
+	}
+
+	crawl.WithSiteRules(rules)
+
+	idx, err := search.Open(cfg.Search.IndexDir)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := idx.Close(); err != nil {
+			slog.Error("failed to close search index", "error", err.Error())
+		}
+	}()
+
+	crawl.WithSearchIndex(idx)
+
+	if cfg.Crawler.EntityWatchlist != "" {
+		watchlist, err := entity.LoadGazetteer(cfg.Crawler.EntityWatchlist)
+		if err != nil {
+			return err
+		}
+
+		crawl.WithEntityWatchlist(watchlist)
+	}
+
+	if cfg.Crawler.SentimentLexicon != "" {
+		lex, err := sentiment.LoadLexicon(cfg.Crawler.SentimentLexicon)
+		if err != nil {
+			return err
+		}
+
+		crawl.WithSentimentLexicon(lex)
+	}
+
+	if cfg.API.InCrawler {
+		hub := pubsub.NewHub().
+			WithHistory(cfg.API.FeedHistory).
+			WithBuffer(cfg.API.FeedBuffer)
+
+		crawl.WithHub(hub)
+
+		go func() {
+			srv := api.NewServer(repo).WithHub(hub)
+			if err := srv.ListenAndServe(ctx, cfg.API.Addr); err != nil {
+				slog.Error(err.Error())
+				stop()
+			}
+		}()
+	}
+
+	return crawl.Run(ctx)
+}
+
+// proxyRotator creates the proxy rotator of PROXY_LIST, nil when
+// the proxies is not used
+func proxyRotator(cfg config.Crawler) (*proxrotate.ProxyRotator, error) {
+	if !cfg.UseProxy {
+		return nil, nil
+	}
+
+	if !cfg.UseProxyList {
+		slog.Warn("proxyscrape is not supported, crawling without proxy")
+		return nil, nil
+	}
+
+	var proxs []*url.URL
+	for _, str := range cfg.ProxyList {
+		if str == "" {
+			continue
+		}
+
+		prox, err := url.Parse(str)
+		if err != nil {
+			return nil, err
+		}
+
+		proxs = append(proxs, prox)
+	}
+
+	return proxrotate.NewProxyRotator(proxs), nil
+}
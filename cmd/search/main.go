@@ -0,0 +1,196 @@
+// Command search queries the full-text search index of the articles,
+// and with -reindex, rebuilds the index from the stored articles
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/config"
+	"github.com/tamboto2000/ivosight-crawler/internal/infra"
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/internal/repository"
+	"github.com/tamboto2000/ivosight-crawler/internal/search"
+)
+
+func main() {
+	reindex := flag.Bool("reindex", false, "rebuild the index from the stored articles")
+	sources := flag.String("source", "", "comma separated article sources, like Detik.com")
+	since := flag.String("since", "", "only the articles published since this date, YYYY-MM-DD")
+	until := flag.String("until", "", "only the articles published before this date, YYYY-MM-DD")
+	tags := flag.String("tag", "", "comma separated tags, the articles having any of it")
+	limit := flag.Int("limit", search.DefaultLimit, "maximum number of articles found")
+	offset := flag.Int("offset", 0, "number of articles skipped")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), `usage: search [flags] <query>
+
+The articles having any of the query words is found. A "quoted phrase"
+must be found as is, and a -word must not be found.`)
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+
+	if *reindex {
+		err = rebuild(cfg)
+	} else {
+		query := search.Query{
+			Text:   strings.Join(flag.Args(), " "),
+			Tags:   split(*tags),
+			Limit:  *limit,
+			Offset: *offset,
+		}
+
+		for _, source := range split(*sources) {
+			query.Sources = append(query.Sources, models.ArticleSource(source))
+		}
+
+		query.Since, err = parseDate(*since)
+		if err == nil {
+			query.Until, err = parseDate(*until)
+		}
+
+		if err == nil {
+			err = run(cfg.Search.IndexDir, query)
+		}
+	}
+
+	if err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(dir string, query search.Query) error {
+	if strings.TrimSpace(query.Text) == "" {
+		flag.Usage()
+		return nil
+	}
+
+	// the crawler may have the index opened, it is only read
+	idx, err := search.OpenReadOnly(dir)
+	if err != nil {
+		return err
+	}
+
+	res := idx.Search(query)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Found %d of %d articles\n\n", res.Total, idx.Len())
+	fmt.Fprintln(w, "SCORE\tPUBLISHED\tSOURCE\tHEADLINE\tLINK")
+	for _, hit := range res.Hits {
+		fmt.Fprintf(w, "%.3f\t%s\t%s\t%s\t%s\n", hit.Score, hit.PublishedAt.Local().Format(time.DateTime), hit.Source, hit.Headline, hit.Link)
+	}
+
+	return w.Flush()
+}
+
+// rebuild indexes all the stored articles into a new index,
+// then replaces the index with it
+func rebuild(cfg config.Config) error {
+	client, err := infra.InitMongoDB(cfg.MongoDB)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	defer client.Disconnect(ctx)
+
+	repo := repository.NewArticleRepository(client.Database(cfg.MongoDB.Database))
+
+	// the index is locked while it is rebuilt, it is not
+	// replaced while the crawler writes to it
+	cur, err := search.Open(cfg.Search.IndexDir)
+	if err != nil {
+		if errors.Is(err, search.ErrLocked) {
+			return errors.New("search index is opened by the crawler, stop it before rebuilding the index")
+		}
+
+		return err
+	}
+
+	defer cur.Close()
+
+	tmpDir := cfg.Search.IndexDir + ".new"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return err
+	}
+
+	idx, err := search.Open(tmpDir)
+	if err != nil {
+		return err
+	}
+
+	err = repo.EachArticle(ctx, func(art models.NewsArticle) error {
+		if err := idx.Add(art); err != nil {
+			return err
+		}
+
+		if n := idx.Len(); n%1000 == 0 {
+			slog.Info("indexing articles", "count", n)
+		}
+
+		return nil
+	})
+
+	if err := idx.Close(); err != nil {
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := cur.Close(); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(cfg.Search.IndexDir); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpDir, cfg.Search.IndexDir); err != nil {
+		return err
+	}
+
+	slog.Info("search index is rebuilt", "count", idx.Len(), "dir", cfg.Search.IndexDir)
+
+	return nil
+}
+
+func split(str string) []string {
+	var list []string
+	for _, s := range strings.Split(str, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+
+	return list
+}
+
+func parseDate(str string) (time.Time, error) {
+	if str == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.ParseInLocation(time.DateOnly, str, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, must be YYYY-MM-DD", str)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,79 @@
+// Command tombstone-report prints the number of removed articles per
+// source, and with -list, the removed articles themselves
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/config"
+	"github.com/tamboto2000/ivosight-crawler/internal/infra"
+	"github.com/tamboto2000/ivosight-crawler/internal/repository"
+)
+
+func main() {
+	since := flag.Duration("since", 7*24*time.Hour, "report the removals observed in this duration")
+	source := flag.String("source", "", "only list the articles of this source")
+	list := flag.Bool("list", false, "list the removed articles")
+	limit := flag.Int("limit", 100, "maximum number of articles listed")
+	flag.Parse()
+
+	if err := run(time.Now().Add(-*since), *source, *list, *limit); err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(since time.Time, source string, list bool, limit int) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := infra.InitMongoDB(cfg.MongoDB)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	defer client.Disconnect(ctx)
+
+	repo := repository.NewArticleRepository(client.Database(cfg.MongoDB.Database))
+
+	reports, err := repo.RemovedReport(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Removed articles since %s\n\n", since.Format(time.DateTime))
+	fmt.Fprintln(w, "SOURCE\tREMOVED\tLAST OBSERVED")
+	for _, rep := range reports {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", rep.Source, rep.Count, rep.LastObservedAt.Local().Format(time.DateTime))
+	}
+
+	if !list {
+		return w.Flush()
+	}
+
+	arts, err := repo.RemovedArticles(ctx, source, since, limit)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "\nOBSERVED\tSOURCE\tREASON\tLINK")
+	for _, art := range arts {
+		if art.Removal == nil {
+			continue
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", art.Removal.ObservedAt.Local().Format(time.DateTime), art.Source, art.Removal.Reason, art.Link)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,285 @@
+// Command webhook manages the webhook endpoints the crawler delivers
+// the stored articles to, and queries their delivery log
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/config"
+	"github.com/tamboto2000/ivosight-crawler/internal/infra"
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/internal/repository"
+	"github.com/tamboto2000/ivosight-crawler/internal/webhook"
+)
+
+const usage = `usage: webhook <command> [flags] [args]
+
+commands:
+  add         register an endpoint, the secret is printed once
+  list        list the endpoints
+  remove      remove an endpoint by its ID
+  deliveries  list the delivery log, the latest first
+  retry       queue a dead delivery by its ID again
+
+Run "webhook <command> -h" for the flags of the command.`
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cmd, args := os.Args[1], os.Args[2:]
+
+	var err error
+	switch cmd {
+	case "add":
+		err = add(args)
+
+	case "list":
+		err = list(args)
+
+	case "remove":
+		err = remove(args)
+
+	case "deliveries":
+		err = deliveries(args)
+
+	case "retry":
+		err = retry(args)
+
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if errors.Is(err, errUsage) {
+		os.Exit(2)
+	}
+
+	if err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+func add(args []string) error {
+	flags := flag.NewFlagSet("add", flag.ContinueOnError)
+	link := flags.String("url", "", "the endpoint URL the articles is POSTed to")
+	secret := flags.String("secret", "", "the signing secret, generated when empty")
+	sources := flags.String("source", "", "comma separated article sources, like Detik.com")
+	channels := flags.String("channel", "", "comma separated channels")
+	keywords := flags.String("keyword", "", "comma separated keywords, an article matches when it has all the words of any keyword")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	u, err := url.Parse(*link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		fmt.Fprintln(os.Stderr, "-url must be an http or https URL")
+		return errUsage
+	}
+
+	if *secret == "" {
+		*secret, err = webhook.NewSecret()
+		if err != nil {
+			return err
+		}
+	}
+
+	id, err := webhook.NewID()
+	if err != nil {
+		return err
+	}
+
+	ep := models.WebhookEndpoint{
+		ID:        id,
+		URL:       u.String(),
+		Secret:    *secret,
+		Sources:   split(*sources),
+		Channels:  models.NormalizeTerms(split(*channels)),
+		Keywords:  split(*keywords),
+		CreatedAt: time.Now(),
+	}
+
+	return withRepo(func(ctx context.Context, repo *repository.ArticleRepository) error {
+		if err := repo.StoreWebhookEndpoint(ctx, ep); err != nil {
+			return err
+		}
+
+		fmt.Printf("id:     %s\nsecret: %s\n", ep.ID, ep.Secret)
+
+		return nil
+	})
+}
+
+func list(args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	return withRepo(func(ctx context.Context, repo *repository.ArticleRepository) error {
+		endpoints, err := repo.WebhookEndpoints(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tURL\tSOURCES\tCHANNELS\tKEYWORDS\tCREATED")
+		for _, ep := range endpoints {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				ep.ID,
+				ep.URL,
+				join(ep.Sources),
+				join(ep.Channels),
+				join(ep.Keywords),
+				ep.CreatedAt.Local().Format(time.DateTime),
+			)
+		}
+
+		return w.Flush()
+	})
+}
+
+func remove(args []string) error {
+	flags := flag.NewFlagSet("remove", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: webhook remove <endpoint-id>")
+		return errUsage
+	}
+
+	return withRepo(func(ctx context.Context, repo *repository.ArticleRepository) error {
+		ok, err := repo.DeleteWebhookEndpoint(ctx, flags.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return fmt.Errorf("endpoint %s is not found", flags.Arg(0))
+		}
+
+		return nil
+	})
+}
+
+func deliveries(args []string) error {
+	flags := flag.NewFlagSet("deliveries", flag.ContinueOnError)
+	endpointID := flags.String("endpoint", "", "only the deliveries of this endpoint ID")
+	status := flags.String("status", "", "only the deliveries with this status, pending, delivered or dead")
+	limit := flags.Int("limit", 50, "maximum number of deliveries listed")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	return withRepo(func(ctx context.Context, repo *repository.ArticleRepository) error {
+		deliveries, err := repo.WebhookDeliveries(ctx, *endpointID, *status, *limit)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tENDPOINT\tARTICLE\tEVENT\tSTATUS\tATTEMPTS\tCREATED\tLAST ERROR")
+		for _, d := range deliveries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+				d.ID,
+				d.EndpointID,
+				d.ArticleID,
+				d.Event,
+				d.Status,
+				d.Attempts,
+				d.CreatedAt.Local().Format(time.DateTime),
+				d.LastError,
+			)
+		}
+
+		return w.Flush()
+	})
+}
+
+func retry(args []string) error {
+	flags := flag.NewFlagSet("retry", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: webhook retry <delivery-id>")
+		return errUsage
+	}
+
+	return withRepo(func(ctx context.Context, repo *repository.ArticleRepository) error {
+		delivery, ok, err := repo.WebhookDelivery(ctx, flags.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return fmt.Errorf("delivery %s is not found", flags.Arg(0))
+		}
+
+		if delivery.Status != models.WebhookDead {
+			return fmt.Errorf("delivery %s is %s, only the dead delivery is retried", delivery.ID, delivery.Status)
+		}
+
+		// the delivery gets all the attempts again, the last
+		// error is kept until the next attempt
+		delivery.Status = models.WebhookPending
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = time.Now()
+		delivery.UpdatedAt = delivery.NextAttemptAt
+
+		return repo.StoreWebhookDelivery(ctx, delivery)
+	})
+}
+
+func withRepo(fn func(ctx context.Context, repo *repository.ArticleRepository) error) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := infra.InitMongoDB(cfg.MongoDB)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	defer client.Disconnect(ctx)
+
+	return fn(ctx, repository.NewArticleRepository(client.Database(cfg.MongoDB.Database)))
+}
+
+func split(str string) []string {
+	var items []string
+	for _, item := range strings.Split(str, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func join(items []string) string {
+	if len(items) == 0 {
+		return "*"
+	}
+
+	return strings.Join(items, ",")
+}
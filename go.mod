@@ -6,6 +6,7 @@ require (
 	github.com/joho/godotenv v1.5.1
 	go.mongodb.org/mongo-driver v1.16.1
 	golang.org/x/net v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
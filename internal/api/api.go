@@ -0,0 +1,406 @@
+// Package api provides the HTTP API over the stored articles
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/internal/pubsub"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+	// defaultSince is how far back the list goes when
+	// since is not given
+	defaultSince = 7 * 24 * time.Hour
+)
+
+type Repository interface {
+	RemovedArticles(ctx context.Context, source string, since time.Time, limit int) ([]models.NewsArticle, error)
+	RemovedReport(ctx context.Context, since time.Time) ([]models.RemovedReport, error)
+	StoryClusters(ctx context.Context, since time.Time, minSources, limit int) ([]models.StoryCluster, error)
+	StoryCluster(ctx context.Context, id string) (models.StoryCluster, bool, error)
+	ArticlesByEntity(ctx context.Context, name, typ string, since time.Time, limit int) ([]models.NewsArticle, error)
+	LatestTrendSnapshot(ctx context.Context, source, channel string) (models.TrendSnapshot, bool, error)
+	ListArticles(ctx context.Context, filter models.ArticleFilter, afterPublishedAt time.Time, afterID string, limit int) ([]models.NewsArticle, error)
+	ArticlesChangedSince(ctx context.Context, filter models.ArticleFilter, since time.Time, limit int) ([]models.NewsArticle, error)
+	FindArticleByID(ctx context.Context, id string) (models.NewsArticle, bool, error)
+	FindArticleByLink(ctx context.Context, link string) (models.NewsArticle, bool, error)
+	ArticleVersions(ctx context.Context, articleID string) ([]models.ArticleVersion, error)
+}
+
+type Server struct {
+	repo         Repository
+	hub          *pubsub.Hub
+	now          func() time.Time
+	pollInterval time.Duration
+	pingInterval time.Duration
+}
+
+func NewServer(repo Repository) *Server {
+	return &Server{
+		repo:         repo,
+		now:          time.Now,
+		pollInterval: streamPollInterval,
+		pingInterval: feedPingInterval,
+	}
+}
+
+// WithHub serves the live feed of the articles published to the
+// hub, the feed is unavailable without it
+func (srv *Server) WithHub(hub *pubsub.Hub) *Server {
+	srv.hub = hub
+	return srv
+}
+
+// Handler gets the handler of the API routes
+func (srv *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.yaml", serveOpenAPI)
+	mux.HandleFunc("GET /articles", srv.listArticles)
+	mux.HandleFunc("GET /articles/{id}", srv.getArticle)
+	mux.HandleFunc("GET /articles/{id}/versions", srv.articleVersions)
+	mux.HandleFunc("GET /articles/lookup", srv.lookupArticle)
+	mux.HandleFunc("GET /articles/stream", srv.streamArticles)
+	mux.HandleFunc("GET /articles/removed", srv.removedArticles)
+	mux.HandleFunc("GET /feed", srv.feedSSE)
+	mux.HandleFunc("GET /feed/ws", srv.feedWebSocket)
+	mux.HandleFunc("GET /reports/removed", srv.removedReport)
+	mux.HandleFunc("GET /stories", srv.storyClusters)
+	mux.HandleFunc("GET /stories/{id}", srv.storyCluster)
+	mux.HandleFunc("GET /entities/{name}/articles", srv.entityArticles)
+	mux.HandleFunc("GET /trends", srv.trends)
+
+	return mux
+}
+
+// ListenAndServe serves the API on addr until ctx is cancelled,
+// the running requests is given 10 seconds to finish
+func (srv *Server) ListenAndServe(ctx context.Context, addr string) error {
+	httpSrv := &http.Server{
+		Addr:              addr,
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		// the stream is cancelled on shutdown
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		httpSrv.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("api server is listening", "addr", addr)
+	if err := httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+type removedArticle struct {
+	ID          string                `json:"id"`
+	Source      models.ArticleSource  `json:"source"`
+	Link        string                `json:"link"`
+	Headline    string                `json:"headline"`
+	Channel     string                `json:"channel"`
+	PublishedAt time.Time             `json:"published_at"`
+	Removal     models.ArticleRemoval `json:"removal"`
+}
+
+// removedArticles lists the removed articles, filtered
+// by source and since query
+func (srv *Server) removedArticles(w http.ResponseWriter, r *http.Request) {
+	since, ok := srv.parseSince(w, r)
+	if !ok {
+		return
+	}
+
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	arts, err := srv.repo.RemovedArticles(r.Context(), r.URL.Query().Get("source"), since, limit)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	list := make([]removedArticle, 0, len(arts))
+	for _, art := range arts {
+		item := removedArticle{
+			ID:          art.ID,
+			Source:      art.Source,
+			Link:        art.Link,
+			Headline:    art.Headline,
+			Channel:     art.Channel,
+			PublishedAt: art.PublishedAt,
+		}
+
+		if art.Removal != nil {
+			item.Removal = *art.Removal
+		}
+
+		list = append(list, item)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"articles": list})
+}
+
+// removedReport counts the removed articles per source
+func (srv *Server) removedReport(w http.ResponseWriter, r *http.Request) {
+	since, ok := srv.parseSince(w, r)
+	if !ok {
+		return
+	}
+
+	reports, err := srv.repo.RemovedReport(r.Context(), since)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	if reports == nil {
+		reports = []models.RemovedReport{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"since": since, "sources": reports})
+}
+
+// storyClusters lists the story clusters first seen since since
+// query, with at least min_sources sources
+func (srv *Server) storyClusters(w http.ResponseWriter, r *http.Request) {
+	since, ok := srv.parseSince(w, r)
+	if !ok {
+		return
+	}
+
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	minSources := 1
+	if str := r.URL.Query().Get("min_sources"); str != "" {
+		n, err := strconv.Atoi(str)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "min_sources must be a positive number")
+			return
+		}
+
+		minSources = n
+	}
+
+	clusters, err := srv.repo.StoryClusters(r.Context(), since, minSources, limit)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	if clusters == nil {
+		clusters = []models.StoryCluster{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"stories": clusters})
+}
+
+// storyCluster gets a story cluster, with who broke it first
+func (srv *Server) storyCluster(w http.ResponseWriter, r *http.Request) {
+	cluster, ok, err := srv.repo.StoryCluster(r.Context(), r.PathValue("id"))
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "story not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cluster)
+}
+
+type entityArticle struct {
+	ID          string               `json:"id"`
+	Source      models.ArticleSource `json:"source"`
+	Link        string               `json:"link"`
+	Headline    string               `json:"headline"`
+	Channel     string               `json:"channel"`
+	PublishedAt time.Time            `json:"published_at"`
+	// Entity is the entity as mentioned in the article, and
+	// Sentiment is the sentiment of the sentences mentioning it
+	Entity    models.ArticleEntity    `json:"entity"`
+	Sentiment *models.EntitySentiment `json:"sentiment,omitempty"`
+}
+
+// entityArticles lists the articles mentioning the entity, filtered
+// by type and since query
+func (srv *Server) entityArticles(w http.ResponseWriter, r *http.Request) {
+	since, ok := srv.parseSince(w, r)
+	if !ok {
+		return
+	}
+
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	typ := r.URL.Query().Get("type")
+	switch typ {
+	case "", models.EntityPerson, models.EntityOrganization, models.EntityLocation:
+	default:
+		writeError(w, http.StatusBadRequest, "type must be person, organization or location")
+		return
+	}
+
+	name := r.PathValue("name")
+	arts, err := srv.repo.ArticlesByEntity(r.Context(), name, typ, since, limit)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	key := models.EntityKey(name)
+	list := make([]entityArticle, 0, len(arts))
+	for _, art := range arts {
+		item := entityArticle{
+			ID:          art.ID,
+			Source:      art.Source,
+			Link:        art.Link,
+			Headline:    art.Headline,
+			Channel:     art.Channel,
+			PublishedAt: art.PublishedAt,
+		}
+
+		for _, e := range art.Entities {
+			if e.Key == key && (typ == "" || e.Type == typ) {
+				item.Entity = e
+				break
+			}
+		}
+
+		if art.Sentiment != nil {
+			for _, es := range art.Sentiment.Entities {
+				if es.Key == item.Entity.Key && es.Type == item.Entity.Type {
+					item.Sentiment = &es
+					break
+				}
+			}
+		}
+
+		list = append(list, item)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"articles": list})
+}
+
+// trends gets the latest trending terms, phrases and entities of
+// the source and channel query, all sources when empty. limit is
+// the number of items of each kind
+func (srv *Server) trends(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	snap, ok, err := srv.repo.LatestTrendSnapshot(r.Context(), query.Get("source"), models.NormalizeTerm(query.Get("channel")))
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "trends not found")
+		return
+	}
+
+	snap.Terms = truncate(snap.Terms, limit)
+	snap.Phrases = truncate(snap.Phrases, limit)
+	snap.Entities = truncate(snap.Entities, limit)
+
+	writeJSON(w, http.StatusOK, snap)
+}
+
+func truncate(items []models.TrendItem, limit int) []models.TrendItem {
+	if items == nil {
+		return []models.TrendItem{}
+	}
+
+	if len(items) > limit {
+		return items[:limit]
+	}
+
+	return items
+}
+
+// parseSince parses since query, defaults to defaultSince ago
+func (srv *Server) parseSince(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	if r.URL.Query().Get("since") == "" {
+		return srv.now().Add(-defaultSince), true
+	}
+
+	return parseTime(w, r, "since")
+}
+
+// parseTime parses the time query, either RFC 3339 time or date
+func parseTime(w http.ResponseWriter, r *http.Request, name string) (time.Time, bool) {
+	str := r.URL.Query().Get(name)
+	if t, err := time.Parse(time.RFC3339, str); err == nil {
+		return t, true
+	}
+
+	if t, err := time.Parse(time.DateOnly, str); err == nil {
+		return t, true
+	}
+
+	writeError(w, http.StatusBadRequest, name+" must be RFC 3339 time or YYYY-MM-DD date")
+
+	return time.Time{}, false
+}
+
+func parseLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	str := r.URL.Query().Get("limit")
+	if str == "" {
+		return defaultLimit, true
+	}
+
+	limit, err := strconv.Atoi(str)
+	if err != nil || limit <= 0 || limit > maxLimit {
+		writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxLimit))
+		return 0, false
+	}
+
+	return limit, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error(err.Error())
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func internalError(w http.ResponseWriter, err error) {
+	slog.Error(err.Error())
+	writeError(w, http.StatusInternalServerError, "internal server error")
+}
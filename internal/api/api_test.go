@@ -0,0 +1,880 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/internal/pubsub"
+	"golang.org/x/net/websocket"
+	"gopkg.in/yaml.v3"
+)
+
+type memRepo struct {
+	mx       sync.Mutex
+	arts     []models.NewsArticle
+	stories  []models.StoryCluster
+	trends   []models.TrendSnapshot
+	versions []models.ArticleVersion
+}
+
+func (repo *memRepo) RemovedArticles(ctx context.Context, source string, since time.Time, limit int) ([]models.NewsArticle, error) {
+	var list []models.NewsArticle
+	for _, art := range repo.arts {
+		if art.Status != models.ArticleStatusRemoved || art.Removal.ObservedAt.Before(since) {
+			continue
+		}
+
+		if source != "" && string(art.Source) != source {
+			continue
+		}
+
+		list = append(list, art)
+	}
+
+	return list, nil
+}
+
+func (repo *memRepo) RemovedReport(ctx context.Context, since time.Time) ([]models.RemovedReport, error) {
+	arts, _ := repo.RemovedArticles(ctx, "", since, 0)
+	counts := make(map[models.ArticleSource]int)
+	var reports []models.RemovedReport
+	for _, art := range arts {
+		if counts[art.Source] == 0 {
+			reports = append(reports, models.RemovedReport{Source: art.Source})
+		}
+
+		counts[art.Source]++
+	}
+
+	for i := range reports {
+		reports[i].Count = counts[reports[i].Source]
+	}
+
+	return reports, nil
+}
+
+func (repo *memRepo) StoryClusters(ctx context.Context, since time.Time, minSources, limit int) ([]models.StoryCluster, error) {
+	var list []models.StoryCluster
+	for _, cluster := range repo.stories {
+		if !cluster.FirstSeenAt.Before(since) && cluster.SourceCount >= minSources {
+			list = append(list, cluster)
+		}
+	}
+
+	return list, nil
+}
+
+func (repo *memRepo) StoryCluster(ctx context.Context, id string) (models.StoryCluster, bool, error) {
+	for _, cluster := range repo.stories {
+		if cluster.ID == id {
+			return cluster, true, nil
+		}
+	}
+
+	return models.StoryCluster{}, false, nil
+}
+
+func (repo *memRepo) ArticlesByEntity(ctx context.Context, name, typ string, since time.Time, limit int) ([]models.NewsArticle, error) {
+	var list []models.NewsArticle
+	for _, art := range repo.arts {
+		if art.PublishedAt.Before(since) {
+			continue
+		}
+
+		for _, e := range art.Entities {
+			if e.Key == models.EntityKey(name) && (typ == "" || e.Type == typ) {
+				list = append(list, art)
+				break
+			}
+		}
+	}
+
+	return list, nil
+}
+
+func (repo *memRepo) LatestTrendSnapshot(ctx context.Context, source, channel string) (models.TrendSnapshot, bool, error) {
+	var latest models.TrendSnapshot
+	found := false
+	for _, snap := range repo.trends {
+		if string(snap.Source) == source && snap.Channel == channel && (!found || snap.CreatedAt.After(latest.CreatedAt)) {
+			latest = snap
+			found = true
+		}
+	}
+
+	return latest, found, nil
+}
+
+func (repo *memRepo) ListArticles(ctx context.Context, filter models.ArticleFilter, afterPublishedAt time.Time, afterID string, limit int) ([]models.NewsArticle, error) {
+	var list []models.NewsArticle
+	for _, art := range repo.arts {
+		if !matchFilter(art, filter) {
+			continue
+		}
+
+		if afterID != "" && !(art.PublishedAt.Before(afterPublishedAt) || art.PublishedAt.Equal(afterPublishedAt) && art.ID < afterID) {
+			continue
+		}
+
+		list = append(list, art)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].PublishedAt.Equal(list[j].PublishedAt) {
+			return list[i].PublishedAt.After(list[j].PublishedAt)
+		}
+
+		return list[i].ID > list[j].ID
+	})
+
+	if len(list) > limit {
+		list = list[:limit]
+	}
+
+	return list, nil
+}
+
+func (repo *memRepo) ArticlesChangedSince(ctx context.Context, filter models.ArticleFilter, since time.Time, limit int) ([]models.NewsArticle, error) {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+
+	var list []models.NewsArticle
+	for _, art := range repo.arts {
+		if matchFilter(art, filter) && !art.ChangedAt.Before(since) {
+			list = append(list, art)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ChangedAt.Before(list[j].ChangedAt)
+	})
+
+	return list, nil
+}
+
+func matchFilter(art models.NewsArticle, filter models.ArticleFilter) bool {
+	switch {
+	case art.Status == models.ArticleStatusRemoved,
+		filter.Source != "" && string(art.Source) != filter.Source,
+		filter.Channel != "" && art.Channel != filter.Channel,
+		!filter.Since.IsZero() && art.PublishedAt.Before(filter.Since),
+		!filter.Until.IsZero() && !art.PublishedAt.Before(filter.Until):
+		return false
+	}
+
+	for _, term := range filter.Terms {
+		if !slices.Contains(art.Terms, term) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (repo *memRepo) FindArticleByID(ctx context.Context, id string) (models.NewsArticle, bool, error) {
+	for _, art := range repo.arts {
+		if art.ID == id {
+			return art, true, nil
+		}
+	}
+
+	return models.NewsArticle{}, false, nil
+}
+
+func (repo *memRepo) FindArticleByLink(ctx context.Context, link string) (models.NewsArticle, bool, error) {
+	for _, art := range repo.arts {
+		if art.Link == link || slices.Contains(art.Aliases, link) {
+			return art, true, nil
+		}
+	}
+
+	return models.NewsArticle{}, false, nil
+}
+
+func (repo *memRepo) ArticleVersions(ctx context.Context, articleID string) ([]models.ArticleVersion, error) {
+	var versions []models.ArticleVersion
+	for _, version := range repo.versions {
+		if version.ArticleID == articleID {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions, nil
+}
+
+func newTestServer() *Server {
+	return newTestServerWithRepo(&memRepo{})
+}
+
+func newTestServerWithRepo(repo *memRepo) *Server {
+	now := time.Date(2024, 8, 12, 10, 0, 0, 0, time.UTC)
+	removed := func(source models.ArticleSource, link string, observedAt time.Time) models.NewsArticle {
+		return models.NewsArticle{
+			Source:  source,
+			Link:    link,
+			Status:  models.ArticleStatusRemoved,
+			Removal: &models.ArticleRemoval{Reason: models.RemovalNotFound, StatusCode: 404, ObservedAt: observedAt},
+		}
+	}
+
+	repo.arts = []models.NewsArticle{
+		removed(models.Detik, "https://news.detik.com/a", now.Add(-time.Hour)),
+		removed(models.Detik, "https://news.detik.com/b", now.Add(-30*24*time.Hour)),
+		removed(models.Liputan6, "https://www.liputan6.com/c", now.Add(-2*time.Hour)),
+		{ID: "d", Source: models.Detik, Link: "https://news.detik.com/d", Status: models.ArticleStatusActive, PublishedAt: now.Add(-time.Hour), Entities: []models.ArticleEntity{
+			{Key: "jawa timur", Name: "Jawa Timur", Type: models.EntityLocation, Count: 2},
+			{Key: "khofifah", Name: "Khofifah", Type: models.EntityPerson, Count: 1},
+		}},
+		{ID: "e", Source: models.Detik, Channel: "news", Link: "https://news.detik.com/e", Headline: "Banjir Rob Landa Demak", PublishedAt: now.Add(-2 * time.Hour), CrawledAt: now.Add(-2 * time.Hour), ChangedAt: now.Add(-2 * time.Hour), Terms: []string{"banjir", "rob", "demak"}},
+		{ID: "f", Source: models.Liputan6, Channel: "news", Link: "https://www.liputan6.com/f", PublishedAt: now.Add(-3 * time.Hour), CrawledAt: now.Add(-3 * time.Hour), ChangedAt: now.Add(-3 * time.Hour), Terms: []string{"banjir"}},
+		{ID: "g", Source: models.Detik, Channel: "finance", Link: "https://finance.detik.com/g", Aliases: []string{"https://finance.detik.com/g-old"}, PublishedAt: now.Add(-3 * time.Hour), CrawledAt: now.Add(-3 * time.Hour), ChangedAt: now.Add(-3 * time.Hour)},
+	}
+
+	repo.versions = []models.ArticleVersion{
+		{ID: "e@1", ArticleID: "e", Version: 1, Diff: models.ArticleDiff{Headline: &models.TextChange{Old: "Banjir Landa Demak", New: "Banjir Rob Landa Demak"}}},
+	}
+
+	repo.stories = []models.StoryCluster{
+		{ID: "a", Headline: "Tol Semarang Demak Diresmikan", FirstSeenAt: now.Add(-time.Hour), FirstSource: models.Liputan6, SourceCount: 2},
+		{ID: "b", Headline: "Timnas Menang", FirstSeenAt: now.Add(-2 * time.Hour), FirstSource: models.Detik, SourceCount: 1},
+	}
+
+	repo.trends = []models.TrendSnapshot{
+		{ID: "all@1", CreatedAt: now.Add(-time.Hour), Terms: []models.TrendItem{{Kind: models.TrendTerm, Key: "timnas"}}},
+		{ID: "all@2", CreatedAt: now, Terms: []models.TrendItem{
+			{Kind: models.TrendTerm, Key: "banjir", Score: 4},
+			{Kind: models.TrendTerm, Key: "rob", Score: 3},
+		}},
+		{ID: "Detik.com/news@2", Source: models.Detik, Channel: "news", CreatedAt: now},
+	}
+
+	srv := NewServer(repo)
+
+	srv.now = func() time.Time { return now }
+
+	return srv
+}
+
+func TestRemovedArticles(t *testing.T) {
+	h := newTestServer().Handler()
+
+	tests := []struct {
+		query string
+		code  int
+		count int
+	}{
+		{"", http.StatusOK, 2},
+		{"?source=Detik.com", http.StatusOK, 1},
+		{"?since=2024-01-01", http.StatusOK, 3},
+		{"?since=kemarin", http.StatusBadRequest, 0},
+		{"?limit=0", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/articles/removed"+tt.query, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%q: expecting status %d, got %d", tt.query, tt.code, rec.Code)
+			continue
+		}
+
+		if tt.code != http.StatusOK {
+			continue
+		}
+
+		var body struct {
+			Articles []removedArticle `json:"articles"`
+		}
+
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(body.Articles) != tt.count {
+			t.Errorf("%q: expecting %d articles, got %d", tt.query, tt.count, len(body.Articles))
+		}
+	}
+}
+
+func TestRemovedReport(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestServer().Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports/removed?since=2024-01-01", nil))
+
+	var body struct {
+		Sources []models.RemovedReport `json:"sources"`
+	}
+
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(body.Sources) != 2 || body.Sources[0].Source != models.Detik || body.Sources[0].Count != 2 {
+		t.Errorf("unexpected report %+v", body.Sources)
+	}
+}
+
+func TestStoryClusters(t *testing.T) {
+	h := newTestServer().Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stories?min_sources=2", nil))
+
+	var body struct {
+		Stories []models.StoryCluster `json:"stories"`
+	}
+
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(body.Stories) != 1 || body.Stories[0].FirstSource != models.Liputan6 {
+		t.Errorf("unexpected stories %+v", body.Stories)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stories/b", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expecting status 200, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stories/x", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expecting status 404, got %d", rec.Code)
+	}
+}
+
+func TestEntityArticles(t *testing.T) {
+	h := newTestServer().Handler()
+
+	tests := []struct {
+		path  string
+		code  int
+		count int
+	}{
+		{"/entities/Jawa%20Timur/articles", http.StatusOK, 1},
+		{"/entities/jawa%20timur/articles?type=location", http.StatusOK, 1},
+		{"/entities/Jawa%20Timur/articles?type=person", http.StatusOK, 0},
+		{"/entities/Jawa%20Timur/articles?type=kota", http.StatusBadRequest, 0},
+		{"/entities/Jawa%20Barat/articles", http.StatusOK, 0},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s: expecting status %d, got %d", tt.path, tt.code, rec.Code)
+			continue
+		}
+
+		if tt.code != http.StatusOK {
+			continue
+		}
+
+		var body struct {
+			Articles []entityArticle `json:"articles"`
+		}
+
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(body.Articles) != tt.count {
+			t.Errorf("%s: expecting %d articles, got %d", tt.path, tt.count, len(body.Articles))
+			continue
+		}
+
+		if tt.count > 0 && body.Articles[0].Entity.Count != 2 {
+			t.Errorf("%s: unexpected entity %+v", tt.path, body.Articles[0].Entity)
+		}
+	}
+}
+
+func TestTrends(t *testing.T) {
+	h := newTestServer().Handler()
+
+	tests := []struct {
+		path  string
+		code  int
+		id    string
+		terms int
+	}{
+		{"/trends", http.StatusOK, "all@2", 2},
+		{"/trends?limit=1", http.StatusOK, "all@2", 1},
+		{"/trends?source=Detik.com&channel=news", http.StatusOK, "Detik.com/news@2", 0},
+		{"/trends?source=Detik.com&channel=News", http.StatusOK, "Detik.com/news@2", 0},
+		{"/trends?source=Detik.com", http.StatusNotFound, "", 0},
+		{"/trends?limit=0", http.StatusBadRequest, "", 0},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s: expecting status %d, got %d", tt.path, tt.code, rec.Code)
+			continue
+		}
+
+		if tt.code != http.StatusOK {
+			continue
+		}
+
+		var snap models.TrendSnapshot
+		if err := json.NewDecoder(rec.Body).Decode(&snap); err != nil {
+			t.Fatal(err)
+		}
+
+		if snap.ID != tt.id || len(snap.Terms) != tt.terms || snap.Phrases == nil {
+			t.Errorf("%s: unexpected snapshot %+v", tt.path, snap)
+		}
+	}
+}
+
+func TestListArticles(t *testing.T) {
+	h := newTestServer().Handler()
+
+	tests := []struct {
+		query string
+		code  int
+		ids   string
+	}{
+		{"", http.StatusOK, "d e g f"},
+		{"source=Detik.com", http.StatusOK, "d e g"},
+		{"channel=news", http.StatusOK, "e f"},
+		{"channel=News", http.StatusOK, "e f"},
+		{"since=2024-08-12T07:30:00Z&until=2024-08-12T09:00:00Z", http.StatusOK, "e"},
+		{"q=Banjir+di+Demak", http.StatusOK, "e"},
+		{"q=banjir", http.StatusOK, "e f"},
+		{"q=yang", http.StatusBadRequest, ""},
+		{"until=kemarin", http.StatusBadRequest, ""},
+		{"cursor=x", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/articles?"+tt.query, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s: expecting status %d, got %d", tt.query, tt.code, rec.Code)
+			continue
+		}
+
+		if tt.code != http.StatusOK {
+			continue
+		}
+
+		var body struct {
+			Articles []models.ArticleWithID `json:"articles"`
+		}
+
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		var ids []string
+		for _, art := range body.Articles {
+			ids = append(ids, art.ID)
+		}
+
+		if strings.Join(ids, " ") != tt.ids {
+			t.Errorf("%s: expecting %s, got %v", tt.query, tt.ids, ids)
+		}
+	}
+}
+
+func TestListArticlesCursor(t *testing.T) {
+	h := newTestServer().Handler()
+
+	var ids []string
+	path := "/articles?limit=2"
+	for pages := 0; path != ""; pages++ {
+		if pages == 3 {
+			t.Fatal("expecting the pages to end")
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		var body struct {
+			Articles   []models.ArticleWithID `json:"articles"`
+			NextCursor *string                `json:"next_cursor"`
+		}
+
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, art := range body.Articles {
+			ids = append(ids, art.ID)
+		}
+
+		path = ""
+		if body.NextCursor != nil {
+			path = "/articles?limit=2&cursor=" + url.QueryEscape(*body.NextCursor)
+		}
+	}
+
+	// g and f is published at the same time
+	if strings.Join(ids, " ") != "d e g f" {
+		t.Errorf("unexpected pages %v", ids)
+	}
+}
+
+func TestGetArticle(t *testing.T) {
+	h := newTestServer().Handler()
+
+	tests := []struct {
+		path string
+		code int
+		id   string
+	}{
+		{"/articles/e", http.StatusOK, "e"},
+		{"/articles/x", http.StatusNotFound, ""},
+		{"/articles/lookup?url=" + url.QueryEscape("https://finance.detik.com/g?utm_source=twitter"), http.StatusOK, "g"},
+		{"/articles/lookup?url=" + url.QueryEscape("https://finance.detik.com/g-old"), http.StatusOK, "g"},
+		{"/articles/lookup?url=" + url.QueryEscape("https://finance.detik.com/x"), http.StatusNotFound, ""},
+		{"/articles/lookup?url=detik", http.StatusBadRequest, ""},
+		{"/articles/lookup", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s: expecting status %d, got %d", tt.path, tt.code, rec.Code)
+			continue
+		}
+
+		if tt.code != http.StatusOK {
+			continue
+		}
+
+		var art models.ArticleWithID
+		if err := json.NewDecoder(rec.Body).Decode(&art); err != nil {
+			t.Fatal(err)
+		}
+
+		if art.ID != tt.id {
+			t.Errorf("%s: expecting %s, got %s", tt.path, tt.id, art.ID)
+		}
+	}
+}
+
+func TestArticleVersions(t *testing.T) {
+	h := newTestServer().Handler()
+
+	tests := []struct {
+		path     string
+		code     int
+		versions []string
+	}{
+		{"/articles/e/versions", http.StatusOK, []string{"e@1"}},
+		{"/articles/d/versions", http.StatusOK, nil},
+		{"/articles/x/versions", http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s: expecting status %d, got %d", tt.path, tt.code, rec.Code)
+			continue
+		}
+
+		if tt.code != http.StatusOK {
+			continue
+		}
+
+		var body struct {
+			Versions []models.ArticleVersion `json:"versions"`
+		}
+
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		if body.Versions == nil {
+			t.Errorf("%s: expecting an empty list, got null", tt.path)
+		}
+
+		var ids []string
+		for _, version := range body.Versions {
+			ids = append(ids, version.ID)
+		}
+
+		if !slices.Equal(ids, tt.versions) {
+			t.Errorf("%s: expecting %v, got %v", tt.path, tt.versions, ids)
+		}
+	}
+}
+
+func TestStreamArticles(t *testing.T) {
+	repo := &memRepo{}
+	srv := newTestServerWithRepo(repo)
+	srv.pollInterval = 10 * time.Millisecond
+
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/articles/stream?source=Detik.com&since=2024-08-12T07:30:00Z", nil)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer res.Body.Close()
+
+	if ct := res.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("unexpected content type %s", ct)
+	}
+
+	lines := bufio.NewScanner(res.Body)
+	next := func() string {
+		if !lines.Scan() {
+			t.Fatal("expecting article")
+		}
+
+		var art models.ArticleWithID
+		if err := json.Unmarshal(lines.Bytes(), &art); err != nil {
+			t.Fatal(err)
+		}
+
+		return art.ID
+	}
+
+	if id := next(); id != "e" {
+		t.Errorf("expecting e, got %s", id)
+	}
+
+	now := time.Date(2024, 8, 12, 10, 0, 0, 0, time.UTC)
+	repo.mx.Lock()
+	// j is re-crawled without changes, it is not streamed
+	repo.arts = append(repo.arts,
+		models.NewsArticle{ID: "h", Source: models.Liputan6, CrawledAt: now, ChangedAt: now},
+		models.NewsArticle{ID: "j", Source: models.Detik, CrawledAt: now, ChangedAt: now.Add(-5 * time.Hour)},
+		models.NewsArticle{ID: "i", Source: models.Detik, CrawledAt: now, ChangedAt: now},
+	)
+	repo.mx.Unlock()
+
+	if id := next(); id != "i" {
+		t.Errorf("expecting i, got %s", id)
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	h := newTestServer().Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil))
+
+	var spec struct {
+		Paths map[string]any `yaml:"paths"`
+	}
+
+	if err := yaml.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(spec.Paths) == 0 {
+		t.Fatal("expecting paths")
+	}
+
+	// every path of the spec is routed
+	param := regexp.MustCompile(`\{\w+\}`)
+	for path := range spec.Paths {
+		if path == "/articles/stream" {
+			continue
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, param.ReplaceAllString(path, "x"), nil))
+		if rec.Code == http.StatusNotFound && strings.Contains(rec.Body.String(), "page not found") {
+			t.Errorf("%s is not routed", path)
+		}
+	}
+}
+
+// waitSubscribers waits until the feed client is subscribed
+func waitSubscribers(t *testing.T, hub *pubsub.Hub, n int) {
+	t.Helper()
+
+	for i := 0; hub.Subscribers() != n; i++ {
+		if i == 100 {
+			t.Fatalf("expecting %d subscribers, got %d", n, hub.Subscribers())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFeedSSE(t *testing.T) {
+	hub := pubsub.NewHub()
+	ts := httptest.NewServer(newTestServer().WithHub(hub).Handler())
+	defer ts.Close()
+
+	a := hub.Publish(pubsub.EventCreated, models.NewsArticle{ID: "a", Source: models.Detik})
+	hub.Publish(pubsub.EventCreated, models.NewsArticle{ID: "b", Source: models.Liputan6})
+	hub.Publish(pubsub.EventUpdated, models.NewsArticle{ID: "c", Source: models.Detik})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/feed?source=Detik.com", nil)
+	req.Header.Set("Last-Event-ID", strconv.FormatUint(a.ID, 10))
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer res.Body.Close()
+
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("unexpected content type %s", ct)
+	}
+
+	lines := bufio.NewScanner(res.Body)
+	next := func() (id, event string, art models.ArticleWithID) {
+		for lines.Scan() {
+			line := lines.Text()
+			switch {
+			case line == "" && event != "":
+				return id, event, art
+
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: ")
+
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+
+			case strings.HasPrefix(line, "data: "):
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &art); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		t.Fatal("expecting event")
+
+		return
+	}
+
+	// resumed after a, b is filtered out
+	if _, event, art := next(); event != pubsub.EventUpdated || art.ID != "c" {
+		t.Errorf("expecting c updated, got %s %s", art.ID, event)
+	}
+
+	waitSubscribers(t, hub, 1)
+	d := hub.Publish(pubsub.EventCreated, models.NewsArticle{ID: "d", Source: models.Detik})
+	if id, event, art := next(); event != pubsub.EventCreated || art.ID != "d" || id != strconv.FormatUint(d.ID, 10) {
+		t.Errorf("expecting d created, got %s %s %s", id, art.ID, event)
+	}
+
+	cancel()
+	waitSubscribers(t, hub, 0)
+}
+
+func TestFeedReset(t *testing.T) {
+	ts := httptest.NewServer(newTestServer().WithHub(pubsub.NewHub()).Handler())
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the last event is published before the hub is started
+	since := time.Date(2024, 8, 12, 10, 0, 0, 0, time.UTC)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/feed", nil)
+	req.Header.Set("Last-Event-ID", strconv.FormatInt(since.UnixNano(), 10))
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer res.Body.Close()
+
+	var event, data string
+	lines := bufio.NewScanner(res.Body)
+	for event == "" && lines.Scan() {
+		if str, ok := strings.CutPrefix(lines.Text(), "event: "); ok {
+			event = str
+			lines.Scan()
+			data = strings.TrimPrefix(lines.Text(), "data: ")
+		}
+	}
+
+	var body struct {
+		Since time.Time `json:"since"`
+	}
+
+	if err := json.Unmarshal([]byte(data), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	if event != "reset" || !body.Since.Equal(since) {
+		t.Errorf("expecting reset since %s, got %s %s", since, event, data)
+	}
+}
+
+func TestFeedWebSocket(t *testing.T) {
+	hub := pubsub.NewHub()
+	ts := httptest.NewServer(newTestServer().WithHub(hub).Handler())
+	defer ts.Close()
+
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/feed/ws?entity=Demak", "", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	waitSubscribers(t, hub, 1)
+	hub.Publish(pubsub.EventCreated, models.NewsArticle{ID: "a"})
+	hub.Publish(pubsub.EventCreated, models.NewsArticle{ID: "b", Entities: []models.ArticleEntity{{Key: "demak"}}})
+
+	var msg feedEvent
+	if err := websocket.JSON.Receive(conn, &msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.Type != pubsub.EventCreated || msg.Article == nil || msg.Article.ID != "b" || msg.ID == "" {
+		t.Errorf("unexpected message %+v", msg)
+	}
+
+	conn.Close()
+	waitSubscribers(t, hub, 0)
+}
+
+func TestFeedUnavailable(t *testing.T) {
+	tests := []struct {
+		srv  *Server
+		path string
+		code int
+	}{
+		{newTestServer(), "/feed", http.StatusServiceUnavailable},
+		{newTestServer(), "/feed/ws", http.StatusServiceUnavailable},
+		{newTestServer().WithHub(pubsub.NewHub()), "/feed?last_event_id=x", http.StatusBadRequest},
+		{newTestServer().WithHub(pubsub.NewHub()), "/feed/ws?q=yang", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		tt.srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s: expecting status %d, got %d", tt.path, tt.code, rec.Code)
+		}
+	}
+}
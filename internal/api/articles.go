@@ -0,0 +1,272 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idnlp"
+	"github.com/tamboto2000/ivosight-crawler/pkg/urlnorm"
+)
+
+const (
+	// streamPollInterval is how often the stream checks
+	// for the new articles
+	streamPollInterval = 5 * time.Second
+	streamBatchSize    = 100
+)
+
+// listArticles lists the articles filtered by source, channel,
+// since, until and q query, the latest published first. The next
+// page is got by passing next_cursor as cursor query
+func (srv *Server) listArticles(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseArticleFilter(w, r)
+	if !ok {
+		return
+	}
+
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	var afterPublishedAt time.Time
+	var afterID string
+	if str := r.URL.Query().Get("cursor"); str != "" {
+		afterPublishedAt, afterID, ok = decodeCursor(str)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+	}
+
+	arts, err := srv.repo.ListArticles(r.Context(), filter, afterPublishedAt, afterID, limit)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	list := make([]models.ArticleWithID, 0, len(arts))
+	for _, art := range arts {
+		list = append(list, art.WithID())
+	}
+
+	res := map[string]any{"articles": list, "next_cursor": nil}
+	if len(arts) == limit {
+		last := arts[len(arts)-1]
+		res["next_cursor"] = encodeCursor(last.PublishedAt, last.ID)
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// getArticle gets an article by its ID
+func (srv *Server) getArticle(w http.ResponseWriter, r *http.Request) {
+	art, ok, err := srv.repo.FindArticleByID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "article not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, art.WithID())
+}
+
+// articleVersions lists the previous versions of an article, the
+// latest first, with the diff of each version to the next one
+func (srv *Server) articleVersions(w http.ResponseWriter, r *http.Request) {
+	art, ok, err := srv.repo.FindArticleByID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "article not found")
+		return
+	}
+
+	versions, err := srv.repo.ArticleVersions(r.Context(), art.ID)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	if versions == nil {
+		versions = []models.ArticleVersion{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"version": art.Version, "versions": versions})
+}
+
+// lookupArticle gets an article by its URL, either the canonical
+// URL or the URLs it is redirected from
+func (srv *Server) lookupArticle(w http.ResponseWriter, r *http.Request) {
+	str := r.URL.Query().Get("url")
+	if str == "" {
+		writeError(w, http.StatusBadRequest, "url is required")
+		return
+	}
+
+	link, err := urlnorm.Normalize(str)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid url")
+		return
+	}
+
+	art, ok, err := srv.repo.FindArticleByLink(r.Context(), link)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "article not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, art.WithID())
+}
+
+// streamArticles streams the articles crawled since since query, or
+// from now, as newline delimited JSON until the client disconnects.
+// A re-crawled article is streamed again with its changes
+func (srv *Server) streamArticles(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseArticleFilter(w, r)
+	if !ok {
+		return
+	}
+
+	// since is the publish time in the other endpoints, here
+	// it is the time the article is created or changed
+	since := srv.now()
+	filter.Since = time.Time{}
+	if r.URL.Query().Get("since") != "" {
+		since, ok = parseTime(w, r, "since")
+		if !ok {
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx := r.Context()
+	enc := json.NewEncoder(w)
+	ticker := time.NewTicker(srv.pollInterval)
+	defer ticker.Stop()
+
+	// streamed is the articles streamed at since, the next
+	// poll gets them again as since is inclusive
+	streamed := make(map[string]bool)
+	for {
+		arts, err := srv.repo.ArticlesChangedSince(ctx, filter, since, streamBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error(err.Error())
+			}
+
+			return
+		}
+
+		for _, art := range arts {
+			if art.ChangedAt.After(since) {
+				since = art.ChangedAt
+				streamed = make(map[string]bool)
+			}
+
+			if streamed[art.ID] {
+				continue
+			}
+
+			streamed[art.ID] = true
+			if err := enc.Encode(art.WithID()); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+	}
+}
+
+// parseArticleFilter parses source, channel, since, until
+// and q query
+func parseArticleFilter(w http.ResponseWriter, r *http.Request) (models.ArticleFilter, bool) {
+	query := r.URL.Query()
+	filter := models.ArticleFilter{
+		Source:  query.Get("source"),
+		Channel: models.NormalizeTerm(query.Get("channel")),
+	}
+
+	var ok bool
+	if query.Get("since") != "" {
+		if filter.Since, ok = parseTime(w, r, "since"); !ok {
+			return filter, false
+		}
+	}
+
+	if query.Get("until") != "" {
+		if filter.Until, ok = parseTime(w, r, "until"); !ok {
+			return filter, false
+		}
+	}
+
+	if q := strings.TrimSpace(query.Get("q")); q != "" {
+		filter.Terms = idnlp.Terms(q)
+		if len(filter.Terms) == 0 {
+			writeError(w, http.StatusBadRequest, "q has no searchable words")
+			return filter, false
+		}
+	}
+
+	return filter, true
+}
+
+// encodeCursor encodes the position of the last article of a page,
+// the publish time is in milliseconds as stored in MongoDB
+func encodeCursor(publishedAt time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(publishedAt.UnixMilli(), 10) + "." + id))
+}
+
+func decodeCursor(cursor string) (time.Time, string, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+
+	msStr, id, ok := strings.Cut(string(data), ".")
+	if !ok || id == "" {
+		return time.Time{}, "", false
+	}
+
+	ms, err := strconv.ParseInt(msStr, 10, 64)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+
+	return time.UnixMilli(ms).UTC(), id, true
+}
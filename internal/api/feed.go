@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/internal/pubsub"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idnlp"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// feedPingInterval is how often a comment is sent to keep
+	// the idle event stream open through the proxies
+	feedPingInterval = 15 * time.Second
+	// feedRetry is how long the browser waits before
+	// reconnecting the event stream
+	feedRetry = 3 * time.Second
+)
+
+// feedReset is the event sent first when the events after the
+// last event ID is no longer kept, like after a restart
+const feedReset = "reset"
+
+// feedEvent is the WebSocket message of an event, the ID is a
+// string as it does not fit in a JavaScript number
+type feedEvent struct {
+	ID      string                `json:"id,omitempty"`
+	Type    string                `json:"type"`
+	Article *models.ArticleWithID `json:"article,omitempty"`
+	Error   string                `json:"error,omitempty"`
+	Since   *time.Time            `json:"since,omitempty"`
+}
+
+// missedSince gets the publish time of the last event the
+// subscriber got, the missed articles is changed since then
+func missedSince(r *http.Request) time.Time {
+	id, _ := lastEventID(r)
+	return time.Unix(0, int64(id)).UTC()
+}
+
+// feedSSE streams the stored articles matching source, q and
+// entity query as server-sent events. The stream is resumed
+// from Last-Event-ID header, or last_event_id query
+func (srv *Server) feedSSE(w http.ResponseWriter, r *http.Request) {
+	sub, ok := srv.subscribe(w, r)
+	if !ok {
+		return
+	}
+
+	defer sub.Close()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// nginx buffers the response otherwise
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", feedRetry.Milliseconds())
+
+	// the client fetches the missed articles from /articles/stream
+	if sub.Missed() {
+		data, _ := json.Marshal(map[string]time.Time{"since": missedSince(r)})
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", feedReset, data)
+	}
+
+	flusher.Flush()
+
+	ping := time.NewTicker(srv.pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+
+		case event, ok := <-sub.Events():
+			if !ok {
+				if err := sub.Err(); err != nil {
+					data, _ := json.Marshal(map[string]string{"error": err.Error()})
+					fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+					flusher.Flush()
+				}
+
+				return
+			}
+
+			data, err := json.Marshal(event.Article.WithID())
+			if err != nil {
+				slog.Error(err.Error())
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
+
+// feedWebSocket is like feedSSE, but over WebSocket. Each event is
+// a JSON message, and the last event ID is only read from the query
+func (srv *Server) feedWebSocket(w http.ResponseWriter, r *http.Request) {
+	sub, ok := srv.subscribe(w, r)
+	if !ok {
+		return
+	}
+
+	defer sub.Close()
+
+	ws := websocket.Server{
+		// the feed is public like the other endpoints,
+		// so any origin is accepted
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+
+			// the client sends nothing, reading only
+			// finds out when it is gone
+			go func() {
+				io.Copy(io.Discard, conn)
+				cancel()
+			}()
+
+			if sub.Missed() {
+				since := missedSince(r)
+				if err := websocket.JSON.Send(conn, feedEvent{Type: feedReset, Since: &since}); err != nil {
+					return
+				}
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+
+				case event, ok := <-sub.Events():
+					if !ok {
+						if err := sub.Err(); err != nil {
+							websocket.JSON.Send(conn, feedEvent{Type: "error", Error: err.Error()})
+						}
+
+						return
+					}
+
+					art := event.Article.WithID()
+					msg := feedEvent{
+						ID:      strconv.FormatUint(event.ID, 10),
+						Type:    event.Type,
+						Article: &art,
+					}
+
+					if err := websocket.JSON.Send(conn, msg); err != nil {
+						return
+					}
+				}
+			}
+		},
+	}
+
+	ws.ServeHTTP(w, r)
+}
+
+// subscribe subscribes to the hub with the filter of the query
+func (srv *Server) subscribe(w http.ResponseWriter, r *http.Request) (*pubsub.Subscription, bool) {
+	if srv.hub == nil {
+		writeError(w, http.StatusServiceUnavailable, "live feed is only served by the crawler, see API_IN_CRAWLER")
+		return nil, false
+	}
+
+	query := r.URL.Query()
+
+	var filter pubsub.Filter
+	for _, str := range query["source"] {
+		for _, source := range strings.Split(str, ",") {
+			if source = strings.TrimSpace(source); source != "" {
+				filter.Sources = append(filter.Sources, source)
+			}
+		}
+	}
+
+	if q := strings.TrimSpace(query.Get("q")); q != "" {
+		filter.Terms = idnlp.Terms(q)
+		if len(filter.Terms) == 0 {
+			writeError(w, http.StatusBadRequest, "q has no searchable words")
+			return nil, false
+		}
+	}
+
+	for _, name := range query["entity"] {
+		filter.Entities = append(filter.Entities, models.EntityKey(name))
+	}
+
+	id, err := lastEventID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid last event ID")
+		return nil, false
+	}
+
+	return srv.hub.Subscribe(filter, id), true
+}
+
+// lastEventID parses Last-Event-ID header, or last_event_id
+// query, it is 0 when there is none
+func lastEventID(r *http.Request) (uint64, error) {
+	str := r.Header.Get("Last-Event-ID")
+	if str == "" {
+		str = r.URL.Query().Get("last_event_id")
+	}
+
+	if str == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseUint(str, 10, 64)
+}
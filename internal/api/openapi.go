@@ -0,0 +1,19 @@
+package api
+
+import (
+	_ "embed"
+	"log/slog"
+	"net/http"
+)
+
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// serveOpenAPI serves the OpenAPI spec of the API, it should
+// be updated along the routes
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	if _, err := w.Write(openAPISpec); err != nil {
+		slog.Error(err.Error())
+	}
+}
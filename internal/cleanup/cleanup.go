@@ -0,0 +1,189 @@
+// Package cleanup removes boilerplate blocks, like "Baca juga" links,
+// ads, newsletter promos and social media embeds, from the contents of
+// the parsed articles. Every block is classified by the rules of its
+// source first, then the generic rules, and the removed blocks is
+// recorded on the article so the cleanup can be audited.
+package cleanup
+
+import (
+	"encoding/json"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/pkg/htmlutil"
+)
+
+// Reason is why a block is removed
+type Reason string
+
+const (
+	ReasonReadAlso    Reason = "read-also"
+	ReasonAd          Reason = "ad"
+	ReasonPromo       Reason = "promo"
+	ReasonSocialEmbed Reason = "social-embed"
+	ReasonBoilerplate Reason = "boilerplate"
+	ReasonEmpty       Reason = "empty"
+)
+
+// Block is a content block being classified
+type Block struct {
+	Index int
+	// Count is the number of blocks of the article
+	Count   int
+	Source  models.ArticleSource
+	Content models.ArticleContent
+	// Text is the plain text of text block, or the headline of
+	// referenced article, or the URL of image and video
+	Text string
+	// HTML is the sanitized HTML of text block
+	HTML string
+	// Prev is the previous block, nil for the first block
+	Prev *Block
+}
+
+// IsLast reports whether the block is the last block
+func (block Block) IsLast() bool {
+	return block.Index == block.Count-1
+}
+
+// Rule classifies a block. A block is removed when any rule
+// matches it
+type Rule interface {
+	Name() string
+	Classify(block Block) (Reason, bool)
+}
+
+// RuleFunc is a [Rule] from a function
+type RuleFunc struct {
+	RuleName string
+	Reason   Reason
+	Match    func(block Block) bool
+}
+
+func (rule RuleFunc) Name() string {
+	return rule.RuleName
+}
+
+func (rule RuleFunc) Classify(block Block) (Reason, bool) {
+	if rule.Match(block) {
+		return rule.Reason, true
+	}
+
+	return "", false
+}
+
+// Pipeline runs the rules over the article contents
+type Pipeline struct {
+	rules     []Rule
+	siteRules map[models.ArticleSource][]Rule
+}
+
+// NewPipeline creates a pipeline with the generic rules, which
+// is run for articles of every source
+func NewPipeline(rules ...Rule) *Pipeline {
+	return &Pipeline{
+		rules:     rules,
+		siteRules: make(map[models.ArticleSource][]Rule),
+	}
+}
+
+// Default creates a pipeline with the built-in generic rules
+// and the rules of detik and liputan6
+func Default() *Pipeline {
+	p := NewPipeline(GenericRules()...)
+	p.AddSiteRules(models.Detik, DetikRules()...)
+	p.AddSiteRules(models.Liputan6, Liputan6Rules()...)
+
+	return p
+}
+
+// AddRules adds generic rules
+func (p *Pipeline) AddRules(rules ...Rule) {
+	p.rules = append(p.rules, rules...)
+}
+
+// AddSiteRules adds rules that is only run for articles of source
+func (p *Pipeline) AddSiteRules(source models.ArticleSource, rules ...Rule) {
+	p.siteRules[source] = append(p.siteRules[source], rules...)
+}
+
+// Clean removes the boilerplate blocks from the article contents.
+// The removed blocks is appended to art.RemovedContents and returned
+func (p *Pipeline) Clean(art *models.NewsArticle) []models.RemovedContent {
+	var kept []models.ArticleContent
+	var removed []models.RemovedContent
+
+	rules := append(append([]Rule(nil), p.siteRules[art.Source]...), p.rules...)
+
+	var prev *Block
+	for i, content := range art.Contents {
+		block := newBlock(art, i, content)
+		block.Prev = prev
+		prev = &block
+
+		matched := false
+		for _, rule := range rules {
+			reason, ok := rule.Classify(block)
+			if !ok {
+				continue
+			}
+
+			removed = append(removed, models.RemovedContent{
+				Index:   i,
+				Rule:    rule.Name(),
+				Reason:  string(reason),
+				Content: content,
+			})
+
+			matched = true
+			break
+		}
+
+		if !matched {
+			kept = append(kept, content)
+		}
+	}
+
+	art.Contents = kept
+	art.RemovedContents = append(art.RemovedContents, removed...)
+
+	return removed
+}
+
+func newBlock(art *models.NewsArticle, i int, content models.ArticleContent) Block {
+	block := Block{
+		Index:   i,
+		Count:   len(art.Contents),
+		Source:  art.Source,
+		Content: content,
+	}
+
+	switch content.Type {
+	case models.ContentParagraphText, models.ContentSectionTitle:
+		if text, ok := content.TextContent(); ok {
+			block.Text = text.Text
+			block.HTML = text.HTML
+		}
+
+	case models.ContentReferencedArticle:
+		var ref models.ArticleReferenceContent
+		json.Unmarshal(content.Data, &ref)
+		block.Text = ref.Headline
+
+	case models.ContentImage:
+		var img models.ArticleImageContent
+		json.Unmarshal(content.Data, &img)
+		block.Text = img.URL
+
+	case models.ContentVideo:
+		var vid models.ArticleVideoContent
+		json.Unmarshal(content.Data, &vid)
+		block.Text = vid.EmbeddedURL
+		if block.Text == "" {
+			block.Text = vid.URL
+		}
+	}
+
+	block.Text = htmlutil.NormalizeText(block.Text)
+
+	return block
+}
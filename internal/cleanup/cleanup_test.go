@@ -0,0 +1,138 @@
+package cleanup
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+func textContent(contentType, text, html string) models.ArticleContent {
+	if html == "" {
+		html = "<p>" + text + "</p>"
+	}
+
+	data, _ := json.Marshal(models.ArticleTextContent{Text: text, HTML: html})
+	return models.ArticleContent{Type: contentType, Data: data}
+}
+
+func paragraph(text string) models.ArticleContent {
+	return textContent(models.ContentParagraphText, text, "")
+}
+
+func linkParagraph(text string) models.ArticleContent {
+	return textContent(models.ContentParagraphText, text, `<p><strong><a href="https://news.detik.com/x">`+text+`</a></strong></p>`)
+}
+
+func video(url string) models.ArticleContent {
+	data, _ := json.Marshal(models.ArticleVideoContent{EmbeddedURL: url})
+	return models.ArticleContent{Type: models.ContentVideo, Data: data}
+}
+
+func reference(headline string) models.ArticleContent {
+	data, _ := json.Marshal(models.ArticleReferenceContent{Headline: headline, ArticleLink: "https://news.detik.com/y"})
+	return models.ArticleContent{Type: models.ContentReferencedArticle, Data: data}
+}
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  models.ArticleSource
+		content models.ArticleContent
+		reason  Reason
+	}{
+		{"story", models.Detik, paragraph("Presiden meresmikan jalan tol baru di Jawa Tengah."), ""},
+		{"story mentioning instagram", models.Detik, paragraph("Ia mengunggah foto itu di akun Instagram pribadinya pada Senin malam, dan langsung viral."), ""},
+		{"empty", models.Detik, paragraph(" • "), ReasonEmpty},
+		{"referenced article", models.Detik, reference("Jokowi Resmikan Tol"), ReasonReadAlso},
+		{"baca juga", models.Liputan6, paragraph("Baca Juga: Harga BBM Naik Lagi"), ReasonReadAlso},
+		{"simak juga", models.Liputan6, paragraph("Simak juga:"), ReasonReadAlso},
+		{"advertisement", models.Detik, paragraph("ADVERTISEMENT"), ReasonAd},
+		{"scroll to continue", models.Detik, paragraph("SCROLL TO CONTINUE WITH CONTENT"), ReasonAd},
+		{"google news", models.Detik, paragraph("Ikuti berita terkini dari detikcom di Google News"), ReasonPromo},
+		{"whatsapp channel", models.Liputan6, paragraph("Dapatkan update berita pilihan setiap hari melalui WhatsApp Channel kami."), ReasonPromo},
+		{"tweet", models.Detik, paragraph("Selamat pagi pic.twitter.com/abc123 — Kemenkes RI (@KemenkesRI) August 12, 2024"), ReasonSocialEmbed},
+		{"instagram text", models.Liputan6, paragraph("View this post on Instagram"), ReasonSocialEmbed},
+		{"instagram video", models.Liputan6, video("https://www.instagram.com/p/abc/embed"), ReasonSocialEmbed},
+		{"video", models.Detik, video("https://20.detik.com/embed/123"), ""},
+		{"detik signature", models.Detik, paragraph("(rdp/imk)"), ReasonBoilerplate},
+		{"detik signature on liputan6", models.Liputan6, paragraph("(rdp/imk)"), ""},
+		{"detik video", models.Detik, paragraph("[Gambas:Video 20detik]"), ReasonBoilerplate},
+		{"liputan6 video", models.Liputan6, paragraph("Saksikan Video Pilihan di Bawah Ini:"), ReasonBoilerplate},
+		{"liputan6 cek fakta", models.Liputan6, paragraph("* Fakta atau Hoaks? Untuk mengetahui kebenaran informasi yang beredar, silakan WhatsApp ke nomor Cek Fakta Liputan6.com 0811 9787 670"), ReasonBoilerplate},
+	}
+
+	p := Default()
+	for _, tt := range tests {
+		art := models.NewsArticle{
+			Source:   tt.source,
+			Contents: []models.ArticleContent{tt.content},
+		}
+
+		removed := p.Clean(&art)
+		if tt.reason == "" {
+			if len(removed) != 0 {
+				t.Errorf("%s: expecting not removed, removed by %s", tt.name, removed[0].Rule)
+			}
+
+			continue
+		}
+
+		if len(removed) != 1 {
+			t.Errorf("%s: expecting removed as %s", tt.name, tt.reason)
+			continue
+		}
+
+		if removed[0].Reason != string(tt.reason) {
+			t.Errorf("%s: expecting %s, got %s by %s", tt.name, tt.reason, removed[0].Reason, removed[0].Rule)
+		}
+
+		if len(art.Contents) != 0 || len(art.RemovedContents) != 1 {
+			t.Errorf("%s: expecting the block to be moved to removed contents", tt.name)
+		}
+	}
+}
+
+func TestCleanReadAlsoList(t *testing.T) {
+	art := models.NewsArticle{
+		Source: models.Liputan6,
+		Contents: []models.ArticleContent{
+			paragraph("Paragraf pertama."),
+			linkParagraph("Bukan daftar baca juga"),
+			paragraph("Baca juga:"),
+			linkParagraph("Berita satu"),
+			linkParagraph("Berita dua"),
+			paragraph("Paragraf kedua."),
+		},
+	}
+
+	removed := Default().Clean(&art)
+	if len(removed) != 3 {
+		t.Fatalf("expecting 3 removed blocks, got %d", len(removed))
+	}
+
+	wantIdx := []int{2, 3, 4}
+	for i, rem := range removed {
+		if rem.Index != wantIdx[i] {
+			t.Errorf("expecting removed index %d, got %d", wantIdx[i], rem.Index)
+		}
+	}
+
+	if len(art.Contents) != 3 {
+		t.Errorf("expecting 3 kept blocks, got %d", len(art.Contents))
+	}
+}
+
+func TestCustomRule(t *testing.T) {
+	p := NewPipeline()
+	p.AddSiteRules("Kompas.com", TextRule("kompas-promo", ReasonPromo, promoRgx))
+
+	art := models.NewsArticle{
+		Source:   "Kompas.com",
+		Contents: []models.ArticleContent{paragraph("Ikuti kami di Google News")},
+	}
+
+	if removed := p.Clean(&art); len(removed) != 1 || removed[0].Rule != "kompas-promo" {
+		t.Errorf("expecting removed by kompas-promo, got %+v", removed)
+	}
+}
@@ -0,0 +1,139 @@
+package cleanup
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+// TextRule creates a rule that matches the text of paragraphs and
+// section titles with rgx
+func TextRule(name string, reason Reason, rgx *regexp.Regexp) Rule {
+	return RuleFunc{
+		RuleName: name,
+		Reason:   reason,
+		Match: func(block Block) bool {
+			return isText(block) && rgx.MatchString(block.Text)
+		},
+	}
+}
+
+// TypeRule creates a rule that matches every block of contentType
+func TypeRule(name string, reason Reason, contentType string) Rule {
+	return RuleFunc{
+		RuleName: name,
+		Reason:   reason,
+		Match: func(block Block) bool {
+			return block.Content.Type == contentType
+		},
+	}
+}
+
+var (
+	readAlsoRgx = regexp.MustCompile(`(?i)^\W*(baca|lihat|simak|cek) (juga|pula|selengkapnya|berita lainnya)\b`)
+	// link only paragraph, like the list after "Baca juga:"
+	linkOnlyRgx = regexp.MustCompile(`(?is)^\s*(<p>)?\s*(<(strong|b|em|i)>\s*)*<a [^>]*>.*</a>\s*(</(strong|b|em|i)>\s*)*(</p>)?\s*$`)
+
+	adRgx = regexp.MustCompile(`(?i)^\W*(advertisement|iklan|scroll to (continue|resume) with content|lanjutkan membaca di bawah|konten berbayar berikut)\W*$`)
+
+	promoRgx = regexp.MustCompile(`(?i)((ikuti|follow|gabung|bergabung)\b.{0,80}\b(google news|whatsapp|wa channel|telegram|instagram|twitter|tiktok|youtube|facebook|saluran)|dapatkan (berita|update|info|informasi)\b.{0,80}\b(di|melalui|lewat)\b|download aplikasi|unduh aplikasi|berlangganan newsletter|subscribe (to )?(our )?newsletter|klik (di ?sini|link berikut) untuk)`)
+
+	socialEmbedRgx = regexp.MustCompile(`(?i)(pic\.twitter\.com/|—\s*.{0,80}\(@\w+\)\s+\w+ \d{1,2}, \d{4}|view this post on instagram|lihat postingan ini di instagram|a post shared by|sebuah kiriman dibagikan oleh|♬ .+ - |\[gambas:(instagram|twitter|tiktok|facebook|youtube)\])`)
+
+	socialHostRgx = regexp.MustCompile(`(?i)^https?://([a-z0-9-]+\.)*(twitter\.com|x\.com|instagram\.com|tiktok\.com|facebook\.com|fb\.watch|threads\.net)/`)
+)
+
+// GenericRules is the rules that is run for every source
+func GenericRules() []Rule {
+	return []Rule{
+		RuleFunc{
+			RuleName: "empty-text",
+			Reason:   ReasonEmpty,
+			Match: func(block Block) bool {
+				return isText(block) && strings.Trim(block.Text, " -*•|.") == ""
+			},
+		},
+		TypeRule("referenced-article", ReasonReadAlso, models.ContentReferencedArticle),
+		TextRule("read-also", ReasonReadAlso, readAlsoRgx),
+		RuleFunc{
+			RuleName: "read-also-list",
+			Reason:   ReasonReadAlso,
+			Match: func(block Block) bool {
+				if !isText(block) || block.Prev == nil || !linkOnlyRgx.MatchString(block.HTML) {
+					return false
+				}
+
+				// the links after "Baca juga:", or after the link
+				// before it which is removed by this rule as well
+				prev := block.Prev
+				for prev != nil && isText(*prev) && linkOnlyRgx.MatchString(prev.HTML) {
+					prev = prev.Prev
+				}
+
+				return prev != nil && (readAlsoRgx.MatchString(prev.Text) || prev.Content.Type == models.ContentReferencedArticle)
+			},
+		},
+		TextRule("ad-placeholder", ReasonAd, adRgx),
+		RuleFunc{
+			RuleName: "promo",
+			Reason:   ReasonPromo,
+			Match: func(block Block) bool {
+				// only short paragraphs, a long paragraph that
+				// mentions it is likely part of the story
+				return isText(block) && len(block.Text) < 300 && promoRgx.MatchString(block.Text)
+			},
+		},
+		TextRule("social-embed-text", ReasonSocialEmbed, socialEmbedRgx),
+		RuleFunc{
+			RuleName: "social-embed-video",
+			Reason:   ReasonSocialEmbed,
+			Match: func(block Block) bool {
+				return block.Content.Type == models.ContentVideo && socialHostRgx.MatchString(block.Text)
+			},
+		},
+	}
+}
+
+var (
+	// detik puts the editor initials at the end, like (rdp/imk)
+	detikSignatureRgx = regexp.MustCompile(`^\(\s*[a-z]{2,5}(\s*/\s*[a-z]{2,5})+\s*\)$`)
+	detikBoilerRgx    = regexp.MustCompile(`(?i)^(\[gambas:video 20detik\]|simak video\b|saksikan (juga )?(live )?detik(pagi|sore|petang|news)\b|tonton juga video\b|halaman selanjutnya\b|selengkapnya di halaman (selanjutnya|berikutnya)\b)`)
+)
+
+// DetikRules is the rules of Detik.com
+func DetikRules() []Rule {
+	return []Rule{
+		RuleFunc{
+			RuleName: "detik-signature",
+			Reason:   ReasonBoilerplate,
+			Match: func(block Block) bool {
+				return isText(block) && detikSignatureRgx.MatchString(block.Text)
+			},
+		},
+		TextRule("detik-boilerplate", ReasonBoilerplate, detikBoilerRgx),
+	}
+}
+
+var (
+	liputan6BoilerRgx = regexp.MustCompile(`(?i)^\W*((saksikan|simak|tonton) (juga )?video (pilihan|berikut|di bawah)\b|video (pilihan|terkini|populer) (hari ini|di bawah ini)\b|fakta atau hoaks\?|liputan6\.com adalah verified fact checking partner\b|untuk mengetahui kebenaran informasi yang beredar\b)`)
+	liputan6PromoRgx  = regexp.MustCompile(`(?i)(whatsapp ke nomor cek fakta|cek fakta liputan6\.com|ayo ikuti|\bshare\s*:)`)
+)
+
+// Liputan6Rules is the rules of Liputan6.com
+func Liputan6Rules() []Rule {
+	return []Rule{
+		TextRule("liputan6-boilerplate", ReasonBoilerplate, liputan6BoilerRgx),
+		RuleFunc{
+			RuleName: "liputan6-promo",
+			Reason:   ReasonPromo,
+			Match: func(block Block) bool {
+				return isText(block) && len(block.Text) < 400 && liputan6PromoRgx.MatchString(block.Text)
+			},
+		},
+	}
+}
+
+func isText(block Block) bool {
+	return block.Content.Type == models.ContentParagraphText || block.Content.Type == models.ContentSectionTitle
+}
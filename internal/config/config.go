@@ -14,6 +14,7 @@ type MongoDB struct {
 	Port     string
 	Username string
 	Password string
+	Database string
 }
 
 func (mongo MongoDB) ToURL() string {
@@ -28,21 +29,154 @@ type Crawler struct {
 	UseProxyList           bool
 	ProxyList              []string
 	RandomRunIntervalRange []int64
-	RespectRobotsTxt       bool
+	// RandomCrawlArticleIntervalRange is the random interval range,
+	// in seconds, between each article crawl
+	RandomCrawlArticleIntervalRange []int64
+	RespectRobotsTxt                bool
+	// SiteRulesDir is the directory of the declarative site rule
+	// files, see pkg/siterules
+	SiteRulesDir string
+	// SiteRulesReloadInterval is the interval, in seconds, of
+	// checking the site rule files for changes
+	SiteRulesReloadInterval int
+	// Recrawl enables re-crawling the recent articles to track
+	// the edits made after the article is published
+	Recrawl bool
+	// RecrawlWindow is how long, in hours, an article is
+	// re-crawled after it is published
+	RecrawlWindow int
+	// RecrawlInterval is the minimum interval, in minutes,
+	// between the crawls of the same article
+	RecrawlInterval int
+	// RecrawlBatchSize is the maximum number of articles queued
+	// for re-crawl on each round
+	RecrawlBatchSize int
+	// TombstoneCheck enables re-checking the stored article
+	// links to find the deleted or retracted articles
+	TombstoneCheck bool
+	// TombstoneCheckWindow is how long, in days, an article
+	// is checked after it is published
+	TombstoneCheckWindow int
+	// TombstoneCheckInterval is the minimum interval, in hours,
+	// between the checks of the same article
+	TombstoneCheckInterval int
+	// TombstoneCheckBatchSize is the maximum number of articles
+	// checked on each round
+	TombstoneCheckBatchSize int
+	// DedupThreshold is the minimum body similarity, from 0 to 1,
+	// of the near-duplicate articles
+	DedupThreshold float64
+	// DedupWindow is how far apart, in days, the near-duplicate
+	// articles can be published
+	DedupWindow int
+	// StoryClusterThreshold is the minimum similarity, from 0 to 1,
+	// of an article to a story cluster to be added to it
+	StoryClusterThreshold float64
+	// StoryClusterWindow is how long, in hours, a story cluster is
+	// open for new articles after its last article
+	StoryClusterWindow int
+	// EntityWatchlist is the gazetteer file of the people,
+	// organizations and locations to track, in addition to
+	// the default gazetteer, see internal/entity
+	EntityWatchlist string
+	// SentimentLexicon is the polarity lexicon file replacing the
+	// default lexicon, see internal/sentiment
+	SentimentLexicon string
+	// Trend enables finding the trending terms, phrases and
+	// entities of the crawled articles
+	Trend bool
+	// TrendWindow is how long, in hours, the recent window
+	// compared to the baseline is
+	TrendWindow int
+	// TrendBaseline is how long, in days, the baseline before
+	// the window is
+	TrendBaseline int
+	// TrendSnapshotInterval is the interval, in minutes, of
+	// storing the trends snapshot
+	TrendSnapshotInterval int
+	// SummarySentences is the number of sentences of the
+	// article summary
+	SummarySentences int
+	// Webhook enables delivering the stored articles to
+	// the registered webhook endpoints
+	Webhook bool
+	// WebhookMaxAttempts is the attempts of a delivery
+	// before it is kept as a dead letter
+	WebhookMaxAttempts int
+	// WebhookTimeout is the timeout, in seconds, of
+	// a delivery attempt
+	WebhookTimeout int
+}
+
+type API struct {
+	// Addr is the address the API server listens on
+	Addr string
+	// InCrawler serves the API from the crawler as well,
+	// instead of running the api command on its own
+	InCrawler bool
+	// FeedHistory is the number of recent articles kept for the
+	// live feed subscribers to resume from
+	FeedHistory int
+	// FeedBuffer is the number of articles a live feed subscriber
+	// can fall behind before it is disconnected
+	FeedBuffer int
+}
+
+type Search struct {
+	// IndexDir is the directory of the full-text search index
+	IndexDir string
 }
 
 type Config struct {
 	MongoDB MongoDB
 	Crawler Crawler
+	API     API
+	Search  Search
 }
 
 const (
+	defaultMongoDatabase  = "ivosight"
 	defaultMaxThreadCount = 12
 	defaultUseProxy       = false
 	defaultUseProxyScrape = false
+
+	defaultSiteRulesDir            = "rules"
+	defaultSiteRulesReloadInterval = 60
+
+	defaultRecrawlWindow    = 48
+	defaultRecrawlInterval  = 60
+	defaultRecrawlBatchSize = 50
+
+	defaultTombstoneCheckWindow    = 30
+	defaultTombstoneCheckInterval  = 24
+	defaultTombstoneCheckBatchSize = 100
+
+	defaultDedupThreshold = 0.8
+	defaultDedupWindow    = 7
+
+	defaultStoryClusterThreshold = 0.35
+	defaultStoryClusterWindow    = 48
+
+	defaultTrendWindow           = 6
+	defaultTrendBaseline         = 7
+	defaultTrendSnapshotInterval = 15
+
+	defaultSummarySentences = 3
+
+	defaultWebhookMaxAttempts = 8
+	defaultWebhookTimeout     = 10
+
+	defaultAPIAddr     = ":8080"
+	defaultFeedHistory = 1000
+	defaultFeedBuffer  = 64
+
+	defaultSearchIndexDir = "data/search"
 )
 
-var defaultRandomRunIntervalRange = []int64{60, 300}
+var (
+	defaultRandomRunIntervalRange          = []int64{60, 300}
+	defaultRandomCrawlArticleIntervalRange = []int64{5, 10}
+)
 
 func LoadConfig() (Config, error) {
 	return parseConfig()
@@ -58,6 +192,7 @@ func parseConfig() (Config, error) {
 	mongoPort := os.Getenv("MONGO_PORT")
 	mongoUser := os.Getenv("MONGO_USERNAME")
 	mongoPwd := os.Getenv("MONGO_PASSWORD")
+	mongoDB := os.Getenv("MONGO_DATABASE")
 
 	maxThreadCount := os.Getenv("MAX_THREAD_COUNT")
 	useProxy := os.Getenv("USE_PROXY")
@@ -65,12 +200,46 @@ func parseConfig() (Config, error) {
 	useProxyList := os.Getenv("USE_PROXY_LIST")
 	proxyList := os.Getenv("PROXY_LIST")
 	randomRunInterval := os.Getenv("RANDOM_RUN_INTERVAL_RANGE")
+	randomCrawlArticleInterval := os.Getenv("RANDOM_CRAWL_ARTICLE_INTERVAL_RANGE")
+	respectRobotsTxt := os.Getenv("RESPECT_ROBOTS_TXT")
+	siteRulesDir := os.Getenv("SITE_RULES_DIR")
+	siteRulesReloadInterval := os.Getenv("SITE_RULES_RELOAD_INTERVAL")
+	recrawl := os.Getenv("RECRAWL")
+	recrawlWindow := os.Getenv("RECRAWL_WINDOW")
+	recrawlInterval := os.Getenv("RECRAWL_INTERVAL")
+	recrawlBatchSize := os.Getenv("RECRAWL_BATCH_SIZE")
+	tombstoneCheck := os.Getenv("TOMBSTONE_CHECK")
+	tombstoneCheckWindow := os.Getenv("TOMBSTONE_CHECK_WINDOW")
+	tombstoneCheckInterval := os.Getenv("TOMBSTONE_CHECK_INTERVAL")
+	tombstoneCheckBatchSize := os.Getenv("TOMBSTONE_CHECK_BATCH_SIZE")
+	dedupThreshold := os.Getenv("DEDUP_THRESHOLD")
+	dedupWindow := os.Getenv("DEDUP_WINDOW")
+	storyClusterThreshold := os.Getenv("STORY_CLUSTER_THRESHOLD")
+	storyClusterWindow := os.Getenv("STORY_CLUSTER_WINDOW")
+	entityWatchlist := os.Getenv("ENTITY_WATCHLIST")
+	sentimentLexicon := os.Getenv("SENTIMENT_LEXICON")
+	trend := os.Getenv("TREND")
+	trendWindow := os.Getenv("TREND_WINDOW")
+	trendBaseline := os.Getenv("TREND_BASELINE")
+	trendSnapshotInterval := os.Getenv("TREND_SNAPSHOT_INTERVAL")
+	summarySentences := os.Getenv("SUMMARY_SENTENCES")
+	webhook := os.Getenv("WEBHOOK")
+	webhookMaxAttempts := os.Getenv("WEBHOOK_MAX_ATTEMPTS")
+	webhookTimeout := os.Getenv("WEBHOOK_TIMEOUT")
+
+	apiAddr := os.Getenv("API_ADDR")
+	apiInCrawler := os.Getenv("API_IN_CRAWLER")
+	feedHistory := os.Getenv("FEED_HISTORY")
+	feedBuffer := os.Getenv("FEED_BUFFER")
+
+	searchIndexDir := os.Getenv("SEARCH_INDEX_DIR")
 
 	mongoCfg := MongoDB{
 		Host:     mongoHost,
 		Port:     mongoPort,
 		Username: mongoUser,
 		Password: mongoPwd,
+		Database: strOrDefault(mongoDB, defaultMongoDatabase),
 	}
 
 	crawlerCfg := Crawler{
@@ -80,14 +249,62 @@ func parseConfig() (Config, error) {
 		UseProxyList:           strToBool(useProxyList, false),
 		ProxyList:              strToStrSlice(proxyList, ",", []string{}),
 		RandomRunIntervalRange: strToInt64Slice(randomRunInterval, "-", defaultRandomRunIntervalRange),
+
+		RandomCrawlArticleIntervalRange: strToInt64Slice(randomCrawlArticleInterval, "-", defaultRandomCrawlArticleIntervalRange),
+		RespectRobotsTxt:                strToBool(respectRobotsTxt, false),
+		SiteRulesDir:                    strOrDefault(siteRulesDir, defaultSiteRulesDir),
+		SiteRulesReloadInterval:         strToInt(siteRulesReloadInterval, defaultSiteRulesReloadInterval),
+		Recrawl:                         strToBool(recrawl, true),
+		RecrawlWindow:                   strToInt(recrawlWindow, defaultRecrawlWindow),
+		RecrawlInterval:                 strToInt(recrawlInterval, defaultRecrawlInterval),
+		RecrawlBatchSize:                strToInt(recrawlBatchSize, defaultRecrawlBatchSize),
+		TombstoneCheck:                  strToBool(tombstoneCheck, true),
+		TombstoneCheckWindow:            strToInt(tombstoneCheckWindow, defaultTombstoneCheckWindow),
+		TombstoneCheckInterval:          strToInt(tombstoneCheckInterval, defaultTombstoneCheckInterval),
+		TombstoneCheckBatchSize:         strToInt(tombstoneCheckBatchSize, defaultTombstoneCheckBatchSize),
+		DedupThreshold:                  strToFloat(dedupThreshold, defaultDedupThreshold),
+		DedupWindow:                     strToInt(dedupWindow, defaultDedupWindow),
+		StoryClusterThreshold:           strToFloat(storyClusterThreshold, defaultStoryClusterThreshold),
+		StoryClusterWindow:              strToInt(storyClusterWindow, defaultStoryClusterWindow),
+		EntityWatchlist:                 entityWatchlist,
+		SentimentLexicon:                sentimentLexicon,
+		Trend:                           strToBool(trend, true),
+		TrendWindow:                     strToInt(trendWindow, defaultTrendWindow),
+		TrendBaseline:                   strToInt(trendBaseline, defaultTrendBaseline),
+		TrendSnapshotInterval:           strToInt(trendSnapshotInterval, defaultTrendSnapshotInterval),
+		SummarySentences:                strToInt(summarySentences, defaultSummarySentences),
+		Webhook:                         strToBool(webhook, true),
+		WebhookMaxAttempts:              strToInt(webhookMaxAttempts, defaultWebhookMaxAttempts),
+		WebhookTimeout:                  strToInt(webhookTimeout, defaultWebhookTimeout),
+	}
+
+	apiCfg := API{
+		Addr:        strOrDefault(apiAddr, defaultAPIAddr),
+		InCrawler:   strToBool(apiInCrawler, false),
+		FeedHistory: strToInt(feedHistory, defaultFeedHistory),
+		FeedBuffer:  strToInt(feedBuffer, defaultFeedBuffer),
+	}
+
+	searchCfg := Search{
+		IndexDir: strOrDefault(searchIndexDir, defaultSearchIndexDir),
 	}
 
 	cfg.MongoDB = mongoCfg
 	cfg.Crawler = crawlerCfg
+	cfg.API = apiCfg
+	cfg.Search = searchCfg
 
 	return cfg, nil
 }
 
+func strOrDefault(str string, def string) string {
+	if str == "" {
+		return def
+	}
+
+	return str
+}
+
 func strToInt(str string, def int) int {
 	i, err := strconv.Atoi(str)
 	if err != nil || i <= 0 {
@@ -97,6 +314,15 @@ func strToInt(str string, def int) int {
 	return i
 }
 
+func strToFloat(str string, def float64) float64 {
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil || f <= 0 {
+		return def
+	}
+
+	return f
+}
+
 func strToBool(str string, def bool) bool {
 	str = strings.ToLower(str)
 	switch str {
@@ -0,0 +1,386 @@
+package crawler
+
+import (
+	"encoding/json"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/pkg/detik"
+	"github.com/tamboto2000/ivosight-crawler/pkg/htmlutil/readability"
+	"github.com/tamboto2000/ivosight-crawler/pkg/liputan6"
+	"github.com/tamboto2000/ivosight-crawler/pkg/metadata"
+	"github.com/tamboto2000/ivosight-crawler/pkg/siterules"
+)
+
+func newsArticleFromDetik(art detik.Article) models.NewsArticle {
+	newsArt := models.NewsArticle{
+		Source:      models.Detik,
+		Link:        art.Link,
+		Headline:    art.Headline,
+		Description: art.Description,
+		PublishedAt: art.PublishedAt,
+		UpdatedAt:   art.UpdatedAt,
+		Author: models.ArticleAuthor{
+			Name: art.Author,
+		},
+	}
+
+	if img := art.HeadlineImage; img != nil {
+		newsArt.Image = models.ArticleImageContent{
+			URL:     img.URL,
+			Title:   img.Title,
+			Caption: img.Caption,
+			Alt:     img.Alt,
+		}
+	}
+
+	for _, content := range art.Contents {
+		var data any
+		switch content.Type {
+		case detik.Image:
+			img := content.ContentImage()
+			data = models.ArticleImageContent{
+				URL:     img.URL,
+				Title:   img.Title,
+				Caption: img.Caption,
+				Alt:     img.Alt,
+			}
+
+		case detik.Video:
+			vid, _ := content.Data.(detik.ContentVideo)
+			data = models.ArticleVideoContent{
+				URL:         vid.URL,
+				EmbeddedURL: vid.EmbeddedURL,
+				Title:       vid.Title,
+				Description: vid.Description,
+				Duration:    vid.Duration,
+				Thumbnail:   vid.ThumbnailURL,
+			}
+
+		case detik.ParagraphText, detik.SectionTitle:
+			text := content.ContentText()
+			data = models.ArticleTextContent{
+				HTML:     text.HTML,
+				Text:     text.Text,
+				Markdown: text.Markdown,
+			}
+
+		case detik.ReferencedArticleLink:
+			ref := content.ReferencedArticle()
+			data = models.ArticleReferenceContent{
+				Headline:    ref.Headline,
+				ArticleLink: ref.ArticleLink,
+			}
+
+		default:
+			data = content.Data
+		}
+
+		newsArt.Contents = appendContent(newsArt.Contents, string(content.Type), data)
+	}
+
+	for _, related := range art.RelatedArticles {
+		newsArt.RelatedArticles = append(newsArt.RelatedArticles, models.RelatedArticle{
+			Title:       related.Title,
+			ArticleLink: related.ArticleLink,
+		})
+	}
+
+	for _, tag := range art.Tags {
+		newsArt.Tags = append(newsArt.Tags, tag.Name)
+	}
+
+	newsArt.Categories = art.Categories
+	newsArt.Pages = art.Pages
+
+	applyMetadata(&newsArt, art.Metadata)
+
+	return newsArt
+}
+
+func newsArticleFromLiputan6(art liputan6.Article) models.NewsArticle {
+	newsArt := models.NewsArticle{
+		Source:      models.Liputan6,
+		Link:        art.Link,
+		Headline:    art.Headline,
+		Description: art.Description,
+		PublishedAt: art.PublishedAt,
+		UpdatedAt:   art.UpdatedAt,
+		Author: models.ArticleAuthor{
+			Name:       art.Author.Name,
+			ProfileURL: art.Author.ProfileURL,
+		},
+	}
+
+	for _, content := range art.Contents {
+		var data any
+		switch content.Type {
+		case liputan6.ParagraphText, liputan6.SectionTitle:
+			text := content.Text()
+			data = models.ArticleTextContent{
+				HTML:     text.HTML,
+				Text:     text.Text,
+				Markdown: text.Markdown,
+			}
+
+		case liputan6.Image:
+			data = imageFromLiputan6(content.Image())
+
+		case liputan6.Video:
+			vid := content.Video()
+			data = models.ArticleVideoContent{
+				URL:         vid.URL,
+				EmbeddedURL: vid.EmbeddedURL,
+				Title:       vid.Title,
+				Description: vid.Description,
+				Duration:    vid.Duration,
+				Thumbnail:   vid.ThumbnailURL,
+			}
+
+		default:
+			data = content.Data
+		}
+
+		newsArt.Contents = appendContent(newsArt.Contents, string(content.Type), data)
+	}
+
+	for _, related := range art.RelatedArticles {
+		newsArt.RelatedArticles = append(newsArt.RelatedArticles, models.RelatedArticle{
+			Title:       related.Title,
+			ArticleLink: related.ArticleLink,
+			Thumbnail:   imageFromLiputan6(related.Thumbnail),
+		})
+	}
+
+	for _, tag := range art.Tags {
+		newsArt.Tags = append(newsArt.Tags, tag.Name)
+	}
+
+	newsArt.Categories = art.Categories
+
+	applyMetadata(&newsArt, art.Metadata)
+
+	return newsArt
+}
+
+func newsArticleFromReadability(art readability.Article) models.NewsArticle {
+	newsArt := models.NewsArticle{
+		Source:      models.ArticleSource(art.Source),
+		Link:        art.Link,
+		Headline:    art.Headline,
+		Description: art.Description,
+		PublishedAt: art.PublishedAt,
+		UpdatedAt:   art.UpdatedAt,
+		Author: models.ArticleAuthor{
+			Name:       art.Author.Name,
+			ProfileURL: art.Author.URL,
+		},
+		Image:      imageFromReadability(art.Image),
+		Categories: art.Categories,
+		WordCount:  art.WordCount,
+	}
+
+	for _, content := range art.Contents {
+		var data any
+		switch content.Type {
+		case readability.ParagraphText, readability.SectionTitle:
+			text := content.Text()
+			data = models.ArticleTextContent{
+				HTML:     text.HTML,
+				Text:     text.Text,
+				Markdown: text.Markdown,
+			}
+
+		case readability.Image:
+			data = imageFromReadability(content.Image())
+
+		default:
+			data = content.Data
+		}
+
+		newsArt.Contents = appendContent(newsArt.Contents, string(content.Type), data)
+	}
+
+	applyMetadata(&newsArt, art.Metadata)
+
+	return newsArt
+}
+
+func newsArticleFromSite(art siterules.Article) models.NewsArticle {
+	newsArt := models.NewsArticle{
+		Source:      models.ArticleSource(art.Source),
+		Link:        art.Link,
+		Headline:    art.Headline,
+		Description: art.Description,
+		PublishedAt: art.PublishedAt,
+		UpdatedAt:   art.UpdatedAt,
+		Author: models.ArticleAuthor{
+			Name:       art.Author.Name,
+			ProfileURL: art.Author.URL,
+		},
+		Image:      imageFromSite(art.Image),
+		Tags:       art.Tags,
+		Categories: art.Categories,
+		WordCount:  art.WordCount,
+	}
+
+	for _, content := range art.Contents {
+		var data any
+		switch content.Type {
+		case siterules.ParagraphText, siterules.SectionTitle:
+			text := content.Text()
+			data = models.ArticleTextContent{
+				HTML:     text.HTML,
+				Text:     text.Text,
+				Markdown: text.Markdown,
+			}
+
+		case siterules.Image:
+			data = imageFromSite(content.Image())
+
+		case siterules.Video:
+			vid := content.Video()
+			data = models.ArticleVideoContent{
+				EmbeddedURL: vid.EmbeddedURL,
+				Title:       vid.Title,
+			}
+
+		case siterules.ReferencedArticleLink:
+			ref := content.ReferencedArticle()
+			data = models.ArticleReferenceContent{
+				Headline:    ref.Headline,
+				ArticleLink: ref.ArticleLink,
+			}
+
+		default:
+			data = content.Data
+		}
+
+		newsArt.Contents = appendContent(newsArt.Contents, string(content.Type), data)
+	}
+
+	for _, related := range art.RelatedArticles {
+		newsArt.RelatedArticles = append(newsArt.RelatedArticles, models.RelatedArticle{
+			Title:       related.Title,
+			ArticleLink: related.ArticleLink,
+			Thumbnail: models.ArticleImageContent{
+				URL: related.ThumbnailURL,
+			},
+		})
+	}
+
+	applyMetadata(&newsArt, art.Metadata)
+
+	return newsArt
+}
+
+func imageFromSite(img siterules.ContentImage) models.ArticleImageContent {
+	return models.ArticleImageContent{
+		URL:     img.URL,
+		Title:   img.Title,
+		Caption: img.Caption,
+		Alt:     img.Alt,
+		Width:   img.Width,
+		Height:  img.Height,
+	}
+}
+
+func imageFromReadability(img readability.ContentImage) models.ArticleImageContent {
+	return models.ArticleImageContent{
+		URL:     img.URL,
+		Title:   img.Title,
+		Caption: img.Caption,
+		Alt:     img.Alt,
+		Width:   img.Width,
+		Height:  img.Height,
+	}
+}
+
+func imageFromLiputan6(img liputan6.ContentImage) models.ArticleImageContent {
+	return models.ArticleImageContent{
+		URL:     img.URL,
+		Title:   img.Title,
+		Caption: img.Caption,
+		Alt:     img.Alt,
+		Width:   img.Width,
+		Height:  img.Height,
+	}
+}
+
+func appendContent(contents []models.ArticleContent, contentType string, data any) []models.ArticleContent {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return contents
+	}
+
+	return append(contents, models.ArticleContent{
+		Type: contentType,
+		Data: raw,
+	})
+}
+
+// applyMetadata fills the article fields that is only available
+// from the page metadata, and the fields that the site parser
+// failed to parse. Tags, keywords and categories is normalized
+func applyMetadata(art *models.NewsArticle, md metadata.Metadata) {
+	art.CanonicalURL = md.CanonicalURL
+	art.Section = md.Section
+	art.Keywords = models.NormalizeTerms(md.Keywords)
+	art.Tags = models.NormalizeTerms(append(art.Tags, md.Tags...))
+
+	if len(art.Categories) == 0 && md.Section != "" {
+		art.Categories = []string{md.Section}
+	}
+
+	art.Categories = models.NormalizeTerms(art.Categories)
+	if art.Channel == "" && len(art.Categories) != 0 {
+		art.Channel = art.Categories[0]
+	}
+
+	if md.WordCount != 0 {
+		art.WordCount = md.WordCount
+	}
+
+	art.Publisher = models.ArticlePublisher{
+		Name:    md.Publisher.Name,
+		URL:     md.Publisher.URL,
+		LogoURL: md.Publisher.Logo.URL,
+	}
+
+	if art.Headline == "" {
+		art.Headline = md.Title
+	}
+
+	if art.Description == "" {
+		art.Description = md.Description
+	}
+
+	if art.PublishedAt.IsZero() {
+		art.PublishedAt = md.PublishedAt
+	}
+
+	if art.UpdatedAt.IsZero() {
+		art.UpdatedAt = md.ModifiedAt
+	}
+
+	if art.Author.Name == "" && len(md.Authors) != 0 {
+		art.Author = models.ArticleAuthor{
+			Name:       md.Authors[0].Name,
+			ProfileURL: md.Authors[0].URL,
+		}
+	}
+
+	if art.Image.URL == "" {
+		art.Image.URL = md.Image.URL
+		art.Image.Caption = md.Image.Caption
+	}
+
+	if art.Image.URL == md.Image.URL {
+		if art.Image.Width == 0 {
+			art.Image.Width = md.Image.Width
+		}
+
+		if art.Image.Height == 0 {
+			art.Image.Height = md.Image.Height
+		}
+	}
+}
@@ -2,19 +2,38 @@ package crawler
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
+	"slices"
 	"sort"
 	"sync"
 	"time"
 
+	"github.com/tamboto2000/ivosight-crawler/internal/cleanup"
 	"github.com/tamboto2000/ivosight-crawler/internal/config"
+	"github.com/tamboto2000/ivosight-crawler/internal/dedup"
+	"github.com/tamboto2000/ivosight-crawler/internal/entity"
 	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/internal/pubsub"
+	"github.com/tamboto2000/ivosight-crawler/internal/repository"
+	"github.com/tamboto2000/ivosight-crawler/internal/search"
+	"github.com/tamboto2000/ivosight-crawler/internal/sentiment"
+	"github.com/tamboto2000/ivosight-crawler/internal/storycluster"
+	"github.com/tamboto2000/ivosight-crawler/internal/summary"
+	"github.com/tamboto2000/ivosight-crawler/internal/tombstone"
+	"github.com/tamboto2000/ivosight-crawler/internal/trend"
+	"github.com/tamboto2000/ivosight-crawler/internal/versioning"
+	"github.com/tamboto2000/ivosight-crawler/internal/webhook"
 	"github.com/tamboto2000/ivosight-crawler/pkg/detik"
+	"github.com/tamboto2000/ivosight-crawler/pkg/htmlutil/readability"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idnlp"
 	"github.com/tamboto2000/ivosight-crawler/pkg/liputan6"
 	"github.com/tamboto2000/ivosight-crawler/pkg/proxrotate"
 	"github.com/tamboto2000/ivosight-crawler/pkg/random"
+	"github.com/tamboto2000/ivosight-crawler/pkg/siterules"
 	"github.com/tamboto2000/ivosight-crawler/pkg/syncx"
+	"github.com/tamboto2000/ivosight-crawler/pkg/urlnorm"
 )
 
 type articleList struct {
@@ -26,6 +45,13 @@ func (artlist *articleList) add(item newsIndexItem) {
 	artlist.rwmx.Lock()
 	defer artlist.rwmx.Unlock()
 
+	for _, listed := range artlist.list {
+		if listed.key == item.key {
+			return
+		}
+	}
+
+	artlist.list = append(artlist.list, item)
 	sort.Slice(artlist.list, func(i, j int) bool {
 		it := artlist.list[i].publishedAt
 		jt := artlist.list[j].publishedAt
@@ -35,8 +61,8 @@ func (artlist *articleList) add(item newsIndexItem) {
 }
 
 func (artlist *articleList) get() (newsIndexItem, bool) {
-	artlist.rwmx.RLock()
-	defer artlist.rwmx.RUnlock()
+	artlist.rwmx.Lock()
+	defer artlist.rwmx.Unlock()
 
 	if len(artlist.list) == 0 {
 		return newsIndexItem{}, false
@@ -50,15 +76,38 @@ func (artlist *articleList) get() (newsIndexItem, bool) {
 
 var reqTimeout time.Duration = 30 * time.Second
 
+// recrawlCheckInterval is how often the articles to re-crawl is queried
+var recrawlCheckInterval = 5 * time.Minute
+
+// tombstoneCheckInterval is how often the articles to check
+// for removal is queried
+var tombstoneCheckInterval = 10 * time.Minute
+
 type newsIndexItem struct {
-	source      string
+	source  string
+	channel string
+	// link is the link as found on the index, which is the link
+	// the article is fetched from. The normalized link, which is
+	// the storage key, is used only to find the stored article
 	link        string
+	key         string
 	publishedAt time.Time
+	// recrawl is true when the article is already stored
+	// and is crawled again to find the edits
+	recrawl bool
 }
 
 type Repository interface {
-	// StoreArticle(ctx context.Context, article models.NewsArticle) error
+	StoreArticle(ctx context.Context, article models.NewsArticle) error
 	IsAlreadyExist(ctx context.Context, link string) (bool, error)
+	ArticlesForRecrawl(ctx context.Context, publishedSince, crawledBefore time.Time, limit int) ([]models.NewsArticle, error)
+	ArticlesForCheck(ctx context.Context, publishedSince, checkedBefore time.Time, limit int) ([]models.NewsArticle, error)
+	versioning.Repository
+	tombstone.Repository
+	dedup.Repository
+	storycluster.Repository
+	trend.Repository
+	webhook.Repository
 }
 
 type NewsCrawler struct {
@@ -66,27 +115,632 @@ type NewsCrawler struct {
 	repo        Repository
 	cfg         config.Crawler
 	proxrot     *proxrotate.ProxyRotator
+	rules       *siterules.Registry
+	cleanup     *cleanup.Pipeline
+	versions    *versioning.Tracker
+	dedup       *dedup.Detector
+	stories     *storycluster.Clusterer
+	search      *search.Index
+	entities    *entity.Extractor
+	sentiment   *sentiment.Scorer
+	trends      *trend.Engine
+	summary     *summary.Summarizer
+	hub         *pubsub.Hub
+	webhooks    *webhook.Dispatcher
 	articleList articleList
 }
 
-func (crawl *NewsCrawler) crawlNewsIndexes() error {
+func NewNewsCrawler(cfg config.Crawler, repo Repository, proxrot *proxrotate.ProxyRotator) *NewsCrawler {
+	routines := syncx.NewRoutines()
+	routines.WithLimit(cfg.MaxThreadCount)
+
+	crawl := &NewsCrawler{
+		routines: routines,
+		repo:     repo,
+		cfg:      cfg,
+		proxrot:  proxrot,
+		cleanup:  cleanup.Default(),
+		versions: versioning.NewTracker(repo),
+		dedup: dedup.NewDetector(repo).
+			WithThreshold(cfg.DedupThreshold).
+			WithWindow(time.Duration(cfg.DedupWindow) * 24 * time.Hour),
+		stories: storycluster.NewClusterer(repo).
+			WithThreshold(cfg.StoryClusterThreshold).
+			WithWindow(time.Duration(cfg.StoryClusterWindow) * time.Hour),
+		entities:  entity.NewExtractor(entity.DefaultGazetteer()),
+		sentiment: sentiment.NewScorer(sentiment.DefaultLexicon()),
+		summary:   summary.NewSummarizer().WithSentences(cfg.SummarySentences),
+	}
+
+	if cfg.Trend {
+		crawl.trends = trend.NewEngine(repo).
+			WithWindow(time.Duration(cfg.TrendWindow) * time.Hour).
+			WithBaseline(time.Duration(cfg.TrendBaseline) * 24 * time.Hour)
+	}
+
+	if cfg.Webhook {
+		crawl.webhooks = webhook.NewDispatcher(repo).
+			WithMaxAttempts(cfg.WebhookMaxAttempts).
+			WithClient(&http.Client{Timeout: time.Duration(cfg.WebhookTimeout) * time.Second})
+	}
+
+	return crawl
+}
+
+// WithSiteRules adds the sites of the declarative rules to be
+// crawled, in addition to the sites with dedicated parser
+func (crawl *NewsCrawler) WithSiteRules(rules *siterules.Registry) {
+	crawl.rules = rules
+}
+
+// WithSearchIndex adds the stored articles to the full-text
+// search index as well
+func (crawl *NewsCrawler) WithSearchIndex(idx *search.Index) {
+	crawl.search = idx
+}
+
+// WithEntityWatchlist adds the watchlist gazetteers to the
+// default gazetteer of the entity extractor
+func (crawl *NewsCrawler) WithEntityWatchlist(watchlists ...*entity.Gazetteer) {
+	crawl.entities = entity.NewExtractor(append([]*entity.Gazetteer{entity.DefaultGazetteer()}, watchlists...)...)
+}
+
+// WithSentimentLexicon replaces the default sentiment lexicon
+func (crawl *NewsCrawler) WithSentimentLexicon(lex *sentiment.Lexicon) {
+	crawl.sentiment = sentiment.NewScorer(lex)
+}
+
+// WithHub publishes the new and changed articles to the hub
+// after they is stored
+func (crawl *NewsCrawler) WithHub(hub *pubsub.Hub) {
+	crawl.hub = hub
+}
+
+// WithCleanup replaces the default content cleanup pipeline
+func (crawl *NewsCrawler) WithCleanup(p *cleanup.Pipeline) {
+	crawl.cleanup = p
+}
+
+// Run runs the crawler until ctx is cancelled. The news indexes is
+// crawled periodically, and the newly found articles is queued to
+// be crawled one by one
+func (crawl *NewsCrawler) Run(ctx context.Context) error {
+	crawl.routines.WithCtx(ctx)
+	if err := crawl.routines.Run(); err != nil {
+		return err
+	}
+
+	if crawl.rules != nil {
+		interval := time.Duration(crawl.cfg.SiteRulesReloadInterval) * time.Second
+		go crawl.rules.Watch(ctx, interval)
+	}
+
+	if crawl.cfg.Recrawl {
+		go crawl.recrawlArticles()
+	}
+
+	if crawl.cfg.TombstoneCheck {
+		go crawl.checkRemovedArticles()
+	}
+
+	if crawl.trends != nil {
+		// the baseline is counted from the stored articles,
+		// the trends is still found without it
+		if err := crawl.trends.Load(ctx); err != nil {
+			slog.Error("failed to load trend baseline", "error", err.Error())
+		}
+
+		go crawl.snapshotTrends()
+	}
+
+	if crawl.webhooks != nil {
+		go crawl.webhooks.Run(ctx)
+	}
+
+	go crawl.crawlArticles()
+	crawl.crawlNewsIndexes()
+	crawl.routines.Wait()
+
+	return nil
+}
+
+// CrawlLink crawls an article from any news portal by its link and
+// stores it. The site rules of the link is used when there is one,
+// otherwise the generic article extractor is used
+func (crawl *NewsCrawler) CrawlLink(ctx context.Context, link string) (models.NewsArticle, error) {
+	var art models.NewsArticle
+	var err error
+
+	// the link might be a short link, or a link with tracking params
+	crawledLink := link
+	link, err = urlnorm.NewResolver(crawl.newClient()).Resolve(ctx, link)
+	if err != nil {
+		slog.Warn("failed to resolve canonical URL", "link", crawledLink, "error", err.Error())
+		link = urlnorm.MustNormalize(crawledLink)
+	}
+
+	if site, ok := crawl.siteForLink(link); ok {
+		siteArt, err := site.ArticleFromLink(ctx, crawl.newClient(), link)
+		if err != nil {
+			slog.Warn("site rules failed, falling back to generic extractor", "link", link, "error", err.Error())
+		} else {
+			art = newsArticleFromSite(siteArt)
+		}
+	}
+
+	if art.Link == "" {
+		rdArt, err := readability.ArticleFromLink(ctx, crawl.newClient(), link)
+		if err != nil {
+			return models.NewsArticle{}, err
+		}
+
+		art = newsArticleFromReadability(rdArt)
+	}
+
+	art.Aliases = append(art.Aliases, urlnorm.MustNormalize(crawledLink))
+
+	if err := crawl.storeArticle(ctx, &art); err != nil {
+		return models.NewsArticle{}, err
+	}
+
+	return art, nil
+}
+
+func (crawl *NewsCrawler) crawlNewsIndexes() {
 	for {
 		interval := crawl.randomInterval()
 		tc := time.After(interval)
 
-		<-tc
+		select {
+		case <-tc:
+		case <-crawl.routines.Dying():
+			return
+		}
 
 		crawl.routines.WaitAvailable()
 		crawl.routines.Go(crawl._crawlDetikIndex)
 		crawl.routines.WaitAvailable()
 		crawl.routines.Go(crawl._crawlLiputan6Index)
+
+		if crawl.rules == nil {
+			continue
+		}
+
+		for _, site := range crawl.rules.Sites() {
+			crawl.routines.WaitAvailable()
+			crawl.routines.Go(func() error {
+				return crawl._crawlSiteIndex(site)
+			})
+		}
 	}
 }
 
+func (crawl *NewsCrawler) crawlArticles() {
+	for {
+		interval := crawl.randomArticleInterval()
+		tc := time.After(interval)
+
+		select {
+		case <-tc:
+		case <-crawl.routines.Dying():
+			return
+		}
+
+		item, ok := crawl.articleList.get()
+		if !ok {
+			continue
+		}
+
+		crawl.routines.WaitAvailable()
+		err := crawl.routines.Go(func() error {
+			return crawl._crawlArticle(item)
+		})
+
+		// the item is put back so it is crawled on the next turn
+		if err != nil {
+			crawl.articleList.add(item)
+			if errors.Is(err, syncx.ErrDied) {
+				return
+			}
+		}
+	}
+}
+
+func (crawl *NewsCrawler) _crawlArticle(item newsIndexItem) error {
+	ctx := context.Background()
+
+	art, err := crawl.fetchArticle(ctx, item)
+	if err != nil {
+		if item.recrawl {
+			crawl.markRemoved(ctx, item, err)
+		}
+
+		slog.Error(err.Error(), "link", item.link)
+		return err
+	}
+
+	if err := crawl.storeArticle(ctx, &art); err != nil {
+		slog.Error(err.Error(), "link", item.link)
+		return err
+	}
+
+	return nil
+}
+
+// recrawlArticles periodically queues the recently published
+// articles to be crawled again, so the edits is tracked
+func (crawl *NewsCrawler) recrawlArticles() {
+	for {
+		select {
+		case <-time.After(recrawlCheckInterval):
+		case <-crawl.routines.Dying():
+			return
+		}
+
+		now := time.Now()
+		publishedSince := now.Add(-time.Duration(crawl.cfg.RecrawlWindow) * time.Hour)
+		crawledBefore := now.Add(-time.Duration(crawl.cfg.RecrawlInterval) * time.Minute)
+
+		arts, err := crawl.repo.ArticlesForRecrawl(context.Background(), publishedSince, crawledBefore, crawl.cfg.RecrawlBatchSize)
+		if err != nil {
+			slog.Error(err.Error())
+			continue
+		}
+
+		for _, art := range arts {
+			crawl.articleList.add(newsIndexItem{
+				source:      string(art.Source),
+				channel:     art.Channel,
+				link:        art.Link,
+				key:         art.Link,
+				publishedAt: art.PublishedAt,
+				recrawl:     true,
+			})
+		}
+	}
+}
+
+// checkRemovedArticles periodically checks the links of the stored
+// articles, the deleted or retracted ones is marked as removed
+func (crawl *NewsCrawler) checkRemovedArticles() {
+	for {
+		select {
+		case <-time.After(tombstoneCheckInterval):
+		case <-crawl.routines.Dying():
+			return
+		}
+
+		now := time.Now()
+		publishedSince := now.AddDate(0, 0, -crawl.cfg.TombstoneCheckWindow)
+		checkedBefore := now.Add(-time.Duration(crawl.cfg.TombstoneCheckInterval) * time.Hour)
+
+		arts, err := crawl.repo.ArticlesForCheck(context.Background(), publishedSince, checkedBefore, crawl.cfg.TombstoneCheckBatchSize)
+		if err != nil {
+			slog.Error(err.Error())
+			continue
+		}
+
+		if len(arts) == 0 {
+			continue
+		}
+
+		crawl.routines.WaitAvailable()
+		crawl.routines.Go(func() error {
+			checker := tombstone.NewChecker(crawl.newClient(), removalRepository{crawl.repo, crawl})
+			removed, err := checker.CheckArticles(context.Background(), arts)
+			if err != nil {
+				slog.Error(err.Error())
+				return err
+			}
+
+			slog.Info("articles checked for removal", "count", len(arts), "removed", removed)
+
+			return nil
+		})
+	}
+}
+
+// snapshotTrends periodically stores the trends snapshot
+func (crawl *NewsCrawler) snapshotTrends() {
+	interval := time.Duration(crawl.cfg.TrendSnapshotInterval) * time.Minute
+	for {
+		select {
+		case <-time.After(interval):
+		case <-crawl.routines.Dying():
+			return
+		}
+
+		if err := crawl.trends.Snapshot(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}
+}
+
+// markRemoved marks the re-crawled article as removed when the
+// parser got 404 or 410
+func (crawl *NewsCrawler) markRemoved(ctx context.Context, item newsIndexItem, err error) {
+	checker := tombstone.NewChecker(nil, crawl.repo)
+	removal, ok := checker.RemovalFromError(err)
+	if !ok {
+		return
+	}
+
+	art, ok, err := crawl.repo.FindArticleByLink(ctx, item.key)
+	if err != nil || !ok {
+		return
+	}
+
+	if err := crawl.repo.MarkRemoved(ctx, art.ID, removal); err != nil {
+		slog.Error(err.Error(), "link", item.link)
+		return
+	}
+
+	crawl.unindex(art.ID)
+
+	slog.Info("article is removed", "link", item.link, "reason", removal.Reason)
+}
+
+// unindex deletes the removed article from the search index
+func (crawl *NewsCrawler) unindex(id string) {
+	if crawl.search == nil {
+		return
+	}
+
+	if err := crawl.search.Delete(id); err != nil {
+		slog.Error("failed to unindex article", "id", id, "error", err.Error())
+	}
+}
+
+// removalRepository is the repository of the tombstone checker, the
+// articles marked as removed is deleted from the search index as well
+type removalRepository struct {
+	Repository
+	crawl *NewsCrawler
+}
+
+func (repo removalRepository) MarkRemoved(ctx context.Context, id string, removal models.ArticleRemoval) error {
+	if err := repo.Repository.MarkRemoved(ctx, id, removal); err != nil {
+		return err
+	}
+
+	repo.crawl.unindex(id)
+
+	return nil
+}
+
+// storeArticle cleans the article, archives the previous version
+// when the article is changed, and stores it
+func (crawl *NewsCrawler) storeArticle(ctx context.Context, art *models.NewsArticle) error {
+	canonicalize(art)
+	crawl.cleanArticle(art)
+
+	changed, err := crawl.versions.Track(ctx, art)
+	if err != nil {
+		return err
+	}
+
+	// the ID of a stored article is set by Track
+	created := art.ID == ""
+
+	if changed {
+		slog.Info("article is changed, previous version is archived", "link", art.Link, "version", art.Version)
+	}
+
+	if art.ID == "" {
+		art.ID = repository.ArticleID(art.Link)
+	}
+
+	duplicate, err := crawl.dedup.Detect(ctx, art)
+	if err != nil {
+		return err
+	}
+
+	if duplicate {
+		slog.Info("article is a near-duplicate", "link", art.Link, "duplicate_of", art.DuplicateOf, "similarity", art.Similarity)
+	}
+
+	if _, err := crawl.stories.Assign(ctx, art); err != nil {
+		return err
+	}
+
+	art.Terms = idnlp.Terms(append([]string{art.Headline}, art.Paragraphs()...)...)
+	art.Entities = crawl.entities.Extract(*art)
+	score := crawl.sentiment.Score(*art)
+	art.Sentiment = &score
+	art.Summary = crawl.summary.Summarize(*art)
+
+	// a successful crawl is a removal check as well
+	art.CrawledAt = time.Now()
+	art.CheckedAt = art.CrawledAt
+	art.Status = models.ArticleStatusActive
+
+	// articles stored before the change time has none, those
+	// is treated as changed when it is crawled
+	if created || changed || art.ChangedAt.IsZero() {
+		art.ChangedAt = art.CrawledAt
+	}
+
+	if err := crawl.repo.StoreArticle(ctx, *art); err != nil {
+		return err
+	}
+
+	// the index can be rebuilt from the stored articles, so
+	// failing to index is not failing the crawl
+	if crawl.search != nil {
+		if err := crawl.search.Add(*art); err != nil {
+			slog.Error("failed to index article", "link", art.Link, "error", err.Error())
+		}
+	}
+
+	if crawl.trends != nil {
+		crawl.trends.Observe(*art)
+	}
+
+	event := ""
+	switch {
+	case created:
+		event = pubsub.EventCreated
+
+	case changed:
+		event = pubsub.EventUpdated
+	}
+
+	if crawl.hub != nil && event != "" {
+		crawl.hub.Publish(event, *art)
+	}
+
+	// the article is stored already, failing to queue
+	// the deliveries is not failing the crawl
+	if crawl.webhooks != nil && event != "" {
+		if _, err := crawl.webhooks.Enqueue(ctx, event, *art); err != nil {
+			slog.Error("failed to queue webhook deliveries", "link", art.Link, "error", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// fetchArticle fetches the article with the parser of its source. When
+// the parser fails or gives no content, which usually means the site
+// markup is changed, the generic article extractor is used instead
+func (crawl *NewsCrawler) fetchArticle(ctx context.Context, item newsIndexItem) (models.NewsArticle, error) {
+	cl := crawl.newClient()
+
+	var art models.NewsArticle
+	var err error
+
+	switch item.source {
+	case models.Detik:
+		var dtkArt detik.Article
+		dtkArt, err = detik.NewDetik(cl).ArticleFromLink(ctx, item.link)
+		art = newsArticleFromDetik(dtkArt)
+		art.Channel = models.NormalizeTerm(item.channel)
+
+	case models.Liputan6:
+		var lptArt liputan6.Article
+		lptArt, err = liputan6.NewLiputan6(cl).ArticleFromLink(ctx, item.link)
+		art = newsArticleFromLiputan6(lptArt)
+
+	default:
+		if crawl.rules == nil {
+			break
+		}
+
+		if site, ok := crawl.rules.Site(item.source); ok {
+			var siteArt siterules.Article
+			siteArt, err = site.ArticleFromLink(ctx, cl, item.link)
+			art = newsArticleFromSite(siteArt)
+			if art.Channel == "" {
+				art.Channel = models.NormalizeTerm(item.channel)
+			}
+		}
+	}
+
+	if err == nil && len(art.Contents) != 0 {
+		return art, nil
+	}
+
+	// the generic extractor gives different contents from the
+	// parser, falling back on re-crawl would be seen as an edit
+	if item.recrawl && crawl.hasParser(item.source) {
+		if err == nil {
+			err = readability.ErrNoContent
+		}
+
+		return models.NewsArticle{}, err
+	}
+
+	if err != nil {
+		slog.Warn("parser failed, falling back to generic extractor", "link", item.link, "error", err.Error())
+	}
+
+	rdArt, ferr := readability.ArticleFromLink(ctx, cl, item.link)
+	if ferr != nil {
+		if err != nil {
+			return models.NewsArticle{}, err
+		}
+
+		return models.NewsArticle{}, ferr
+	}
+
+	fallback := newsArticleFromReadability(rdArt)
+
+	if item.source != "" {
+		fallback.Source = models.ArticleSource(item.source)
+	}
+
+	if item.channel != "" {
+		fallback.Channel = models.NormalizeTerm(item.channel)
+	}
+
+	return fallback, nil
+}
+
+// canonicalize sets the article link to its canonical URL, which is
+// the storage key. The link it is crawled from is kept as an alias,
+// so it is not crawled again
+func canonicalize(art *models.NewsArticle) {
+	canonical := urlnorm.Canonical(art.Link, art.CanonicalURL)
+
+	var aliases []string
+	for _, alias := range append(art.Aliases, urlnorm.MustNormalize(art.Link)) {
+		if alias != canonical && !slices.Contains(aliases, alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	art.Link = canonical
+	art.Aliases = aliases
+}
+
+// cleanArticle removes the boilerplate blocks from the article
+// contents, the removed blocks is kept on the article for auditing
+func (crawl *NewsCrawler) cleanArticle(art *models.NewsArticle) {
+	if crawl.cleanup == nil {
+		return
+	}
+
+	if removed := crawl.cleanup.Clean(art); len(removed) != 0 {
+		slog.Debug("boilerplate blocks removed", "link", art.Link, "count", len(removed))
+	}
+}
+
+// hasParser reports whether the source has a dedicated parser
+// or site rules
+func (crawl *NewsCrawler) hasParser(source string) bool {
+	switch source {
+	case models.Detik, models.Liputan6:
+		return true
+	}
+
+	if crawl.rules == nil {
+		return false
+	}
+
+	_, ok := crawl.rules.Site(source)
+
+	return ok
+}
+
+func (crawl *NewsCrawler) siteForLink(link string) (*siterules.Site, bool) {
+	if crawl.rules == nil {
+		return nil, false
+	}
+
+	return crawl.rules.SiteForLink(link)
+}
+
+func (crawl *NewsCrawler) newClient() *http.Client {
+	cl := &http.Client{Timeout: reqTimeout}
+	if crawl.proxrot != nil {
+		crawl.proxrot.Rotate(cl)
+	}
+
+	return cl
+}
+
 func (crawl *NewsCrawler) _crawlDetikIndex() error {
-	cl := http.DefaultClient
-	cl.Timeout = reqTimeout
-	crawl.proxrot.Rotate(cl)
+	cl := crawl.newClient()
 
 	dtk := detik.NewDetik(cl)
 	list, err := dtk.ArticleListFromChannel(context.Background(), detik.ChannelNews)
@@ -96,7 +750,8 @@ func (crawl *NewsCrawler) _crawlDetikIndex() error {
 	}
 
 	for _, item := range list {
-		exists, err := crawl.repo.IsAlreadyExist(context.Background(), item.ArticleLink)
+		key := urlnorm.MustNormalize(item.ArticleLink)
+		exists, err := crawl.repo.IsAlreadyExist(context.Background(), key)
 		if err != nil {
 			slog.Error(err.Error())
 			return err
@@ -105,7 +760,9 @@ func (crawl *NewsCrawler) _crawlDetikIndex() error {
 		if !exists {
 			crawl.articleList.add(newsIndexItem{
 				source:      models.Detik,
+				channel:     detik.ChannelNews.Name(),
 				link:        item.ArticleLink,
+				key:         key,
 				publishedAt: item.PublishedAt,
 			})
 		}
@@ -115,9 +772,7 @@ func (crawl *NewsCrawler) _crawlDetikIndex() error {
 }
 
 func (crawl *NewsCrawler) _crawlLiputan6Index() error {
-	cl := http.DefaultClient
-	cl.Timeout = reqTimeout
-	crawl.proxrot.Rotate(cl)
+	cl := crawl.newClient()
 
 	lpt := liputan6.NewLiputan6(cl)
 	list, err := lpt.ArticleListFromIndex(context.Background())
@@ -127,7 +782,8 @@ func (crawl *NewsCrawler) _crawlLiputan6Index() error {
 	}
 
 	for _, item := range list {
-		exists, err := crawl.repo.IsAlreadyExist(context.Background(), item.Link)
+		key := urlnorm.MustNormalize(item.Link)
+		exists, err := crawl.repo.IsAlreadyExist(context.Background(), key)
 		if err != nil {
 			slog.Error(err.Error())
 			return err
@@ -137,6 +793,38 @@ func (crawl *NewsCrawler) _crawlLiputan6Index() error {
 			crawl.articleList.add(newsIndexItem{
 				source:      models.Liputan6,
 				link:        item.Link,
+				key:         key,
+				publishedAt: item.PublishedAt,
+			})
+		}
+	}
+
+	return nil
+}
+
+func (crawl *NewsCrawler) _crawlSiteIndex(site *siterules.Site) error {
+	cl := crawl.newClient()
+
+	list, err := site.ArticleListFromIndex(context.Background(), cl)
+	if err != nil {
+		slog.Error(err.Error())
+		return err
+	}
+
+	for _, item := range list {
+		key := urlnorm.MustNormalize(item.Link)
+		exists, err := crawl.repo.IsAlreadyExist(context.Background(), key)
+		if err != nil {
+			slog.Error(err.Error())
+			return err
+		}
+
+		if !exists {
+			crawl.articleList.add(newsIndexItem{
+				source:      site.Source,
+				channel:     item.Channel,
+				link:        item.Link,
+				key:         key,
 				publishedAt: item.PublishedAt,
 			})
 		}
@@ -152,3 +840,11 @@ func (crawl *NewsCrawler) randomInterval() time.Duration {
 
 	return time.Duration(randnum) * time.Second
 }
+
+func (crawl *NewsCrawler) randomArticleInterval() time.Duration {
+	min := crawl.cfg.RandomCrawlArticleIntervalRange[0]
+	max := crawl.cfg.RandomCrawlArticleIntervalRange[1]
+	randnum := random.RandomNumRange(min, max)
+
+	return time.Duration(randnum) * time.Second
+}
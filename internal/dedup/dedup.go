@@ -0,0 +1,219 @@
+// Package dedup detects near-duplicate articles, like the wire copy
+// from the same agency run by several portals with small edits. The
+// body of each article is fingerprinted with MinHash of its word
+// shingles, the candidates is looked up by the LSH band keys, and
+// the article is linked to the earliest similar article with the
+// similarity score. The near-duplicates share the same cluster ID,
+// so they can be counted once. When an article is crawled after its
+// later copies, the clusters of the later copies is moved to it.
+package dedup
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/pkg/minhash"
+)
+
+const (
+	// signatureSize and bandCount gives 8 rows per band, which
+	// finds the pairs with 0.8 similarity with 0.95 probability
+	signatureSize = 128
+	bandCount     = 16
+	hasherSeed    = 20240812
+	shingleSize   = 5
+	// minWords is the minimum words of the body to fingerprint,
+	// short bodies is too similar to each other
+	minWords = 40
+
+	maxCandidates = 50
+
+	DefaultThreshold = 0.8
+	DefaultWindow    = 7 * 24 * time.Hour
+)
+
+type Repository interface {
+	// DuplicateCandidates finds the articles published between
+	// publishedSince and publishedUntil sharing any of the bands
+	DuplicateCandidates(ctx context.Context, bands []string, publishedSince, publishedUntil time.Time, limit int) ([]models.NewsArticle, error)
+	// ClusterArticles finds the articles of the cluster
+	ClusterArticles(ctx context.Context, clusterID string) ([]models.NewsArticle, error)
+	// LinkDuplicate links the stored article to its earlier
+	// near-duplicate
+	LinkDuplicate(ctx context.Context, id, duplicateOf, clusterID string, similarity float64) error
+}
+
+type Detector struct {
+	repo      Repository
+	hasher    *minhash.Hasher
+	threshold float64
+	window    time.Duration
+}
+
+func NewDetector(repo Repository) *Detector {
+	return &Detector{
+		repo:      repo,
+		hasher:    minhash.NewHasher(signatureSize, hasherSeed),
+		threshold: DefaultThreshold,
+		window:    DefaultWindow,
+	}
+}
+
+// WithThreshold sets the minimum similarity of near-duplicates
+func (det *Detector) WithThreshold(threshold float64) *Detector {
+	det.threshold = threshold
+	return det
+}
+
+// WithWindow sets how far apart the near-duplicates can be published
+func (det *Detector) WithWindow(window time.Duration) *Detector {
+	det.window = window
+	return det
+}
+
+// Fingerprint sets the MinHash signature and the LSH bands of the
+// article body. Bodies shorter than minWords is not fingerprinted
+func (det *Detector) Fingerprint(art *models.NewsArticle) {
+	art.MinHash = nil
+	art.LSHBands = nil
+
+	words := minhash.Words(strings.Join(art.Paragraphs(), "\n"))
+	if len(words) < minWords {
+		return
+	}
+
+	sig := det.hasher.Signature(minhash.Shingles(words, shingleSize))
+	art.MinHash = sig
+	art.LSHBands = sig.Bands(bandCount)
+}
+
+// Detect fingerprints the article and links it to the earliest near
+// duplicate published before it. The article must have its ID set.
+// Only an earlier article is linked, so the links never make a cycle.
+// The stored later copies that is not linked to an earlier article
+// is linked to the article, with their clusters
+func (det *Detector) Detect(ctx context.Context, art *models.NewsArticle) (duplicate bool, err error) {
+	det.Fingerprint(art)
+	art.DuplicateOf = ""
+	art.Similarity = 0
+	art.ClusterID = art.ID
+
+	if len(art.LSHBands) == 0 {
+		return false, nil
+	}
+
+	publishedAt := art.PublishedAt
+	if publishedAt.IsZero() {
+		publishedAt = time.Now()
+	}
+
+	cands, err := det.repo.DuplicateCandidates(ctx, art.LSHBands, publishedAt.Add(-det.window), publishedAt.Add(det.window), maxCandidates)
+	if err != nil {
+		return false, err
+	}
+
+	sig := minhash.Signature(art.MinHash)
+	ref := models.NewsArticle{ID: art.ID, PublishedAt: publishedAt}
+
+	var best *models.NewsArticle
+	var bestSim float64
+	var later []models.NewsArticle
+	var laterSims []float64
+	for i := range cands {
+		cand := &cands[i]
+		if cand.ID == art.ID {
+			continue
+		}
+
+		sim := sig.Similarity(cand.MinHash)
+		if sim < det.threshold {
+			continue
+		}
+
+		if !isEarlier(*cand, ref) {
+			later = append(later, *cand)
+			laterSims = append(laterSims, sim)
+			continue
+		}
+
+		if best == nil || sim > bestSim || (sim == bestSim && isEarlier(*cand, *best)) {
+			best = cand
+			bestSim = sim
+		}
+	}
+
+	if best != nil {
+		art.DuplicateOf = best.ID
+		if best.DuplicateOf != "" {
+			art.DuplicateOf = best.DuplicateOf
+		}
+
+		art.ClusterID = best.ClusterID
+		if art.ClusterID == "" {
+			art.ClusterID = art.DuplicateOf
+		}
+
+		art.Similarity = bestSim
+	}
+
+	for i, cand := range later {
+		if err := det.relink(ctx, *art, cand, laterSims[i]); err != nil {
+			return false, err
+		}
+	}
+
+	return best != nil, nil
+}
+
+// relink links a stored later copy, which is the earliest of its
+// cluster, to the article, and moves the rest of its cluster. The
+// links still points to an earlier article, the root of art is
+// earlier than art, which is earlier than the copy and its cluster
+func (det *Detector) relink(ctx context.Context, art, later models.NewsArticle, sim float64) error {
+	clusterID := later.ClusterID
+	if clusterID == "" {
+		clusterID = later.ID
+	}
+
+	if later.DuplicateOf != "" || clusterID == art.ClusterID {
+		return nil
+	}
+
+	root := art.DuplicateOf
+	if root == "" {
+		root = art.ID
+	}
+
+	if err := det.repo.LinkDuplicate(ctx, later.ID, root, art.ClusterID, sim); err != nil {
+		return err
+	}
+
+	members, err := det.repo.ClusterArticles(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		if member.ID == later.ID {
+			continue
+		}
+
+		if err := det.repo.LinkDuplicate(ctx, member.ID, root, art.ClusterID, member.Similarity); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isEarlier reports whether a is published before b, the ID
+// breaks the tie
+func isEarlier(a, b models.NewsArticle) bool {
+	if a.PublishedAt.Equal(b.PublishedAt) {
+		return a.ID < b.ID
+	}
+
+	return a.PublishedAt.Before(b.PublishedAt)
+}
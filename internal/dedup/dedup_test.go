@@ -0,0 +1,160 @@
+package dedup
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+type memRepo struct {
+	arts []models.NewsArticle
+}
+
+func (repo *memRepo) DuplicateCandidates(ctx context.Context, bands []string, publishedSince, publishedUntil time.Time, limit int) ([]models.NewsArticle, error) {
+	var cands []models.NewsArticle
+	for _, art := range repo.arts {
+		if art.PublishedAt.Before(publishedSince) || art.PublishedAt.After(publishedUntil) {
+			continue
+		}
+
+		for _, band := range bands {
+			if slices.Contains(art.LSHBands, band) {
+				cands = append(cands, art)
+				break
+			}
+		}
+	}
+
+	return cands, nil
+}
+
+func (repo *memRepo) ClusterArticles(ctx context.Context, clusterID string) ([]models.NewsArticle, error) {
+	var arts []models.NewsArticle
+	for _, art := range repo.arts {
+		if art.ClusterID == clusterID {
+			arts = append(arts, art)
+		}
+	}
+
+	return arts, nil
+}
+
+func (repo *memRepo) LinkDuplicate(ctx context.Context, id, duplicateOf, clusterID string, similarity float64) error {
+	for i := range repo.arts {
+		if repo.arts[i].ID == id {
+			repo.arts[i].DuplicateOf = duplicateOf
+			repo.arts[i].ClusterID = clusterID
+			repo.arts[i].Similarity = similarity
+		}
+	}
+
+	return nil
+}
+
+const wireCopy = `Presiden Joko Widodo meresmikan jalan tol Semarang Demak seksi dua di Kabupaten Demak, Jawa Tengah, pada Jumat pagi.
+Jalan tol sepanjang enam belas kilometer itu diharapkan memangkas waktu tempuh dari Semarang ke Demak menjadi hanya dua puluh menit, sekaligus menjadi tanggul laut untuk mencegah banjir rob di pesisir utara Jawa Tengah.
+Presiden mengatakan pembangunan tol ini menelan biaya sekitar sepuluh triliun rupiah yang berasal dari APBN dan investasi badan usaha jalan tol.
+Ia berharap masyarakat dapat memanfaatkan jalan tol tersebut untuk mendorong pertumbuhan ekonomi di kawasan utara Jawa Tengah.`
+
+const otherStory = `Tim nasional sepak bola Indonesia berhasil mengalahkan Vietnam dengan skor dua gol tanpa balas dalam laga kualifikasi Piala Dunia.
+Pertandingan yang digelar di Stadion Utama Gelora Bung Karno, Jakarta, pada Selasa malam itu disaksikan lebih dari enam puluh ribu penonton.
+Pelatih tim nasional mengatakan kemenangan ini menjadi modal penting untuk laga tandang berikutnya melawan Irak bulan depan.`
+
+// article gets the article with a paragraph per line of body
+func article(id string, publishedAt time.Time, body string) models.NewsArticle {
+	art := models.NewsArticle{ID: id, PublishedAt: publishedAt}
+	for _, p := range strings.Split(body, "\n") {
+		data, _ := json.Marshal(models.ArticleTextContent{Text: p})
+		art.Contents = append(art.Contents, models.ArticleContent{Type: models.ContentParagraphText, Data: data})
+	}
+
+	return art
+}
+
+func TestDetect(t *testing.T) {
+	repo := new(memRepo)
+	det := NewDetector(repo)
+	ctx := context.Background()
+	now := time.Date(2024, 8, 12, 10, 0, 0, 0, time.UTC)
+
+	store := func(art models.NewsArticle) models.NewsArticle {
+		if _, err := det.Detect(ctx, &art); err != nil {
+			t.Fatal(err)
+		}
+
+		repo.arts = append(repo.arts, art)
+
+		return art
+	}
+
+	orig := store(article("a", now, wireCopy))
+	if orig.DuplicateOf != "" || orig.ClusterID != "a" {
+		t.Errorf("expecting original to be its own cluster, got %+v", orig.ClusterID)
+	}
+
+	edited := strings.Replace(wireCopy, "pada Jumat pagi", "pada Jumat (12/8/2024) pagi", 1)
+	dup := store(article("b", now.Add(time.Hour), edited))
+	if dup.DuplicateOf != "a" || dup.ClusterID != "a" || dup.Similarity < DefaultThreshold {
+		t.Errorf("expecting duplicate of a, got %q with similarity %.2f", dup.DuplicateOf, dup.Similarity)
+	}
+
+	// linked to the root, not to the duplicate it is most similar to
+	dupOfDup := store(article("c", now.Add(2*time.Hour), edited))
+	if dupOfDup.DuplicateOf != "a" || dupOfDup.ClusterID != "a" {
+		t.Errorf("expecting duplicate of a, got %q", dupOfDup.DuplicateOf)
+	}
+
+	other := store(article("d", now.Add(time.Hour), otherStory))
+	if other.DuplicateOf != "" || other.ClusterID != "d" {
+		t.Errorf("expecting different story not to be duplicate, got %q", other.DuplicateOf)
+	}
+
+	tooLate := store(article("e", now.Add(8*24*time.Hour), wireCopy))
+	if tooLate.DuplicateOf != "" {
+		t.Errorf("expecting article outside the window not to be duplicate, got %q", tooLate.DuplicateOf)
+	}
+
+	short := store(article("f", now.Add(time.Hour), "Jalan tol Semarang Demak diresmikan."))
+	if short.DuplicateOf != "" || len(short.MinHash) != 0 {
+		t.Error("expecting short body not to be fingerprinted")
+	}
+}
+
+func TestDetectEarlierArticle(t *testing.T) {
+	now := time.Date(2024, 8, 12, 10, 0, 0, 0, time.UTC)
+	det := NewDetector(new(memRepo))
+
+	later := article("b", now.Add(time.Hour), wireCopy)
+	det.Fingerprint(&later)
+	later.ClusterID = "b"
+
+	laterDup := article("c", now.Add(2*time.Hour), wireCopy)
+	det.Fingerprint(&laterDup)
+	laterDup.DuplicateOf = "b"
+	laterDup.ClusterID = "b"
+
+	repo := &memRepo{arts: []models.NewsArticle{later, laterDup}}
+	det.repo = repo
+
+	// the earlier article is not linked to an article published
+	// after it, the later copies is linked to it instead
+	earlier := article("a", now, wireCopy)
+	if dup, _ := det.Detect(context.Background(), &earlier); dup || earlier.ClusterID != "a" {
+		t.Errorf("expecting earlier article not to be a duplicate of later one")
+	}
+
+	for _, art := range repo.arts {
+		if art.DuplicateOf != "a" || art.ClusterID != "a" {
+			t.Errorf("expecting %s to be moved to the cluster of a, got %q of cluster %q", art.ID, art.DuplicateOf, art.ClusterID)
+		}
+	}
+
+	if repo.arts[0].Similarity < DefaultThreshold {
+		t.Errorf("expecting the similarity of b to be set, got %.2f", repo.arts[0].Similarity)
+	}
+}
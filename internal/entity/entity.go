@@ -0,0 +1,376 @@
+// Package entity extracts the people, organizations and locations
+// mentioned in the articles. The names is first matched with the
+// gazetteers, the default one of the Indonesian regions, state
+// institutions and parties, and the configured watchlists. The other
+// capitalized names is then typed by the words around it, like the
+// titles (Bupati, AKBP), the reporting verbs (kata, ujar) and the
+// organization and location words (PT, Partai, Desa, Jl.), and the
+// names without any clue is dropped. The later short mention of a
+// person, like Budi after Budi Santoso, is the same person.
+package entity
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idnlp"
+)
+
+// Mention is an entity mentioned in a text
+type Mention struct {
+	Name string
+	Type string
+	// Start and End is the byte offsets in the text
+	Start int
+	End   int
+	Text  string
+}
+
+type Extractor struct {
+	gaz *trie
+}
+
+// NewExtractor creates an extractor with the gazetteers, the entry
+// of a later gazetteer replaces the same entry of an earlier one
+func NewExtractor(gazetteers ...*Gazetteer) *Extractor {
+	ext := &Extractor{gaz: newTrie()}
+	for _, gaz := range gazetteers {
+		ext.gaz.add(gaz)
+	}
+
+	return ext
+}
+
+// ExtractText gets the entities mentioned in the text
+func (ext *Extractor) ExtractText(text string) []Mention {
+	mentions, _ := ext.extract(text)
+	return mentions
+}
+
+// Extract gets the entities mentioned in the headline and paragraph
+// blocks of the article, the most mentioned first
+func (ext *Extractor) Extract(art models.NewsArticle) []models.ArticleEntity {
+	type blockMention struct {
+		Mention
+		block int
+	}
+
+	var mentions, unknown []blockMention
+	add := func(block int, text string) {
+		found, unk := ext.extract(text)
+		for _, m := range found {
+			mentions = append(mentions, blockMention{m, block})
+		}
+
+		for _, m := range unk {
+			unknown = append(unknown, blockMention{m, block})
+		}
+	}
+
+	add(-1, art.Headline)
+	for i, content := range art.Contents {
+		if content.Type != models.ContentParagraphText {
+			continue
+		}
+
+		if text, ok := content.TextContent(); ok && text.Text != "" {
+			add(i, text.Text)
+		}
+	}
+
+	// the short mentions of a person is resolved to the full name
+	people := make(map[string][]string)
+	for _, m := range mentions {
+		if m.Type != models.EntityPerson || !strings.Contains(m.Name, " ") {
+			continue
+		}
+
+		for _, word := range strings.Fields(models.EntityKey(m.Name)) {
+			if len(word) >= 3 && !contains(people[word], m.Name) {
+				people[word] = append(people[word], m.Name)
+			}
+		}
+	}
+
+	resolve := func(name string) (string, bool) {
+		names := people[models.EntityKey(name)]
+		if len(names) == 1 {
+			return names[0], true
+		}
+
+		return "", false
+	}
+
+	for i, m := range mentions {
+		if m.Type != models.EntityPerson || strings.Contains(m.Name, " ") {
+			continue
+		}
+
+		if full, ok := resolve(m.Name); ok {
+			mentions[i].Name = full
+		}
+	}
+
+	for _, m := range unknown {
+		if full, ok := resolve(m.Name); ok {
+			m.Name = full
+			m.Type = models.EntityPerson
+			mentions = append(mentions, m)
+		}
+	}
+
+	var entities []models.ArticleEntity
+	idx := make(map[string]int)
+	for _, m := range mentions {
+		key := models.EntityKey(m.Name)
+		i, ok := idx[m.Type+"\x00"+key]
+		if !ok {
+			i = len(entities)
+			idx[m.Type+"\x00"+key] = i
+			entities = append(entities, models.ArticleEntity{Key: key, Name: m.Name, Type: m.Type})
+		}
+
+		entities[i].Count++
+		entities[i].Mentions = append(entities[i].Mentions, models.EntityMention{
+			Block: m.block,
+			Start: m.Start,
+			End:   m.End,
+			Text:  m.Text,
+		})
+	}
+
+	for i := range entities {
+		sort.SliceStable(entities[i].Mentions, func(a, b int) bool {
+			ma, mb := entities[i].Mentions[a], entities[i].Mentions[b]
+			if ma.Block != mb.Block {
+				return ma.Block < mb.Block
+			}
+
+			return ma.Start < mb.Start
+		})
+	}
+
+	sort.SliceStable(entities, func(i, j int) bool {
+		return entities[i].Count > entities[j].Count
+	})
+
+	return entities
+}
+
+// extract gets the mentions of the text, and the capitalized
+// names without any clue of its type
+func (ext *Extractor) extract(text string) (mentions, unknown []Mention) {
+	tokens := tokenize(text)
+
+	// titled is true after the titles, like Bupati in Bupati Demak
+	// Eisti'anah, the name after it is a person
+	titled := false
+	for i := 0; i < len(tokens); {
+		if k, n, ok := ext.gaz.match(tokens, text, i); ok {
+			mentions = append(mentions, newMention(k.name, k.typ, text, tokens[i:i+n]))
+			titled = titled && adjacent(text, tokens, i)
+			i += n
+			continue
+		}
+
+		if !isNameToken(tokens[i]) {
+			titled = false
+			i++
+			continue
+		}
+
+		j := ext.scanName(tokens, text, i)
+
+		// the capitalized stopwords, like Menurut at the start
+		// of a sentence, is not a part of the name
+		for i < j && tokens[i].Kind == idnlp.TokenWord && idnlp.IsStopword(tokens[i].Text) {
+			i++
+		}
+
+		if i == j {
+			titled = false
+			continue
+		}
+
+		start := i
+		for start < j && isTitle(tokens, start, i) {
+			start++
+		}
+
+		switch {
+		case start == j:
+			// only titles, the name is after the
+			// gazetteer name, like the region
+			titled = true
+			i = j
+			continue
+
+		case start > i || (titled && adjacent(text, tokens, i)):
+			mentions = append(mentions, newMention("", models.EntityPerson, text, tokens[start:j]))
+
+		default:
+			if typ, ok := classify(tokens, text, i, j); ok {
+				mentions = append(mentions, newMention("", typ, text, tokens[i:j]))
+			} else {
+				unknown = append(unknown, newMention("", "", text, tokens[i:j]))
+			}
+		}
+
+		titled = false
+		i = j
+	}
+
+	return mentions, unknown
+}
+
+// scanName gets the end of the capitalized name starting at the
+// token i, the name ends before a gazetteer name
+func (ext *Extractor) scanName(tokens []idnlp.Token, text string, i int) int {
+	j := i + 1
+	for j < len(tokens) && adjacent(text, tokens, j) {
+		if _, _, ok := ext.gaz.match(tokens, text, j); ok {
+			break
+		}
+
+		if isNameToken(tokens[j]) {
+			j++
+			continue
+		}
+
+		// the connector inside a name, like bin in Umar bin Khattab
+		if connectors[strings.ToLower(tokens[j].Text)] && j+1 < len(tokens) &&
+			adjacent(text, tokens, j+1) && isNameToken(tokens[j+1]) {
+			j += 2
+			continue
+		}
+
+		break
+	}
+
+	return j
+}
+
+// classify gets the type of the name from the tokens i to j
+// by its words and the words around it
+func classify(tokens []idnlp.Token, text string, i, j int) (string, bool) {
+	first := word(tokens[i])
+	last := word(tokens[j-1])
+
+	var prev, next string
+	if i > 0 {
+		prev = word(tokens[i-1])
+	}
+
+	if j < len(tokens) {
+		next = word(tokens[j])
+	}
+
+	switch {
+	case (orgWords[first] && j-i > 1) || last == "tbk":
+		return models.EntityOrganization, true
+
+	case locationWords[first] && j-i > 1:
+		return models.EntityLocation, true
+
+	case i > 0 && tokens[i-1].Kind == idnlp.TokenAbbrev && locationWords[prev]:
+		return models.EntityLocation, true
+
+	case reportingVerbs[prev] || reportingVerbsAfter[next]:
+		return models.EntityPerson, true
+
+	case (prev == "di" || prev == "ke") && adjacent(text, tokens, i):
+		return models.EntityLocation, true
+	}
+
+	return "", false
+}
+
+func newMention(name, typ, text string, tokens []idnlp.Token) Mention {
+	start := tokens[0].Start
+	end := tokens[len(tokens)-1].End
+	m := Mention{
+		Name:  name,
+		Type:  typ,
+		Start: start,
+		End:   end,
+		Text:  text[start:end],
+	}
+
+	if m.Name == "" {
+		m.Name = strings.Join(strings.Fields(m.Text), " ")
+	}
+
+	return m
+}
+
+// tokenize tokenizes the text, the compound words, like
+// Semarang-Demak, is split into its words while the reduplication,
+// like Bangsa-Bangsa, is kept as is
+func tokenize(text string) []idnlp.Token {
+	var tokens []idnlp.Token
+	for _, tok := range idnlp.Tokenize(text) {
+		parts := strings.Split(tok.Text, "-")
+		if tok.Kind != idnlp.TokenWord || len(parts) == 1 || (len(parts) == 2 && strings.EqualFold(parts[0], parts[1])) {
+			tokens = append(tokens, tok)
+			continue
+		}
+
+		start := tok.Start
+		for _, part := range parts {
+			if part != "" {
+				tokens = append(tokens, idnlp.Token{Text: part, Start: start, End: start + len(part), Kind: idnlp.TokenWord})
+			}
+
+			start += len(part) + 1
+		}
+	}
+
+	return tokens
+}
+
+// adjacent reports whether only spaces, or the hyphen of a
+// compound word, is between the token i and the previous token
+func adjacent(text string, tokens []idnlp.Token, i int) bool {
+	return i > 0 && joined(text[tokens[i-1].End:tokens[i].Start])
+}
+
+// word gets the lowercased token text without the dot
+func word(tok idnlp.Token) string {
+	return strings.ToLower(strings.TrimSuffix(tok.Text, "."))
+}
+
+// isNameToken reports whether the token can be a part of
+// a name, a capitalized word, an initial like M., or a title
+func isNameToken(tok idnlp.Token) bool {
+	switch tok.Kind {
+	case idnlp.TokenWord:
+		return isCapitalized(tok.Text)
+
+	case idnlp.TokenAbbrev:
+		return titles[word(tok)] || (len(tok.Text) == 2 && isCapitalized(tok.Text))
+	}
+
+	return false
+}
+
+// isTitle reports whether the token k is a title, from is the start
+// of the name, like Wali Kota or Wakil Presiden
+func isTitle(tokens []idnlp.Token, k, from int) bool {
+	w := word(tokens[k])
+	if titles[w] {
+		return true
+	}
+
+	return k > from && word(tokens[k-1]) == "wali" && w == "kota"
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,133 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+func TestExtractText(t *testing.T) {
+	ext := NewExtractor(DefaultGazetteer())
+
+	tests := []struct {
+		text     string
+		mentions []Mention
+	}{
+		{
+			"Kapolres Demak AKBP Budi Santoso meninjau Desa Sayung, Kab. Demak.",
+			[]Mention{
+				{"Demak", models.EntityLocation, 9, 14, "Demak"},
+				{"Budi Santoso", models.EntityPerson, 20, 32, "Budi Santoso"},
+				{"Desa Sayung", models.EntityLocation, 42, 53, "Desa Sayung"},
+				{"Demak", models.EntityLocation, 60, 65, "Demak"},
+			},
+		},
+		{
+			"Menurut Sri, PT Waskita Karya Tbk dan kpk belum dipanggil KPK.",
+			[]Mention{
+				{"Sri", models.EntityPerson, 8, 11, "Sri"},
+				{"PT Waskita Karya Tbk", models.EntityOrganization, 13, 33, "PT Waskita Karya Tbk"},
+				{"Komisi Pemberantasan Korupsi", models.EntityOrganization, 58, 61, "KPK"},
+			},
+		},
+		{
+			"\"Kami siaga,\" kata Ahmad bin Yusuf di Cikarang, Jatim.",
+			[]Mention{
+				{"Ahmad bin Yusuf", models.EntityPerson, 19, 34, "Ahmad bin Yusuf"},
+				{"Cikarang", models.EntityLocation, 38, 46, "Cikarang"},
+				{"Jawa Timur", models.EntityLocation, 48, 53, "Jatim"},
+			},
+		},
+		{
+			"Wali Kota Surabaya Eri Cahyadi bertemu Kementerian Hukum dan HAM.",
+			[]Mention{
+				{"Surabaya", models.EntityLocation, 10, 18, "Surabaya"},
+				{"Eri Cahyadi", models.EntityPerson, 19, 30, "Eri Cahyadi"},
+				{"Kementerian Hukum dan HAM", models.EntityOrganization, 39, 64, "Kementerian Hukum dan HAM"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		mentions := ext.ExtractText(tt.text)
+		if len(mentions) != len(tt.mentions) {
+			t.Errorf("%q:\nexpecting %+v\ngot %+v", tt.text, tt.mentions, mentions)
+			continue
+		}
+
+		for i := range mentions {
+			if mentions[i] != tt.mentions[i] {
+				t.Errorf("%q: expecting %+v, got %+v", tt.text, tt.mentions[i], mentions[i])
+			}
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	watchlist, err := ParseGazetteer([]byte(`
+people:
+  - {name: Joko Widodo, aliases: [Jokowi]}
+organizations:
+  - Waskita Karya
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ext := NewExtractor(DefaultGazetteer(), watchlist)
+
+	art := models.NewsArticle{Headline: "Jokowi Resmikan Tol Semarang-Demak"}
+	for _, p := range []string{
+		"Presiden Joko Widodo meresmikan tol yang dibangun Waskita Karya di Demak.",
+		"Menurut Budi Santoso, warga Demak menyambut baik tol itu.",
+		"\"Banjir rob berkurang,\" ujar Budi.",
+	} {
+		data, _ := json.Marshal(models.ArticleTextContent{Text: p})
+		art.Contents = append(art.Contents, models.ArticleContent{Type: models.ContentParagraphText, Data: data})
+	}
+
+	entities := ext.Extract(art)
+	byKey := make(map[string]models.ArticleEntity)
+	for _, e := range entities {
+		byKey[e.Key] = e
+	}
+
+	tests := []struct {
+		key   string
+		typ   string
+		count int
+	}{
+		{"joko widodo", models.EntityPerson, 2},
+		{"demak", models.EntityLocation, 3},
+		{"waskita karya", models.EntityOrganization, 1},
+		{"budi santoso", models.EntityPerson, 2},
+		{"semarang", models.EntityLocation, 1},
+	}
+
+	for _, tt := range tests {
+		e, ok := byKey[tt.key]
+		if !ok || e.Type != tt.typ || e.Count != tt.count {
+			t.Errorf("%s: expecting %s mentioned %d times, got %+v", tt.key, tt.typ, tt.count, e)
+		}
+	}
+
+	if len(entities) != len(tests) {
+		t.Errorf("expecting %d entities, got %+v", len(tests), entities)
+	}
+
+	if entities[0].Key != "demak" {
+		t.Errorf("expecting the most mentioned first, got %s", entities[0].Key)
+	}
+
+	jokowi := byKey["joko widodo"].Mentions
+	if jokowi[0].Block != -1 || jokowi[0].Text != "Jokowi" || jokowi[1].Block != 0 {
+		t.Errorf("unexpected mentions %+v", jokowi)
+	}
+
+	budi := byKey["budi santoso"].Mentions[1]
+	text, _ := art.Contents[budi.Block].TextContent()
+	if text.Text[budi.Start:budi.End] != "Budi" {
+		t.Errorf("unexpected mention offsets %+v", budi)
+	}
+}
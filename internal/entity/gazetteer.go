@@ -0,0 +1,259 @@
+package entity
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idnlp"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed data/gazetteer.yaml
+var defaultGazetteer []byte
+
+// Entry is a gazetteer entry, either written as the name
+// only or as the name and its aliases
+type Entry struct {
+	Name    string   `yaml:"name"`
+	Aliases []string `yaml:"aliases"`
+}
+
+func (entry *Entry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		entry.Name = node.Value
+		return nil
+	}
+
+	type plain Entry
+
+	return node.Decode((*plain)(entry))
+}
+
+// Gazetteer is the known names of the entities, like the default
+// provinces, ministries and parties, or a watchlist of the people
+// and companies to track
+type Gazetteer struct {
+	People        []Entry `yaml:"people"`
+	Organizations []Entry `yaml:"organizations"`
+	Locations     []Entry `yaml:"locations"`
+}
+
+// ParseGazetteer parses the gazetteer YAML
+func ParseGazetteer(data []byte) (*Gazetteer, error) {
+	var gaz Gazetteer
+	if err := yaml.Unmarshal(data, &gaz); err != nil {
+		return nil, fmt.Errorf("error parsing gazetteer: %w", err)
+	}
+
+	return &gaz, nil
+}
+
+// LoadGazetteer loads the gazetteer YAML file
+func LoadGazetteer(path string) (*Gazetteer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	gaz, err := ParseGazetteer(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return gaz, nil
+}
+
+// DefaultGazetteer gets the embedded gazetteer of the Indonesian
+// provinces, regencies, state institutions, ministries, parties
+// and state-owned companies
+func DefaultGazetteer() *Gazetteer {
+	gaz, err := ParseGazetteer(defaultGazetteer)
+	if err != nil {
+		panic(err)
+	}
+
+	return gaz
+}
+
+// namePart is a word of a gazetteer name
+type namePart struct {
+	word string
+	// exact is true for the all uppercase word, like KPK,
+	// which must be written as is
+	exact bool
+	// capitalized is true for the word that must be written
+	// capitalized, the lowercase word, like dan in Kementerian
+	// Hukum dan HAM, matches in any case
+	capitalized bool
+}
+
+// known is a gazetteer name
+type known struct {
+	parts []namePart
+	name  string
+	typ   string
+}
+
+// trie is the gazetteer names keyed by the lowercased words
+type trie struct {
+	children map[string]*trie
+	names    []known
+}
+
+func newTrie() *trie {
+	return &trie{children: make(map[string]*trie)}
+}
+
+func (t *trie) add(gaz *Gazetteer) {
+	for _, group := range []struct {
+		typ     string
+		entries []Entry
+	}{
+		{models.EntityPerson, gaz.People},
+		{models.EntityOrganization, gaz.Organizations},
+		{models.EntityLocation, gaz.Locations},
+	} {
+		for _, entry := range group.entries {
+			name := strings.Join(strings.Fields(entry.Name), " ")
+			if name == "" {
+				continue
+			}
+
+			for _, alias := range append([]string{name}, entry.Aliases...) {
+				t.insert(known{parts: nameParts(alias), name: name, typ: group.typ})
+			}
+		}
+	}
+}
+
+func (t *trie) insert(k known) {
+	if len(k.parts) == 0 {
+		return
+	}
+
+	node := t
+	for _, part := range k.parts {
+		child, ok := node.children[part.word]
+		if !ok {
+			child = newTrie()
+			node.children[part.word] = child
+		}
+
+		node = child
+	}
+
+	// the later entry, like of a watchlist, replaces the
+	// earlier one of the same type
+	for i, existing := range node.names {
+		if existing.typ == k.typ && sameParts(existing.parts, k.parts) {
+			node.names[i] = k
+			return
+		}
+	}
+
+	node.names = append(node.names, k)
+}
+
+// match finds the longest gazetteer name starting at the token i,
+// and gets the number of tokens it spans
+func (t *trie) match(tokens []idnlp.Token, text string, i int) (known, int, bool) {
+	var best known
+	var bestLen int
+	node := t
+	for j := i; j < len(tokens); j++ {
+		if j > i && !adjacent(text, tokens, j) {
+			break
+		}
+
+		child, ok := node.children[strings.ToLower(tokens[j].Text)]
+		if !ok {
+			break
+		}
+
+		node = child
+		for _, k := range node.names {
+			if j-i+1 > bestLen && k.matches(tokens[i:j+1]) {
+				best = k
+				bestLen = j - i + 1
+			}
+		}
+	}
+
+	return best, bestLen, bestLen > 0
+}
+
+func (k known) matches(tokens []idnlp.Token) bool {
+	for i, part := range k.parts {
+		switch {
+		case part.exact && tokens[i].Text != strings.ToUpper(tokens[i].Text):
+			return false
+
+		case part.capitalized && !isCapitalized(tokens[i].Text):
+			return false
+		}
+	}
+
+	return true
+}
+
+func nameParts(name string) []namePart {
+	var parts []namePart
+	for _, tok := range tokenize(name) {
+		parts = append(parts, namePart{
+			word:        strings.ToLower(tok.Text),
+			exact:       isAcronym(tok.Text),
+			capitalized: isCapitalized(tok.Text),
+		})
+	}
+
+	return parts
+}
+
+func sameParts(a, b []namePart) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isCapitalized reports whether the word starts with an
+// uppercase letter or a digit
+func isCapitalized(word string) bool {
+	r, _ := utf8.DecodeRuneInString(word)
+	return unicode.IsUpper(r) || unicode.IsDigit(r)
+}
+
+// isAcronym reports whether the word is two or more
+// uppercase letters, like KPK
+func isAcronym(word string) bool {
+	letters := 0
+	for _, r := range word {
+		if unicode.IsLower(r) {
+			return false
+		}
+
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+
+	return letters >= 2
+}
+
+// joined reports whether the gap between two words is
+// spaces or a hyphen
+func joined(gap string) bool {
+	return gap == "-" || strings.TrimSpace(gap) == ""
+}
@@ -0,0 +1,70 @@
+package entity
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+
+	return m
+}
+
+// titles is the lowercased titles, positions and ranks written
+// before the name of a person
+var titles = set(
+	"presiden", "wapres", "wakil", "menteri", "menko", "wamen", "gubernur", "wagub",
+	"bupati", "wabup", "wali", "walkot", "camat", "lurah", "kades", "ketua", "ketum",
+	"sekjen", "sekretaris", "bendahara", "direktur", "dirut", "kepala", "kabid", "kadis",
+	"kapolri", "wakapolri", "kapolda", "wakapolda", "kapolres", "kapolresta", "kapolsek",
+	"kasat", "kasatreskrim", "kasatlantas", "kasi", "kabag", "kasubbag",
+	"panglima", "pangdam", "dandim", "danrem", "kajati", "kajari", "jaksa", "hakim",
+	"pak", "bu", "bapak", "ibu", "mas", "mbak", "kak", "bang", "habib", "ustaz", "ustadz",
+	"kiai", "kyai", "gus", "haji", "hajah", "sultan", "raja", "ratu", "pangeran", "paus",
+	"dr", "prof", "ir", "drs", "dra", "h", "hj", "kh", "sdr", "bpk", "ny", "tn",
+	"jenderal", "jend", "brigjen", "mayjen", "letjen", "irjen", "komjen", "kombes", "akbp",
+	"kompol", "akp", "iptu", "ipda", "aiptu", "aipda", "bripka", "brigpol", "briptu",
+	"bripda", "kolonel", "kol", "letkol", "mayor", "kapten", "kapt", "lettu", "letda",
+	"serka", "sertu", "serda", "kopral", "pratu", "laksamana", "marsekal", "juru", "jubir",
+	"anggota", "senator", "pelatih", "striker", "penyidik", "tersangka", "terdakwa",
+)
+
+// orgWords is the lowercased first words of an organization name
+var orgWords = set(
+	"pt", "cv", "ud", "perum", "perumda", "persero", "kementerian", "kemenko", "partai",
+	"bank", "universitas", "institut", "politeknik", "sekolah", "sma", "smp", "sd", "smk",
+	"madrasah", "pesantren", "ponpes", "komisi", "badan", "dinas", "pemkab", "pemkot",
+	"pemprov", "pemda", "polres", "polresta", "polrestabes", "polda", "polsek", "kodam",
+	"kodim", "korem", "koramil", "lanud", "lantamal", "dprd", "pengadilan", "kejaksaan",
+	"kejari", "kejati", "rsud", "rs", "yayasan", "persatuan", "perhimpunan", "asosiasi",
+	"ikatan", "lembaga", "komite", "koperasi", "himpunan", "serikat", "gabungan",
+	"federasi", "grup", "klub", "majelis", "dewan", "balai", "direktorat",
+	"ditjen", "otorita", "satgas", "tim", "gerakan", "relawan", "kantor",
+)
+
+// locationWords is the lowercased first words of a location name,
+// also the abbreviations written before it, like Kab. and Jl.
+var locationWords = set(
+	"kabupaten", "kab", "kota", "provinsi", "prov", "kecamatan", "kec", "desa", "ds",
+	"kelurahan", "kel", "dusun", "kampung", "jalan", "jl", "jln", "gang", "gg", "pulau",
+	"gunung", "sungai", "selat", "teluk", "danau", "pantai", "pelabuhan", "bandara",
+	"bandar", "stasiun", "terminal", "pasar", "taman", "masjid", "gereja", "pura", "vihara",
+	"klenteng", "candi", "alun-alun", "lapangan", "stadion", "tol", "perumahan", "kompleks",
+)
+
+// reportingVerbs is the verbs written before the person quoted,
+// like "...," kata Budi, or Menurut Budi
+var reportingVerbs = set(
+	"menurut", "kata", "ujar", "ucap", "ungkap", "jelas", "tutur", "imbuh", "tambah", "sebut",
+	"terang", "tegas", "papar", "pungkas", "tukas", "timpal", "lanjut", "bilang",
+)
+
+// reportingVerbsAfter is the verbs written after the person quoted,
+// like Budi mengatakan
+var reportingVerbsAfter = set(
+	"mengatakan", "menjelaskan", "menuturkan", "mengungkapkan", "menyebut", "menyebutkan",
+	"menegaskan", "berkata", "menambahkan", "memaparkan", "menerangkan", "mengaku",
+	"berharap", "meminta", "mengimbau", "menilai", "menyampaikan",
+)
+
+// connectors is the lowercase words inside a person name
+var connectors = set("bin", "binti", "van", "der", "de", "al", "von", "da")
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ArticleFilter filters the stored articles, the zero
+// fields is not filtered
+type ArticleFilter struct {
+	Source  string
+	Channel string
+	// Since and Until is the range of the publish time,
+	// Until is exclusive
+	Since time.Time
+	Until time.Time
+	// Terms is the stemmed terms the articles must all have,
+	// see pkg/idnlp.Terms
+	Terms []string
+}
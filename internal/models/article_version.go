@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+const (
+	BlockInserted = "inserted"
+	BlockDeleted  = "deleted"
+	BlockModified = "modified"
+)
+
+// TextChange is a change of a single text field, like the headline
+type TextChange struct {
+	Old string `bson:"old" json:"old"`
+	New string `bson:"new" json:"new"`
+}
+
+// BlockChange is a change of a content block. OldIndex is -1 for
+// inserted block, and NewIndex is -1 for deleted block
+type BlockChange struct {
+	Op       string `bson:"op" json:"op"`
+	Type     string `bson:"type" json:"type"`
+	OldIndex int    `bson:"old_index" json:"old_index"`
+	NewIndex int    `bson:"new_index" json:"new_index"`
+	Old      string `bson:"old" json:"old"`
+	New      string `bson:"new" json:"new"`
+}
+
+// ArticleDiff is the block level diff between two versions
+// of an article
+type ArticleDiff struct {
+	Headline    *TextChange   `bson:"headline,omitempty" json:"headline,omitempty"`
+	Description *TextChange   `bson:"description,omitempty" json:"description,omitempty"`
+	Blocks      []BlockChange `bson:"blocks" json:"blocks"`
+}
+
+// IsEmpty reports whether there is no change
+func (diff ArticleDiff) IsEmpty() bool {
+	return diff.Headline == nil && diff.Description == nil && len(diff.Blocks) == 0
+}
+
+// ArticleVersion is a previous version of an article, kept when
+// the article is edited after it is crawled
+type ArticleVersion struct {
+	ID        string `bson:"_id" json:"id"`
+	ArticleID string `bson:"article_id" json:"article_id"`
+	Link      string `bson:"link" json:"link"`
+	Version   int    `bson:"version" json:"version"`
+	// Article is the article as it was on this version
+	Article NewsArticle `bson:"article" json:"article"`
+	// Diff is the changes from this version to the next one
+	Diff ArticleDiff `bson:"diff" json:"diff"`
+	// DetectedAt is when the next version is found
+	DetectedAt time.Time `bson:"detected_at" json:"detected_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "strings"
+
+const (
+	EntityPerson       = "person"
+	EntityOrganization = "organization"
+	EntityLocation     = "location"
+)
+
+// EntityMention is where an entity is mentioned in the article
+type EntityMention struct {
+	// Block is the index of the content block, or -1 for
+	// the headline
+	Block int `bson:"block" json:"block"`
+	// Start and End is the byte offsets of the mention
+	// in the block text
+	Start int    `bson:"start" json:"start"`
+	End   int    `bson:"end" json:"end"`
+	Text  string `bson:"text" json:"text"`
+}
+
+// ArticleEntity is a person, organization or location
+// mentioned in the article
+type ArticleEntity struct {
+	// Key is the lowercased name, used to find the
+	// articles mentioning the entity
+	Key      string          `bson:"key" json:"key"`
+	Name     string          `bson:"name" json:"name"`
+	Type     string          `bson:"type" json:"type"`
+	Count    int             `bson:"count" json:"count"`
+	Mentions []EntityMention `bson:"mentions" json:"mentions"`
+}
+
+// EntityKey gets the key of the entity name
+func EntityKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
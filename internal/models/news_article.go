@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 )
 
@@ -12,11 +13,25 @@ const (
 	Detik    = "Detik.com"
 )
 
+const (
+	ContentSectionTitle      = "section-title"
+	ContentParagraphText     = "paragraph-text"
+	ContentImage             = "image"
+	ContentVideo             = "video"
+	ContentReferencedArticle = "referenced-article-link"
+)
+
 type ArticleAuthor struct {
 	Name       string `bson:"name" json:"name"`
 	ProfileURL string `bson:"profile_url" json:"profile_url"`
 }
 
+type ArticlePublisher struct {
+	Name    string `bson:"name" json:"name"`
+	URL     string `bson:"url" json:"url"`
+	LogoURL string `bson:"logo_url" json:"logo_url"`
+}
+
 type ArticleImageContent struct {
 	URL     string `bson:"url" json:"url"`
 	Title   string `bson:"title" json:"title"`
@@ -35,11 +50,81 @@ type ArticleVideoContent struct {
 	Thumbnail   string `bson:"thumbnail" json:"thumbnail"`
 }
 
+// ArticleTextContent is a text block, like paragraph and section
+// title, in sanitized HTML, plain text and Markdown
+type ArticleTextContent struct {
+	HTML     string `bson:"html" json:"html"`
+	Text     string `bson:"text" json:"text"`
+	Markdown string `bson:"markdown" json:"markdown"`
+}
+
+type ArticleReferenceContent struct {
+	Headline    string `bson:"headline" json:"headline"`
+	ArticleLink string `bson:"article_link" json:"article_link"`
+}
+
 type ArticleContent struct {
 	Type string          `bson:"type" json:"type"`
 	Data json.RawMessage `bson:"data" json:"data"`
 }
 
+// TextContent decodes the data of a text block. Plain string
+// data is treated as the text
+func (content ArticleContent) TextContent() (ArticleTextContent, bool) {
+	var text ArticleTextContent
+	if err := json.Unmarshal(content.Data, &text); err == nil {
+		return text, true
+	}
+
+	if err := json.Unmarshal(content.Data, &text.Text); err == nil {
+		return text, true
+	}
+
+	return text, false
+}
+
+// RemovedContent is a content block removed by the content cleanup,
+// kept on the article so the cleanup can be audited
+type RemovedContent struct {
+	// Index is the index of the block in the original contents
+	Index   int            `bson:"index" json:"index"`
+	Rule    string         `bson:"rule" json:"rule"`
+	Reason  string         `bson:"reason" json:"reason"`
+	Content ArticleContent `bson:"content" json:"content"`
+}
+
+const (
+	ArticleStatusActive  = "active"
+	ArticleStatusRemoved = "removed"
+)
+
+const (
+	RemovalNotFound         = "not-found"
+	RemovalGone             = "gone"
+	RemovalRedirectHomepage = "redirect-homepage"
+)
+
+// ArticleRemoval is the tombstone of an article that is
+// deleted or retracted by the publisher
+type ArticleRemoval struct {
+	// Reason is either not-found, gone or redirect-homepage
+	Reason     string `bson:"reason" json:"reason"`
+	StatusCode int    `bson:"status_code" json:"status_code"`
+	// RedirectURL is where the article link is redirected to
+	RedirectURL string `bson:"redirect_url,omitempty" json:"redirect_url,omitempty"`
+	// ObservedAt is when the removal is first observed, the
+	// actual removal is somewhere between the previous check
+	// and this
+	ObservedAt time.Time `bson:"observed_at" json:"observed_at"`
+}
+
+// RemovedReport is the number of removed articles of a source
+type RemovedReport struct {
+	Source         ArticleSource `bson:"_id" json:"source"`
+	Count          int           `bson:"count" json:"count"`
+	LastObservedAt time.Time     `bson:"last_observed_at" json:"last_observed_at"`
+}
+
 type RelatedArticle struct {
 	Title       string              `bson:"title" json:"title"`
 	ArticleLink string              `bson:"related_article"`
@@ -47,13 +132,126 @@ type RelatedArticle struct {
 }
 
 type NewsArticle struct {
-	ID              string           `bson:"_id" json:"-"`
-	Source          ArticleSource    `bson:"source" json:"source"`
-	Headline        string           `bson:"headline" json:"headline"`
-	Description     string           `bson:"description" json:"description"`
-	PublishedAt     time.Time        `bson:"published_at" json:"published_at"`
-	UpdatedAt       time.Time        `bson:"updated_at" json:"updated_at"`
-	Author          ArticleAuthor    `bson:"article_author" json:"article_author"`
-	Contents        []ArticleContent `bson:"contents" json:"contents"`
-	RelatedArticles []RelatedArticle `bson:"related_articles" json:"related_articles"`
+	ID              string              `bson:"_id" json:"-"`
+	Source          ArticleSource       `bson:"source" json:"source"`
+	Link            string              `bson:"link" json:"link"`
+	CanonicalURL    string              `bson:"canonical_url" json:"canonical_url"`
+	Headline        string              `bson:"headline" json:"headline"`
+	Description     string              `bson:"description" json:"description"`
+	Image           ArticleImageContent `bson:"image" json:"image"`
+	Section         string              `bson:"section" json:"section"`
+	Channel         string              `bson:"channel" json:"channel"`
+	Categories      []string            `bson:"categories" json:"categories"`
+	Tags            []string            `bson:"tags" json:"tags"`
+	Keywords        []string            `bson:"keywords" json:"keywords"`
+	WordCount       int                 `bson:"word_count" json:"word_count"`
+	PublishedAt     time.Time           `bson:"published_at" json:"published_at"`
+	UpdatedAt       time.Time           `bson:"updated_at" json:"updated_at"`
+	Author          ArticleAuthor       `bson:"article_author" json:"article_author"`
+	Publisher       ArticlePublisher    `bson:"publisher" json:"publisher"`
+	Contents        []ArticleContent    `bson:"contents" json:"contents"`
+	RelatedArticles []RelatedArticle    `bson:"related_articles" json:"related_articles"`
+	Pages           int                 `bson:"pages,omitempty" json:"pages,omitempty"`
+	RemovedContents []RemovedContent    `bson:"removed_contents" json:"removed_contents"`
+	// ContentHash is the hash of the headline and body, used to
+	// detect the edits when the article is re-crawled
+	ContentHash string    `bson:"content_hash" json:"content_hash"`
+	Version     int       `bson:"version" json:"version"`
+	CrawledAt   time.Time `bson:"crawled_at" json:"crawled_at"`
+	// ChangedAt is when the article is first crawled or its changes
+	// is last detected, an unchanged re-crawl keeps it
+	ChangedAt time.Time `bson:"changed_at" json:"changed_at"`
+	// Status is either active or removed, articles stored before
+	// the tombstone check has no status and is treated as active
+	Status  string          `bson:"status" json:"status"`
+	Removal *ArticleRemoval `bson:"removal,omitempty" json:"removal,omitempty"`
+	// CheckedAt is when the article link is last checked
+	// for removal
+	CheckedAt time.Time `bson:"checked_at" json:"checked_at"`
+	// Aliases is the other URLs the article is found under, like
+	// before redirect. Link is the canonical URL, normalized with
+	// urlnorm, and is the storage key
+	Aliases []string `bson:"aliases,omitempty" json:"aliases,omitempty"`
+	// MinHash is the MinHash signature of the body and LSHBands is
+	// its band keys, used to find the near-duplicate articles
+	MinHash  []uint32 `bson:"minhash,omitempty" json:"-"`
+	LSHBands []string `bson:"lsh_bands,omitempty" json:"-"`
+	// DuplicateOf is the ID of the earliest article the article is
+	// a near-duplicate of, like the same wire copy with small edits,
+	// and Similarity is the estimated similarity to the most similar
+	// of the near-duplicates
+	DuplicateOf string  `bson:"duplicate_of,omitempty" json:"duplicate_of,omitempty"`
+	Similarity  float64 `bson:"similarity,omitempty" json:"similarity,omitempty"`
+	// ClusterID is the ID of the earliest article of the
+	// near-duplicates, or the article ID itself. Counts and
+	// aggregates should count each cluster once
+	ClusterID string `bson:"cluster_id" json:"cluster_id"`
+	// StoryID is the ID of the story cluster, the articles
+	// about the same event from any source
+	StoryID string `bson:"story_id,omitempty" json:"story_id,omitempty"`
+	// Terms is the stemmed searchable terms of the headline and
+	// paragraph blocks, without the stopwords
+	Terms []string `bson:"terms,omitempty" json:"-"`
+	// Entities is the people, organizations and locations
+	// mentioned in the article
+	Entities  []ArticleEntity   `bson:"entities,omitempty" json:"entities,omitempty"`
+	Sentiment *ArticleSentiment `bson:"sentiment,omitempty" json:"sentiment,omitempty"`
+	Summary   *ArticleSummary   `bson:"summary,omitempty" json:"summary,omitempty"`
+}
+
+// ArticleWithID is the article with its ID, which is not in the
+// JSON of NewsArticle. It is the article of the API responses
+// and the webhook payloads
+type ArticleWithID struct {
+	ID string `json:"id"`
+	NewsArticle
+}
+
+// WithID gets the article with its ID for the JSON
+func (art NewsArticle) WithID() ArticleWithID {
+	return ArticleWithID{ID: art.ID, NewsArticle: art}
+}
+
+// Paragraphs gets the plain text of the paragraph blocks
+func (art NewsArticle) Paragraphs() []string {
+	var paragraphs []string
+	for _, content := range art.Contents {
+		if content.Type != ContentParagraphText {
+			continue
+		}
+
+		if text, ok := content.TextContent(); ok && text.Text != "" {
+			paragraphs = append(paragraphs, text.Text)
+		}
+	}
+
+	return paragraphs
+}
+
+// NormalizeTerm normalizes a tag, keyword or category so
+// the same term from different sources can be matched
+func NormalizeTerm(term string) string {
+	term = strings.ReplaceAll(term, "\u00a0", " ")
+	term = strings.TrimLeft(strings.TrimSpace(term), "#")
+	term = strings.Join(strings.Fields(term), " ")
+
+	return strings.ToLower(term)
+}
+
+// NormalizeTerms normalizes terms and removes the empty
+// and duplicate terms, the order of the terms is kept
+func NormalizeTerms(terms []string) []string {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		term = NormalizeTerm(term)
+		if term == "" || seen[term] {
+			continue
+		}
+
+		seen[term] = true
+		normalized = append(normalized, term)
+	}
+
+	return normalized
 }
@@ -0,0 +1,50 @@
+package models
+
+const (
+	SentimentPositive = "positive"
+	SentimentNegative = "negative"
+	SentimentNeutral  = "neutral"
+)
+
+// SentenceSentiment is the sentiment of a sentence
+type SentenceSentiment struct {
+	// Block is the index of the content block, or -1 for
+	// the headline
+	Block int `bson:"block" json:"block"`
+	// Start and End is the byte offsets of the sentence
+	// in the block text
+	Start int     `bson:"start" json:"start"`
+	End   int     `bson:"end" json:"end"`
+	Score float64 `bson:"score" json:"score"`
+	Label string  `bson:"label" json:"label"`
+}
+
+// EntitySentiment is the sentiment of the sentences
+// mentioning an entity
+type EntitySentiment struct {
+	Key   string  `bson:"key" json:"key"`
+	Name  string  `bson:"name" json:"name"`
+	Type  string  `bson:"type" json:"type"`
+	Score float64 `bson:"score" json:"score"`
+	Label string  `bson:"label" json:"label"`
+	// Sentences is the number of sentences mentioning
+	// the entity
+	Sentences int `bson:"sentences" json:"sentences"`
+}
+
+// ArticleSentiment is the sentiment of an article. The score is
+// from -1 (very negative) to 1 (very positive)
+type ArticleSentiment struct {
+	// LexiconVersion is the version of the lexicon used,
+	// the article is scored again when it is changed
+	LexiconVersion string  `bson:"lexicon_version" json:"lexicon_version"`
+	Score          float64 `bson:"score" json:"score"`
+	Label          string  `bson:"label" json:"label"`
+	// Positive, Negative and Neutral is the number
+	// of sentences of each label
+	Positive  int                 `bson:"positive" json:"positive"`
+	Negative  int                 `bson:"negative" json:"negative"`
+	Neutral   int                 `bson:"neutral" json:"neutral"`
+	Sentences []SentenceSentiment `bson:"sentences" json:"sentences"`
+	Entities  []EntitySentiment   `bson:"entities,omitempty" json:"entities,omitempty"`
+}
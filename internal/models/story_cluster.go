@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// StoryMember is an article of a story cluster
+type StoryMember struct {
+	ArticleID   string        `bson:"article_id" json:"article_id"`
+	Link        string        `bson:"link" json:"link"`
+	Source      ArticleSource `bson:"source" json:"source"`
+	Headline    string        `bson:"headline" json:"headline"`
+	PublishedAt time.Time     `bson:"published_at" json:"published_at"`
+	// Similarity is the similarity to the cluster when
+	// the article is added
+	Similarity float64 `bson:"similarity" json:"similarity"`
+}
+
+// StorySource is the coverage of a story by a source
+type StorySource struct {
+	Source      ArticleSource `bson:"source" json:"source"`
+	Count       int           `bson:"count" json:"count"`
+	FirstSeenAt time.Time     `bson:"first_seen_at" json:"first_seen_at"`
+}
+
+// StoryCluster is the articles about the same event, from
+// any source
+type StoryCluster struct {
+	ID string `bson:"_id" json:"id"`
+	// Headline is the headline of the representative article,
+	// the member most similar to the cluster
+	Headline         string        `bson:"headline" json:"headline"`
+	RepresentativeID string        `bson:"representative_id" json:"representative_id"`
+	Members          []StoryMember `bson:"members" json:"members"`
+	// FirstSeenAt and FirstSource is when and by whom the
+	// story is first published
+	FirstSeenAt time.Time     `bson:"first_seen_at" json:"first_seen_at"`
+	FirstSource ArticleSource `bson:"first_source" json:"first_source"`
+	LastSeenAt  time.Time     `bson:"last_seen_at" json:"last_seen_at"`
+	// Sources is the source spread, the earliest source first
+	Sources     []StorySource `bson:"sources" json:"sources"`
+	SourceCount int           `bson:"source_count" json:"source_count"`
+	// Terms is the summed term frequencies of the members, the
+	// centroid of the cluster is Terms divided by len(Members)
+	Terms     map[string]float64 `bson:"terms" json:"-"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// SummarySentence is a sentence selected to the summary
+type SummarySentence struct {
+	// Index is the index of the sentence among the sentences
+	// of the paragraph blocks
+	Index int `bson:"index" json:"index"`
+	// Block is the index of the content block, Start and End
+	// is the byte offsets of the sentence in the block text
+	Block int `bson:"block" json:"block"`
+	Start int `bson:"start" json:"start"`
+	End   int `bson:"end" json:"end"`
+}
+
+// ArticleSummary is the extractive summary of an article, the
+// most central sentences of its paragraphs in the article order
+type ArticleSummary struct {
+	Text      string            `bson:"text" json:"text"`
+	Sentences []SummarySentence `bson:"sentences" json:"sentences"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+const (
+	TrendTerm   = "term"
+	TrendPhrase = "phrase"
+	TrendEntity = "entity"
+)
+
+// TrendItem is a trending term, phrase or entity
+type TrendItem struct {
+	Kind string `bson:"kind" json:"kind"`
+	// Key is the lowercased text, or the entity key
+	Key  string `bson:"key" json:"key"`
+	Text string `bson:"text" json:"text"`
+	// EntityType is the type of the entity item
+	EntityType string `bson:"entity_type,omitempty" json:"entity_type,omitempty"`
+	// Count is the number of articles in the window, and
+	// Expected is the number expected by the baseline
+	Count    int     `bson:"count" json:"count"`
+	Expected float64 `bson:"expected" json:"expected"`
+	// Score is the burst score, how much the count is
+	// above the expected
+	Score float64 `bson:"score" json:"score"`
+}
+
+// TrendSnapshot is the trending items of a source and channel
+// at a time. Source and Channel is empty for all
+type TrendSnapshot struct {
+	ID            string        `bson:"_id" json:"id"`
+	Source        ArticleSource `bson:"source" json:"source"`
+	Channel       string        `bson:"channel" json:"channel"`
+	WindowStart   time.Time     `bson:"window_start" json:"window_start"`
+	WindowEnd     time.Time     `bson:"window_end" json:"window_end"`
+	BaselineStart time.Time     `bson:"baseline_start" json:"baseline_start"`
+	// Articles is the number of articles in the window
+	Articles  int         `bson:"articles" json:"articles"`
+	Terms     []TrendItem `bson:"terms" json:"terms"`
+	Phrases   []TrendItem `bson:"phrases" json:"phrases"`
+	Entities  []TrendItem `bson:"entities" json:"entities"`
+	CreatedAt time.Time   `bson:"created_at" json:"created_at"`
+}
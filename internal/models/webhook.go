@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	// WebhookDead is the delivery given up after the maximum
+	// attempts, kept as the dead letter
+	WebhookDead = "dead"
+)
+
+// WebhookEndpoint is a registered receiver of the stored articles.
+// The empty filters is not filtered
+type WebhookEndpoint struct {
+	ID  string `bson:"_id" json:"id"`
+	URL string `bson:"url" json:"url"`
+	// Secret is the HMAC key of the payload signature
+	Secret  string   `bson:"secret" json:"-"`
+	Sources []string `bson:"sources" json:"sources"`
+	// Channels is normalized with NormalizeTerm, like
+	// the channel of the articles
+	Channels []string `bson:"channels" json:"channels"`
+	// Keywords is matched by their root words, an article
+	// matches when it has all the words of any keyword
+	Keywords  []string  `bson:"keywords" json:"keywords"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// WebhookDelivery is the delivery of an article to an endpoint,
+// and its delivery log
+type WebhookDelivery struct {
+	ID         string `bson:"_id" json:"id"`
+	EndpointID string `bson:"endpoint_id" json:"endpoint_id"`
+	ArticleID  string `bson:"article_id" json:"article_id"`
+	Event      string `bson:"event" json:"event"`
+	// Payload is the JSON body, kept so the delivery
+	// is retried after restart
+	Payload  []byte `bson:"payload" json:"-"`
+	Status   string `bson:"status" json:"status"`
+	Attempts int    `bson:"attempts" json:"attempts"`
+	// LastStatusCode and LastError is the result of the
+	// last attempt
+	LastStatusCode int       `bson:"last_status_code,omitempty" json:"last_status_code,omitempty"`
+	LastError      string    `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	NextAttemptAt  time.Time `bson:"next_attempt_at" json:"next_attempt_at"`
+	DeliveredAt    time.Time `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	CreatedAt      time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,238 @@
+// Package pubsub is the in-process hub the stored articles is
+// published to, and the live feed subscribes to. The publisher is
+// never blocked: each subscription has a bounded buffer, and a
+// subscriber too slow to drain it is dropped. The recent events is
+// kept, so a dropped or reconnecting subscriber resumes from the
+// last event it got without missing any, unless the event is no
+// longer kept or is published before a restart.
+package pubsub
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+const (
+	DefaultHistory = 1000
+	DefaultBuffer  = 64
+)
+
+const (
+	EventCreated = "created"
+	EventUpdated = "updated"
+)
+
+// ErrSlowConsumer is the error of the subscription dropped
+// because its buffer is full
+var ErrSlowConsumer = errors.New("subscriber is too slow, resume from the last event")
+
+// Event is a stored article. The ID is increasing, and is the
+// publish time in nanoseconds so it keeps increasing after restart
+type Event struct {
+	ID      uint64
+	Type    string
+	Article models.NewsArticle
+}
+
+// Filter filters the events of a subscription, the empty
+// fields is not filtered
+type Filter struct {
+	// Sources is the sources of the articles, any of it
+	Sources []string
+	// Terms is the stemmed terms the articles must all have,
+	// see pkg/idnlp.Terms
+	Terms []string
+	// Entities is the keys of the entities the articles
+	// mention, any of it. See models.EntityKey
+	Entities []string
+}
+
+// Match reports whether the article matches the filter
+func (filter Filter) Match(art models.NewsArticle) bool {
+	if len(filter.Sources) != 0 && !slices.Contains(filter.Sources, string(art.Source)) {
+		return false
+	}
+
+	for _, term := range filter.Terms {
+		if !slices.Contains(art.Terms, term) {
+			return false
+		}
+	}
+
+	if len(filter.Entities) == 0 {
+		return true
+	}
+
+	for _, e := range art.Entities {
+		if slices.Contains(filter.Entities, e.Key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Subscription struct {
+	hub    *Hub
+	filter Filter
+	events chan Event
+	missed bool
+	err    error
+	closed bool
+}
+
+// Missed reports whether some events after the last event ID
+// subscribed with is no longer kept, so the subscriber should
+// fetch the articles changed since then from the stored articles.
+// The event ID is its publish time, see Event
+func (sub *Subscription) Missed() bool {
+	return sub.missed
+}
+
+// Events gets the events of the subscription, it is closed when
+// the subscription is closed or dropped, see Err
+func (sub *Subscription) Events() <-chan Event {
+	return sub.events
+}
+
+// Err gets ErrSlowConsumer when the subscription is dropped
+// because its buffer is full
+func (sub *Subscription) Err() error {
+	sub.hub.mx.Lock()
+	defer sub.hub.mx.Unlock()
+
+	return sub.err
+}
+
+// Close unsubscribes, it is safe to call it more than once
+func (sub *Subscription) Close() {
+	sub.hub.mx.Lock()
+	defer sub.hub.mx.Unlock()
+
+	sub.hub.drop(sub, nil)
+}
+
+type Hub struct {
+	historySize int
+	bufferSize  int
+	now         func() time.Time
+
+	mx     sync.Mutex
+	lastID uint64
+	// history is the recent events, the oldest first
+	history []Event
+	// keptAfter is the ID the events after it is all kept, it is
+	// the start time at first, then the last ID dropped from history
+	keptAfter uint64
+	subs      map[*Subscription]bool
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		historySize: DefaultHistory,
+		bufferSize:  DefaultBuffer,
+		now:         time.Now,
+		keptAfter:   uint64(time.Now().UnixNano()),
+		subs:        make(map[*Subscription]bool),
+	}
+}
+
+// WithHistory sets how many recent events is kept for resuming
+func (hub *Hub) WithHistory(n int) *Hub {
+	hub.historySize = n
+	return hub
+}
+
+// WithBuffer sets how many events a subscriber can fall
+// behind before it is dropped
+func (hub *Hub) WithBuffer(n int) *Hub {
+	hub.bufferSize = n
+	return hub
+}
+
+// Publish publishes the article to the matching subscribers
+// without waiting for them
+func (hub *Hub) Publish(typ string, art models.NewsArticle) Event {
+	hub.mx.Lock()
+	defer hub.mx.Unlock()
+
+	id := max(uint64(hub.now().UnixNano()), hub.lastID+1)
+	hub.lastID = id
+
+	event := Event{ID: id, Type: typ, Article: art}
+	hub.history = append(hub.history, event)
+	if n := len(hub.history) - hub.historySize; n > 0 {
+		hub.keptAfter = hub.history[n-1].ID
+		hub.history = slices.Delete(hub.history, 0, n)
+	}
+
+	for sub := range hub.subs {
+		if !sub.filter.Match(art) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			hub.drop(sub, ErrSlowConsumer)
+		}
+	}
+
+	return event
+}
+
+// Subscribe subscribes to the events matching the filter. When
+// lastEventID is not 0, the kept events after it is sent first,
+// see Subscription.Missed for the events no longer kept
+func (hub *Hub) Subscribe(filter Filter, lastEventID uint64) *Subscription {
+	hub.mx.Lock()
+	defer hub.mx.Unlock()
+
+	var missed []Event
+	if lastEventID != 0 {
+		for _, event := range hub.history {
+			if event.ID > lastEventID && filter.Match(event.Article) {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	sub := &Subscription{
+		hub:    hub,
+		filter: filter,
+		events: make(chan Event, hub.bufferSize+len(missed)),
+		missed: lastEventID != 0 && lastEventID < hub.keptAfter,
+	}
+
+	for _, event := range missed {
+		sub.events <- event
+	}
+
+	hub.subs[sub] = true
+
+	return sub
+}
+
+// Subscribers gets the number of subscribers
+func (hub *Hub) Subscribers() int {
+	hub.mx.Lock()
+	defer hub.mx.Unlock()
+
+	return len(hub.subs)
+}
+
+// drop closes the subscription, hub.mx must be locked
+func (hub *Hub) drop(sub *Subscription, err error) {
+	if sub.closed {
+		return
+	}
+
+	sub.closed = true
+	sub.err = err
+	close(sub.events)
+	delete(hub.subs, sub)
+}
@@ -0,0 +1,163 @@
+package pubsub
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+func newHub() *Hub {
+	hub := NewHub()
+	// the same time for every event, the ID still increases
+	now := time.Now()
+	hub.now = func() time.Time { return now }
+
+	return hub
+}
+
+func receive(t *testing.T, sub *Subscription) []string {
+	t.Helper()
+
+	var ids []string
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return ids
+			}
+
+			ids = append(ids, event.Article.ID)
+
+		default:
+			return ids
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	art := models.NewsArticle{
+		Source:   models.Detik,
+		Terms:    []string{"banjir", "rob", "demak"},
+		Entities: []models.ArticleEntity{{Key: "demak", Type: models.EntityLocation}},
+	}
+
+	tests := []struct {
+		filter Filter
+		match  bool
+	}{
+		{Filter{}, true},
+		{Filter{Sources: []string{models.Liputan6, models.Detik}}, true},
+		{Filter{Sources: []string{models.Liputan6}}, false},
+		{Filter{Terms: []string{"banjir", "rob"}}, true},
+		{Filter{Terms: []string{"banjir", "gempa"}}, false},
+		{Filter{Entities: []string{"jawa tengah", "demak"}}, true},
+		{Filter{Entities: []string{"jawa tengah"}}, false},
+		{Filter{Sources: []string{models.Detik}, Terms: []string{"banjir"}, Entities: []string{"semarang"}}, false},
+	}
+
+	for _, tt := range tests {
+		if tt.filter.Match(art) != tt.match {
+			t.Errorf("%+v: expecting match %v", tt.filter, tt.match)
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	hub := newHub()
+
+	all := hub.Subscribe(Filter{}, 0)
+	detik := hub.Subscribe(Filter{Sources: []string{models.Detik}}, 0)
+
+	first := hub.Publish(EventCreated, models.NewsArticle{ID: "a", Source: models.Detik})
+	second := hub.Publish(EventCreated, models.NewsArticle{ID: "b", Source: models.Liputan6})
+	if second.ID <= first.ID {
+		t.Errorf("expecting increasing IDs, got %d then %d", first.ID, second.ID)
+	}
+
+	if ids := receive(t, all); len(ids) != 2 {
+		t.Errorf("expecting a and b, got %v", ids)
+	}
+
+	if ids := receive(t, detik); len(ids) != 1 || ids[0] != "a" {
+		t.Errorf("expecting a, got %v", ids)
+	}
+
+	detik.Close()
+	detik.Close()
+	if hub.Subscribers() != 1 {
+		t.Errorf("expecting 1 subscriber, got %d", hub.Subscribers())
+	}
+}
+
+func TestResume(t *testing.T) {
+	hub := newHub().WithHistory(3)
+
+	var ids []uint64
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		ids = append(ids, hub.Publish(EventCreated, models.NewsArticle{ID: id}).ID)
+	}
+
+	// c is no longer kept, the kept events after it is sent
+	// and the subscriber knows c is missed
+	sub := hub.Subscribe(Filter{}, ids[0])
+	if got := receive(t, sub); len(got) != 3 || got[0] != "c" {
+		t.Errorf("expecting c d e, got %v", got)
+	}
+
+	if !sub.Missed() {
+		t.Error("expecting missed events")
+	}
+
+	// b is no longer kept, but nothing after it is dropped
+	sub = hub.Subscribe(Filter{}, ids[1])
+	if got := receive(t, sub); len(got) != 3 || got[0] != "c" {
+		t.Errorf("expecting c d e, got %v", got)
+	}
+
+	if sub.Missed() {
+		t.Error("expecting no missed event")
+	}
+
+	if got := receive(t, hub.Subscribe(Filter{}, ids[3])); len(got) != 1 || got[0] != "e" {
+		t.Errorf("expecting e, got %v", got)
+	}
+
+	// the events before a restart is not kept
+	if !NewHub().Subscribe(Filter{}, ids[4]).Missed() {
+		t.Error("expecting missed events after restart")
+	}
+
+	if got := receive(t, hub.Subscribe(Filter{}, 0)); len(got) != 0 {
+		t.Errorf("expecting no event, got %v", got)
+	}
+}
+
+func TestSlowConsumer(t *testing.T) {
+	hub := newHub().WithBuffer(2)
+	sub := hub.Subscribe(Filter{}, 0)
+
+	var last Event
+	for _, id := range []string{"a", "b", "c", "d"} {
+		last = hub.Publish(EventCreated, models.NewsArticle{ID: id})
+	}
+
+	if got := receive(t, sub); len(got) != 2 {
+		t.Errorf("expecting the buffered a b, got %v", got)
+	}
+
+	if !errors.Is(sub.Err(), ErrSlowConsumer) {
+		t.Errorf("expecting slow consumer, got %v", sub.Err())
+	}
+
+	if hub.Subscribers() != 0 {
+		t.Errorf("expecting the subscriber dropped")
+	}
+
+	// resuming from the last received gets the rest
+	resumed := hub.Subscribe(Filter{}, last.ID-2)
+	if got := receive(t, resumed); len(got) != 2 || got[0] != "c" {
+		t.Errorf("expecting c d, got %v", got)
+	}
+}
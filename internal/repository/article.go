@@ -0,0 +1,833 @@
+// Package repository provides MongoDB backed storage for the crawled articles
+package repository
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	articleCollection         = "news_articles"
+	articleVersionCollection  = "article_versions"
+	storyClusterCollection    = "story_clusters"
+	trendSnapshotCollection   = "trend_snapshots"
+	webhookEndpointCollection = "webhook_endpoints"
+	webhookDeliveryCollection = "webhook_deliveries"
+)
+
+type ArticleRepository struct {
+	coll       *mongo.Collection
+	versions   *mongo.Collection
+	stories    *mongo.Collection
+	trends     *mongo.Collection
+	webhooks   *mongo.Collection
+	deliveries *mongo.Collection
+}
+
+func NewArticleRepository(db *mongo.Database) *ArticleRepository {
+	return &ArticleRepository{
+		coll:       db.Collection(articleCollection),
+		versions:   db.Collection(articleVersionCollection),
+		stories:    db.Collection(storyClusterCollection),
+		trends:     db.Collection(trendSnapshotCollection),
+		webhooks:   db.Collection(webhookEndpointCollection),
+		deliveries: db.Collection(webhookDeliveryCollection),
+	}
+}
+
+// EnsureIndexes creates the indexes of articles collection.
+// Creating an index that is already exists is a no-op, so
+// it is safe to call this on every startup
+func (repo *ArticleRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "link", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			// for finding the article by the URLs it is redirected from
+			Keys: bson.D{{Key: "aliases", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "source", Value: 1}, {Key: "published_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "channel", Value: 1}, {Key: "published_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "tags", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "keywords", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "categories", Value: 1}},
+		},
+		{
+			// for auditing the content cleanup
+			Keys: bson.D{{Key: "removed_contents.rule", Value: 1}},
+		},
+		{
+			// for finding the articles to re-crawl
+			Keys: bson.D{{Key: "published_at", Value: -1}, {Key: "crawled_at", Value: 1}},
+		},
+		{
+			// for finding the near-duplicate candidates
+			Keys: bson.D{{Key: "lsh_bands", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "cluster_id", Value: 1}, {Key: "published_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "story_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "terms", Value: 1}},
+		},
+		{
+			// for finding the articles mentioning an entity
+			Keys: bson.D{{Key: "entities.key", Value: 1}, {Key: "published_at", Value: -1}},
+		},
+		{
+			// for listing the articles page by page
+			Keys: bson.D{{Key: "published_at", Value: -1}, {Key: "_id", Value: -1}},
+		},
+		{
+			// for streaming the new and changed articles
+			Keys: bson.D{{Key: "changed_at", Value: 1}},
+		},
+		{
+			// for the removed articles and its report
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "removal.observed_at", Value: -1}},
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.versions.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "article_id", Value: 1}, {Key: "version", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "detected_at", Value: -1}},
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.stories.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "last_seen_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "first_seen_at", Value: -1}, {Key: "source_count", Value: -1}},
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.trends.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "source", Value: 1}, {Key: "channel", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.deliveries.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "endpoint_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "created_at", Value: -1}},
+		},
+	})
+
+	return err
+}
+
+// IsAlreadyExist reports whether the article is stored, link should
+// be normalized with urlnorm. The aliases is matched as well
+func (repo *ArticleRepository) IsAlreadyExist(ctx context.Context, link string) (bool, error) {
+	err := repo.coll.FindOne(ctx, linkFilter(link), options.FindOne().SetProjection(bson.M{"_id": 1})).Err()
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// StoreArticle stores the article, replacing the article
+// with the same ID if it is already exists. If the article
+// has no ID, the ID is generated from the article link,
+// which is the canonical URL
+func (repo *ArticleRepository) StoreArticle(ctx context.Context, article models.NewsArticle) error {
+	if article.ID == "" {
+		article.ID = ArticleID(article.Link)
+	}
+
+	_, err := repo.coll.ReplaceOne(ctx, bson.M{"_id": article.ID}, article, options.Replace().SetUpsert(true))
+
+	return err
+}
+
+// ArticleID generates article ID from its link
+func ArticleID(link string) string {
+	sum := sha1.Sum([]byte(link))
+	return hex.EncodeToString(sum[:])
+}
+
+// FindArticleByLink finds the article by its link or aliases, ok
+// is false when the article is not found
+func (repo *ArticleRepository) FindArticleByLink(ctx context.Context, link string) (models.NewsArticle, bool, error) {
+	var art models.NewsArticle
+	err := repo.coll.FindOne(ctx, linkFilter(link)).Decode(&art)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return art, false, nil
+		}
+
+		return art, false, err
+	}
+
+	return art, true, nil
+}
+
+// FindArticleByID finds the article by its ID, ok is false
+// when the article is not found
+func (repo *ArticleRepository) FindArticleByID(ctx context.Context, id string) (models.NewsArticle, bool, error) {
+	var art models.NewsArticle
+	err := repo.coll.FindOne(ctx, bson.M{"_id": id}, options.FindOne().SetProjection(internalFields)).Decode(&art)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return art, false, nil
+		}
+
+		return art, false, err
+	}
+
+	return art, true, nil
+}
+
+// internalFields is the fields not returned to
+// the API consumers
+var internalFields = bson.M{"minhash": 0, "lsh_bands": 0, "terms": 0}
+
+// ListArticles finds the articles matching the filter, the latest
+// published first, then by ID descending. When afterID is not empty
+// the list starts after the article published at afterPublishedAt
+// with the ID, the last article of the previous page. The contents
+// is not returned
+func (repo *ArticleRepository) ListArticles(ctx context.Context, filter models.ArticleFilter, afterPublishedAt time.Time, afterID string, limit int) ([]models.NewsArticle, error) {
+	query := articleFilter(filter)
+	if afterID != "" {
+		query = bson.M{"$and": bson.A{query, bson.M{"$or": bson.A{
+			bson.M{"published_at": bson.M{"$lt": afterPublishedAt}},
+			bson.M{"published_at": afterPublishedAt, "_id": bson.M{"$lt": afterID}},
+		}}}}
+	}
+
+	projection := bson.M{"contents": 0, "related_articles": 0, "removed_contents": 0}
+	for field := range internalFields {
+		projection[field] = 0
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "published_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetProjection(projection)
+
+	cur, err := repo.coll.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var arts []models.NewsArticle
+	if err := cur.All(ctx, &arts); err != nil {
+		return nil, err
+	}
+
+	return arts, nil
+}
+
+// ArticlesChangedSince finds the articles matching the filter that
+// is created or changed since since, the earliest changed first. An
+// unchanged re-crawl is not a change
+func (repo *ArticleRepository) ArticlesChangedSince(ctx context.Context, filter models.ArticleFilter, since time.Time, limit int) ([]models.NewsArticle, error) {
+	query := articleFilter(filter)
+	query["changed_at"] = bson.M{"$gte": since}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "changed_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(internalFields)
+
+	cur, err := repo.coll.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var arts []models.NewsArticle
+	if err := cur.All(ctx, &arts); err != nil {
+		return nil, err
+	}
+
+	return arts, nil
+}
+
+func articleFilter(filter models.ArticleFilter) bson.M {
+	query := bson.M{"status": bson.M{"$ne": models.ArticleStatusRemoved}}
+	if filter.Source != "" {
+		query["source"] = filter.Source
+	}
+
+	if filter.Channel != "" {
+		query["channel"] = filter.Channel
+	}
+
+	published := bson.M{}
+	if !filter.Since.IsZero() {
+		published["$gte"] = filter.Since
+	}
+
+	if !filter.Until.IsZero() {
+		published["$lt"] = filter.Until
+	}
+
+	if len(published) != 0 {
+		query["published_at"] = published
+	}
+
+	if len(filter.Terms) != 0 {
+		query["terms"] = bson.M{"$all": filter.Terms}
+	}
+
+	return query
+}
+
+func linkFilter(link string) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"link": link},
+		bson.M{"aliases": link},
+	}}
+}
+
+// ArticlesForRecrawl finds the articles published since publishedSince
+// that is last crawled before crawledBefore, the least recently crawled
+// first. Only the fields needed to re-crawl is returned
+func (repo *ArticleRepository) ArticlesForRecrawl(ctx context.Context, publishedSince, crawledBefore time.Time, limit int) ([]models.NewsArticle, error) {
+	filter := bson.M{
+		"published_at": bson.M{"$gte": publishedSince},
+		"crawled_at":   bson.M{"$lt": crawledBefore},
+		"status":       bson.M{"$ne": models.ArticleStatusRemoved},
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "crawled_at", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"_id": 1, "link": 1, "source": 1, "channel": 1, "published_at": 1})
+
+	cur, err := repo.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var arts []models.NewsArticle
+	if err := cur.All(ctx, &arts); err != nil {
+		return nil, err
+	}
+
+	return arts, nil
+}
+
+// StoreVersion stores a previous version of an article
+func (repo *ArticleRepository) StoreVersion(ctx context.Context, version models.ArticleVersion) error {
+	_, err := repo.versions.ReplaceOne(ctx, bson.M{"_id": version.ID}, version, options.Replace().SetUpsert(true))
+	return err
+}
+
+// ArticleVersions finds the previous versions of an article,
+// the latest version first
+func (repo *ArticleRepository) ArticleVersions(ctx context.Context, articleID string) ([]models.ArticleVersion, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+	cur, err := repo.versions.Find(ctx, bson.M{"article_id": articleID}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []models.ArticleVersion
+	if err := cur.All(ctx, &versions); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// ArticlesForCheck finds the articles published since publishedSince
+// that is not removed and is last checked for removal before
+// checkedBefore, the least recently checked first
+func (repo *ArticleRepository) ArticlesForCheck(ctx context.Context, publishedSince, checkedBefore time.Time, limit int) ([]models.NewsArticle, error) {
+	filter := bson.M{
+		"published_at": bson.M{"$gte": publishedSince},
+		"status":       bson.M{"$ne": models.ArticleStatusRemoved},
+		"$or": bson.A{
+			bson.M{"checked_at": bson.M{"$lt": checkedBefore}},
+			bson.M{"checked_at": bson.M{"$exists": false}},
+		},
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "checked_at", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"_id": 1, "link": 1, "source": 1, "published_at": 1})
+
+	cur, err := repo.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var arts []models.NewsArticle
+	if err := cur.All(ctx, &arts); err != nil {
+		return nil, err
+	}
+
+	return arts, nil
+}
+
+// MarkRemoved marks the article as removed
+func (repo *ArticleRepository) MarkRemoved(ctx context.Context, id string, removal models.ArticleRemoval) error {
+	update := bson.M{"$set": bson.M{
+		"status":     models.ArticleStatusRemoved,
+		"removal":    removal,
+		"checked_at": removal.ObservedAt,
+	}}
+
+	_, err := repo.coll.UpdateByID(ctx, id, update)
+
+	return err
+}
+
+// MarkChecked sets when the article is last checked for removal
+func (repo *ArticleRepository) MarkChecked(ctx context.Context, id string, at time.Time) error {
+	_, err := repo.coll.UpdateByID(ctx, id, bson.M{"$set": bson.M{"checked_at": at}})
+	return err
+}
+
+// RemovedArticles finds the articles observed as removed since since,
+// the latest removal first. All sources is included when source is
+// empty. The contents is not returned
+func (repo *ArticleRepository) RemovedArticles(ctx context.Context, source string, since time.Time, limit int) ([]models.NewsArticle, error) {
+	filter := bson.M{
+		"status":              models.ArticleStatusRemoved,
+		"removal.observed_at": bson.M{"$gte": since},
+	}
+
+	if source != "" {
+		filter["source"] = source
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "removal.observed_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"contents": 0, "removed_contents": 0, "related_articles": 0})
+
+	cur, err := repo.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var arts []models.NewsArticle
+	if err := cur.All(ctx, &arts); err != nil {
+		return nil, err
+	}
+
+	return arts, nil
+}
+
+// RemovedReport counts the articles observed as removed since
+// since per source, the source with most removals first
+func (repo *ArticleRepository) RemovedReport(ctx context.Context, since time.Time) ([]models.RemovedReport, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"status":              models.ArticleStatusRemoved,
+			"removal.observed_at": bson.M{"$gte": since},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":              "$source",
+			"count":            bson.M{"$sum": 1},
+			"last_observed_at": bson.M{"$max": "$removal.observed_at"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+
+	cur, err := repo.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []models.RemovedReport
+	if err := cur.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+// DuplicateCandidates finds the articles published between
+// publishedSince and publishedUntil sharing any of the LSH bands.
+// Only the fields needed to compare is returned
+func (repo *ArticleRepository) DuplicateCandidates(ctx context.Context, bands []string, publishedSince, publishedUntil time.Time, limit int) ([]models.NewsArticle, error) {
+	filter := bson.M{
+		"lsh_bands":    bson.M{"$in": bands},
+		"published_at": bson.M{"$gte": publishedSince, "$lte": publishedUntil},
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "published_at", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"_id": 1, "link": 1, "source": 1, "published_at": 1, "minhash": 1, "duplicate_of": 1, "cluster_id": 1})
+
+	cur, err := repo.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var arts []models.NewsArticle
+	if err := cur.All(ctx, &arts); err != nil {
+		return nil, err
+	}
+
+	return arts, nil
+}
+
+// ClusterArticles finds the near-duplicate articles of a cluster,
+// the earliest first. The contents is not returned
+func (repo *ArticleRepository) ClusterArticles(ctx context.Context, clusterID string) ([]models.NewsArticle, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "published_at", Value: 1}}).
+		SetProjection(bson.M{"contents": 0, "removed_contents": 0, "related_articles": 0, "minhash": 0, "lsh_bands": 0})
+
+	cur, err := repo.coll.Find(ctx, bson.M{"cluster_id": clusterID}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var arts []models.NewsArticle
+	if err := cur.All(ctx, &arts); err != nil {
+		return nil, err
+	}
+
+	return arts, nil
+}
+
+// LinkDuplicate links the article to its earlier near-duplicate
+func (repo *ArticleRepository) LinkDuplicate(ctx context.Context, id, duplicateOf, clusterID string, similarity float64) error {
+	update := bson.M{"$set": bson.M{
+		"duplicate_of": duplicateOf,
+		"cluster_id":   clusterID,
+		"similarity":   similarity,
+	}}
+
+	_, err := repo.coll.UpdateByID(ctx, id, update)
+
+	return err
+}
+
+// EachArticle calls fn with every stored article that is not
+// removed, the earliest published first, until fn returns an error
+func (repo *ArticleRepository) EachArticle(ctx context.Context, fn func(models.NewsArticle) error) error {
+	return repo.eachArticle(ctx, bson.M{"status": bson.M{"$ne": models.ArticleStatusRemoved}}, fn)
+}
+
+// EachArticleSince is like EachArticle, but only the articles
+// published since since
+func (repo *ArticleRepository) EachArticleSince(ctx context.Context, since time.Time, fn func(models.NewsArticle) error) error {
+	filter := bson.M{
+		"status":       bson.M{"$ne": models.ArticleStatusRemoved},
+		"published_at": bson.M{"$gte": since},
+	}
+
+	return repo.eachArticle(ctx, filter, fn)
+}
+
+func (repo *ArticleRepository) eachArticle(ctx context.Context, filter bson.M, fn func(models.NewsArticle) error) error {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "published_at", Value: 1}}).
+		SetProjection(bson.M{"removed_contents": 0, "related_articles": 0, "minhash": 0, "lsh_bands": 0})
+
+	cur, err := repo.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var art models.NewsArticle
+		if err := cur.Decode(&art); err != nil {
+			return err
+		}
+
+		if err := fn(art); err != nil {
+			return err
+		}
+	}
+
+	return cur.Err()
+}
+
+// ArticlesByEntity finds the articles published since since that
+// mention the entity, the latest first. name is matched by its key,
+// see models.EntityKey, and any type is matched when typ is empty.
+// The removed articles is skipped, and the contents is not returned
+func (repo *ArticleRepository) ArticlesByEntity(ctx context.Context, name, typ string, since time.Time, limit int) ([]models.NewsArticle, error) {
+	match := bson.M{"key": models.EntityKey(name)}
+	if typ != "" {
+		match["type"] = typ
+	}
+
+	filter := bson.M{
+		"status":       bson.M{"$ne": models.ArticleStatusRemoved},
+		"entities":     bson.M{"$elemMatch": match},
+		"published_at": bson.M{"$gte": since},
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "published_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"contents": 0, "removed_contents": 0, "related_articles": 0, "minhash": 0, "lsh_bands": 0, "terms": 0})
+
+	cur, err := repo.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var arts []models.NewsArticle
+	if err := cur.All(ctx, &arts); err != nil {
+		return nil, err
+	}
+
+	return arts, nil
+}
+
+// ActiveStoryClusters finds the story clusters last seen since since
+func (repo *ArticleRepository) ActiveStoryClusters(ctx context.Context, since time.Time) ([]models.StoryCluster, error) {
+	cur, err := repo.stories.Find(ctx, bson.M{"last_seen_at": bson.M{"$gte": since}})
+	if err != nil {
+		return nil, err
+	}
+
+	var clusters []models.StoryCluster
+	if err := cur.All(ctx, &clusters); err != nil {
+		return nil, err
+	}
+
+	return clusters, nil
+}
+
+// StoreStoryCluster stores the story cluster, replacing the
+// cluster with the same ID
+func (repo *ArticleRepository) StoreStoryCluster(ctx context.Context, cluster models.StoryCluster) error {
+	_, err := repo.stories.ReplaceOne(ctx, bson.M{"_id": cluster.ID}, cluster, options.Replace().SetUpsert(true))
+	return err
+}
+
+// StoryClusters finds the story clusters first seen since since and
+// covered by at least minSources sources, the latest first
+func (repo *ArticleRepository) StoryClusters(ctx context.Context, since time.Time, minSources, limit int) ([]models.StoryCluster, error) {
+	filter := bson.M{
+		"first_seen_at": bson.M{"$gte": since},
+		"source_count":  bson.M{"$gte": minSources},
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "first_seen_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"terms": 0})
+
+	cur, err := repo.stories.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var clusters []models.StoryCluster
+	if err := cur.All(ctx, &clusters); err != nil {
+		return nil, err
+	}
+
+	return clusters, nil
+}
+
+// StoryCluster finds the story cluster by its ID, ok is false
+// when the cluster is not found
+func (repo *ArticleRepository) StoryCluster(ctx context.Context, id string) (models.StoryCluster, bool, error) {
+	var cluster models.StoryCluster
+	// the terms is kept, the clusterer stores the cluster back
+	err := repo.stories.FindOne(ctx, bson.M{"_id": id}).Decode(&cluster)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return cluster, false, nil
+		}
+
+		return cluster, false, err
+	}
+
+	return cluster, true, nil
+}
+
+// StoreTrendSnapshot stores the trend snapshot, replacing the
+// snapshot with the same ID
+func (repo *ArticleRepository) StoreTrendSnapshot(ctx context.Context, snap models.TrendSnapshot) error {
+	_, err := repo.trends.ReplaceOne(ctx, bson.M{"_id": snap.ID}, snap, options.Replace().SetUpsert(true))
+	return err
+}
+
+// LatestTrendSnapshot finds the latest trend snapshot of the source
+// and channel, empty is all of it. ok is false when there is none
+func (repo *ArticleRepository) LatestTrendSnapshot(ctx context.Context, source, channel string) (models.TrendSnapshot, bool, error) {
+	var snap models.TrendSnapshot
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	err := repo.trends.FindOne(ctx, bson.M{"source": source, "channel": channel}, opts).Decode(&snap)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return snap, false, nil
+		}
+
+		return snap, false, err
+	}
+
+	return snap, true, nil
+}
+
+// StoreWebhookEndpoint stores the webhook endpoint, replacing
+// the endpoint with the same ID
+func (repo *ArticleRepository) StoreWebhookEndpoint(ctx context.Context, ep models.WebhookEndpoint) error {
+	_, err := repo.webhooks.ReplaceOne(ctx, bson.M{"_id": ep.ID}, ep, options.Replace().SetUpsert(true))
+	return err
+}
+
+// DeleteWebhookEndpoint deletes the webhook endpoint, ok is false
+// when the endpoint is not found. The deliveries is kept as the log
+func (repo *ArticleRepository) DeleteWebhookEndpoint(ctx context.Context, id string) (bool, error) {
+	res, err := repo.webhooks.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+
+	return res.DeletedCount != 0, nil
+}
+
+// WebhookEndpoints finds all the webhook endpoints, the oldest first
+func (repo *ArticleRepository) WebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := repo.webhooks.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []models.WebhookEndpoint
+	if err := cursor.All(ctx, &endpoints); err != nil {
+		return nil, err
+	}
+
+	return endpoints, nil
+}
+
+// StoreWebhookDelivery stores the webhook delivery, replacing
+// the delivery with the same ID
+func (repo *ArticleRepository) StoreWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	_, err := repo.deliveries.ReplaceOne(ctx, bson.M{"_id": delivery.ID}, delivery, options.Replace().SetUpsert(true))
+	return err
+}
+
+// DueWebhookDeliveries finds the pending webhook deliveries with
+// next attempt at or before now, the earliest first
+func (repo *ArticleRepository) DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	filter := bson.M{
+		"status":          models.WebhookPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := repo.deliveries.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// WebhookDeliveries finds the webhook deliveries of the endpoint
+// with the status, empty is all of it, the latest first
+func (repo *ArticleRepository) WebhookDeliveries(ctx context.Context, endpointID, status string, limit int) ([]models.WebhookDelivery, error) {
+	filter := bson.M{}
+	if endpointID != "" {
+		filter["endpoint_id"] = endpointID
+	}
+
+	if status != "" {
+		filter["status"] = status
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetProjection(bson.M{"payload": 0}).
+		SetLimit(int64(limit))
+
+	cursor, err := repo.deliveries.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// WebhookDelivery finds the webhook delivery by its ID, ok is
+// false when the delivery is not found
+func (repo *ArticleRepository) WebhookDelivery(ctx context.Context, id string) (models.WebhookDelivery, bool, error) {
+	var delivery models.WebhookDelivery
+	err := repo.deliveries.FindOne(ctx, bson.M{"_id": id}).Decode(&delivery)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return delivery, false, nil
+		}
+
+		return delivery, false, err
+	}
+
+	return delivery, true, nil
+}
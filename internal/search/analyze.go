@@ -0,0 +1,84 @@
+package search
+
+import (
+	"strings"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idnlp"
+)
+
+// positionGap is the position gap between the headline and each
+// paragraph, so a phrase does not match across them
+const positionGap = 100
+
+// analyze gets the terms of the text with its positions, starting
+// from pos, and the next position. The words is lowercased and
+// stemmed, the stopwords is dropped but still takes a position
+func analyze(text string, pos int, fn func(term string, pos int)) int {
+	st := idnlp.DefaultStemmer()
+	for _, tok := range idnlp.Tokenize(text) {
+		if tok.Kind == idnlp.TokenAbbrev {
+			pos++
+			continue
+		}
+
+		if tok.Kind == idnlp.TokenNumber {
+			fn(tok.Text, pos)
+			pos++
+			continue
+		}
+
+		for _, w := range tok.Words() {
+			if w != "" && !idnlp.IsStopword(w) {
+				fn(st.Stem(w), pos)
+			}
+
+			pos++
+		}
+	}
+
+	return pos
+}
+
+// analyzeText gets the terms of the text, in order
+func analyzeText(text string) []string {
+	var terms []string
+	analyze(text, 0, func(term string, pos int) {
+		terms = append(terms, term)
+	})
+
+	return terms
+}
+
+// newDocument analyzes the headline and paragraphs of the article
+func newDocument(art models.NewsArticle) *Document {
+	doc := &Document{
+		ID:          art.ID,
+		Link:        art.Link,
+		Headline:    art.Headline,
+		Source:      art.Source,
+		Channel:     art.Channel,
+		PublishedAt: art.PublishedAt,
+		Terms:       make(map[string][]int),
+		Headlines:   make(map[string]int),
+	}
+
+	for _, tag := range art.Tags {
+		doc.Tags = append(doc.Tags, strings.ToLower(strings.TrimSpace(tag)))
+	}
+
+	pos := analyze(art.Headline, 0, func(term string, pos int) {
+		doc.Terms[term] = append(doc.Terms[term], pos)
+		doc.Headlines[term]++
+		doc.Length++
+	})
+
+	for _, p := range art.Paragraphs() {
+		pos = analyze(p, pos+positionGap, func(term string, pos int) {
+			doc.Terms[term] = append(doc.Terms[term], pos)
+			doc.Length++
+		})
+	}
+
+	return doc
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package search
+
+import "os"
+
+// lockFile does nothing where flock is not available
+func lockFile(f *os.File) error {
+	return nil
+}
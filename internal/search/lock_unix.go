@@ -0,0 +1,21 @@
+//go:build unix
+
+package search
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile takes the exclusive lock of the file without waiting,
+// the lock is released when the file is closed, or the process
+// exits
+func lockFile(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return ErrLocked
+	}
+
+	return err
+}
@@ -0,0 +1,145 @@
+package search
+
+import (
+	"strings"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+const (
+	DefaultLimit = 10
+	MaxLimit     = 1000
+)
+
+// Query is a search query. Text is the words to find, a "quoted
+// phrase" must be found as is and a -word must not be found. The
+// articles having any of the words is found, ranked by BM25, unless
+// there is a phrase, then only those having all the phrases
+type Query struct {
+	Text string
+	// Sources filters the article sources, any source when empty
+	Sources []models.ArticleSource
+	// Since and Until filters the article published time,
+	// Until is exclusive
+	Since time.Time
+	Until time.Time
+	// Tags filters the articles having any of the tags
+	Tags   []string
+	Limit  int
+	Offset int
+}
+
+type phraseTerm struct {
+	term string
+	// offset is the position of the term in the phrase
+	offset int
+}
+
+type parsedQuery struct {
+	terms    []string
+	phrases  [][]phraseTerm
+	excluded []string
+}
+
+// parseQuery parses the query text into the terms, the
+// phrases and the excluded terms
+func parseQuery(text string) parsedQuery {
+	var q parsedQuery
+	for text != "" {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			break
+		}
+
+		switch {
+		case text[0] == '"':
+			phrase, rest, _ := strings.Cut(text[1:], `"`)
+			text = rest
+
+			var terms []phraseTerm
+			analyze(phrase, 0, func(term string, pos int) {
+				terms = append(terms, phraseTerm{term, pos})
+			})
+
+			if len(terms) == 1 {
+				q.terms = append(q.terms, terms[0].term)
+			} else if len(terms) > 1 {
+				q.phrases = append(q.phrases, terms)
+			}
+
+		default:
+			word, rest, _ := strings.Cut(text, " ")
+			text = rest
+
+			if excluded, ok := strings.CutPrefix(word, "-"); ok {
+				q.excluded = append(q.excluded, analyzeText(excluded)...)
+				continue
+			}
+
+			q.terms = append(q.terms, analyzeText(word)...)
+		}
+	}
+
+	for _, phrase := range q.phrases {
+		for _, pt := range phrase {
+			q.terms = append(q.terms, pt.term)
+		}
+	}
+
+	q.terms = unique(q.terms)
+
+	return q
+}
+
+func unique(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	var uniq []string
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			uniq = append(uniq, s)
+		}
+	}
+
+	return uniq
+}
+
+// match reports whether the document passes the query filters
+func (query Query) match(doc *Document) bool {
+	if len(query.Sources) > 0 {
+		found := false
+		for _, source := range query.Sources {
+			if strings.EqualFold(string(source), string(doc.Source)) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if !query.Since.IsZero() && doc.PublishedAt.Before(query.Since) {
+		return false
+	}
+
+	if !query.Until.IsZero() && !doc.PublishedAt.Before(query.Until) {
+		return false
+	}
+
+	if len(query.Tags) > 0 {
+		for _, tag := range query.Tags {
+			for _, docTag := range doc.Tags {
+				if strings.EqualFold(tag, docTag) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,284 @@
+// Package search is an embedded full-text search index of the
+// articles. The headline and paragraphs of the articles is analyzed
+// with the Indonesian tokenizer and stemmer of idnlp into an inverted
+// index, ranked with BM25, and the phrase queries is matched with the
+// term positions. The index lives in memory, and when opened from a
+// directory, every update is appended to a log and compacted into a
+// snapshot from time to time, see Open.
+package search
+
+import (
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+// BM25 parameters
+const (
+	k1 = 1.2
+	b  = 0.75
+	// headlineBoost is how many times a headline term counts
+	headlineBoost = 3
+)
+
+// Document is an indexed article
+type Document struct {
+	ID          string
+	Link        string
+	Headline    string
+	Source      models.ArticleSource
+	Channel     string
+	PublishedAt time.Time
+	Tags        []string
+	// Terms is the positions of each term in the headline
+	// and paragraphs
+	Terms map[string][]int
+	// Headlines is the number of each term in the headline
+	Headlines map[string]int
+	// Length is the number of terms
+	Length int
+}
+
+// Hit is a found article
+type Hit struct {
+	ID          string               `json:"id"`
+	Link        string               `json:"link"`
+	Headline    string               `json:"headline"`
+	Source      models.ArticleSource `json:"source"`
+	Channel     string               `json:"channel"`
+	PublishedAt time.Time            `json:"published_at"`
+	Score       float64              `json:"score"`
+}
+
+type Result struct {
+	// Total is the number of articles found, Hits is only
+	// those within the query limit and offset
+	Total int   `json:"total"`
+	Hits  []Hit `json:"hits"`
+}
+
+// Index is the inverted index of the articles, safe for
+// concurrent use
+type Index struct {
+	mx   sync.RWMutex
+	docs map[string]*Document
+	// postings is the IDs of the documents having each term
+	postings    map[string]map[string]struct{}
+	totalLength int
+
+	// dir is where the index is persisted, empty when the
+	// index is in memory only
+	dir    string
+	log    *os.File
+	logged int
+	// compacting receives the error of the compaction running
+	// in the background, nil when there is none
+	compacting chan error
+	// lock is the lock file of dir, see Open
+	lock *os.File
+}
+
+// NewIndex creates an index in memory only
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[string]*Document),
+		postings: make(map[string]map[string]struct{}),
+	}
+}
+
+// Len gets the number of indexed articles
+func (idx *Index) Len() int {
+	idx.mx.RLock()
+	defer idx.mx.RUnlock()
+
+	return len(idx.docs)
+}
+
+// Add indexes the article, replacing the previous one with the
+// same ID. The article must have its ID set
+func (idx *Index) Add(art models.NewsArticle) error {
+	doc := newDocument(art)
+
+	idx.mx.Lock()
+	defer idx.mx.Unlock()
+
+	idx.add(doc)
+
+	return idx.append(record{Doc: doc})
+}
+
+// Delete removes the article from the index
+func (idx *Index) Delete(id string) error {
+	idx.mx.Lock()
+	defer idx.mx.Unlock()
+
+	if _, ok := idx.docs[id]; !ok {
+		return nil
+	}
+
+	idx.delete(id)
+
+	return idx.append(record{Deleted: id})
+}
+
+func (idx *Index) add(doc *Document) {
+	idx.delete(doc.ID)
+
+	idx.docs[doc.ID] = doc
+	idx.totalLength += doc.Length
+	for term := range doc.Terms {
+		ids, ok := idx.postings[term]
+		if !ok {
+			ids = make(map[string]struct{})
+			idx.postings[term] = ids
+		}
+
+		ids[doc.ID] = struct{}{}
+	}
+}
+
+func (idx *Index) delete(id string) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+
+	for term := range doc.Terms {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+
+	idx.totalLength -= doc.Length
+	delete(idx.docs, id)
+}
+
+// Search finds the articles matching the query, the most
+// relevant first
+func (idx *Index) Search(query Query) Result {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	limit = min(limit, MaxLimit)
+
+	q := parseQuery(query.Text)
+
+	idx.mx.RLock()
+	defer idx.mx.RUnlock()
+
+	var hits []Hit
+	for id, score := range idx.score(q) {
+		doc := idx.docs[id]
+		if !query.match(doc) || !idx.matchPhrases(doc, q) || idx.excluded(doc, q) {
+			continue
+		}
+
+		hits = append(hits, Hit{
+			ID:          doc.ID,
+			Link:        doc.Link,
+			Headline:    doc.Headline,
+			Source:      doc.Source,
+			Channel:     doc.Channel,
+			PublishedAt: doc.PublishedAt,
+			Score:       math.Round(score*1000) / 1000,
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+
+		if !hits[i].PublishedAt.Equal(hits[j].PublishedAt) {
+			return hits[i].PublishedAt.After(hits[j].PublishedAt)
+		}
+
+		return hits[i].ID < hits[j].ID
+	})
+
+	res := Result{Total: len(hits), Hits: []Hit{}}
+	if query.Offset < len(hits) {
+		hits = hits[max(query.Offset, 0):]
+		res.Hits = hits[:min(limit, len(hits))]
+	}
+
+	return res
+}
+
+// score gets the BM25 score of the documents having any of the terms
+func (idx *Index) score(q parsedQuery) map[string]float64 {
+	scores := make(map[string]float64)
+	n := float64(len(idx.docs))
+	if n == 0 {
+		return scores
+	}
+
+	avgLength := float64(idx.totalLength) / n
+	for _, term := range q.terms {
+		ids := idx.postings[term]
+		df := float64(len(ids))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id := range ids {
+			doc := idx.docs[id]
+			tf := float64(len(doc.Terms[term]) + (headlineBoost-1)*doc.Headlines[term])
+			norm := 1 - b + b*float64(doc.Length)/avgLength
+			scores[id] += idf * tf * (k1 + 1) / (tf + k1*norm)
+		}
+	}
+
+	return scores
+}
+
+// matchPhrases reports whether the document has all the phrases
+func (idx *Index) matchPhrases(doc *Document, q parsedQuery) bool {
+	for _, phrase := range q.phrases {
+		if !hasPhrase(doc, phrase) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hasPhrase(doc *Document, phrase []phraseTerm) bool {
+	first := phrase[0]
+	for _, pos := range doc.Terms[first.term] {
+		start := pos - first.offset
+		found := true
+		for _, pt := range phrase[1:] {
+			if !hasPosition(doc.Terms[pt.term], start+pt.offset) {
+				found = false
+				break
+			}
+		}
+
+		if found {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasPosition finds pos in the sorted positions
+func hasPosition(positions []int, pos int) bool {
+	i := sort.SearchInts(positions, pos)
+	return i < len(positions) && positions[i] == pos
+}
+
+func (idx *Index) excluded(doc *Document, q parsedQuery) bool {
+	for _, term := range q.excluded {
+		if _, ok := doc.Terms[term]; ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,298 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+var base = time.Date(2024, 8, 12, 8, 0, 0, 0, time.UTC)
+
+// paragraphs gets the paragraph blocks of the texts
+func paragraphs(texts ...string) []models.ArticleContent {
+	var contents []models.ArticleContent
+	for _, text := range texts {
+		data, _ := json.Marshal(models.ArticleTextContent{Text: text})
+		contents = append(contents, models.ArticleContent{Type: models.ContentParagraphText, Data: data})
+	}
+
+	return contents
+}
+
+func articles() []models.NewsArticle {
+	return []models.NewsArticle{
+		{
+			ID: "tol", Source: models.Liputan6, PublishedAt: base, Tags: []string{"Jokowi", "Tol"},
+			Headline: "Jokowi Resmikan Tol Semarang-Demak",
+			Contents: paragraphs(
+				"Presiden Joko Widodo meresmikan jalan tol Semarang-Demak yang juga menjadi tanggul laut.",
+				"Tanggul laut itu diharapkan mengatasi banjir rob di pesisir Demak."),
+		},
+		{
+			ID: "banjir", Source: models.Detik, PublishedAt: base.Add(time.Hour), Tags: []string{"Banjir"},
+			Headline: "Banjir Rob Rendam Ratusan Rumah di Demak",
+			Contents: paragraphs(
+				"Banjir rob kembali merendam ratusan rumah warga di Kabupaten Demak, Jawa Tengah.",
+				"Warga berharap tanggul segera dibangun untuk mencegah banjir."),
+		},
+		{
+			ID: "timnas", Source: models.Detik, PublishedAt: base.Add(2 * time.Hour), Tags: []string{"Timnas"},
+			Headline: "Timnas Indonesia Kalahkan Vietnam",
+			Contents: paragraphs("Timnas Indonesia menang 2-0 atas Vietnam di Stadion GBK, Jakarta."),
+		},
+	}
+}
+
+func newTestIndex(t *testing.T) *Index {
+	idx := NewIndex()
+	for _, art := range articles() {
+		if err := idx.Add(art); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return idx
+}
+
+func ids(res Result) []string {
+	var list []string
+	for _, hit := range res.Hits {
+		list = append(list, hit.ID)
+	}
+
+	return list
+}
+
+func TestSearch(t *testing.T) {
+	idx := newTestIndex(t)
+
+	tests := []struct {
+		name  string
+		query Query
+		ids   []string
+	}{
+		{"stemmed", Query{Text: "peresmian tol"}, []string{"tol"}},
+		{"ranked", Query{Text: "banjir demak"}, []string{"banjir", "tol"}},
+		{"phrase", Query{Text: `"tanggul laut"`}, []string{"tol"}},
+		{"phrase with stopword", Query{Text: `"menang 2-0 atas vietnam"`}, []string{"timnas"}},
+		{"phrase not adjacent", Query{Text: `"laut tanggul"`}, nil},
+		{"excluded", Query{Text: "banjir -tol"}, []string{"banjir"}},
+		{"source", Query{Text: "demak", Sources: []models.ArticleSource{"detik.com"}}, []string{"banjir"}},
+		{"date range", Query{Text: "demak timnas", Since: base.Add(30 * time.Minute), Until: base.Add(90 * time.Minute)}, []string{"banjir"}},
+		{"tags", Query{Text: "demak", Tags: []string{"jokowi"}}, []string{"tol"}},
+		{"limit", Query{Text: "banjir demak", Limit: 1}, []string{"banjir"}},
+		{"offset", Query{Text: "banjir demak", Offset: 1}, []string{"tol"}},
+		{"not found", Query{Text: "pemilu"}, nil},
+	}
+
+	for _, tt := range tests {
+		res := idx.Search(tt.query)
+		got := ids(res)
+		if len(got) != len(tt.ids) {
+			t.Errorf("%s: expecting %v, got %v", tt.name, tt.ids, got)
+			continue
+		}
+
+		for i := range got {
+			if got[i] != tt.ids[i] {
+				t.Errorf("%s: expecting %v, got %v", tt.name, tt.ids, got)
+				break
+			}
+		}
+	}
+}
+
+func TestAddReplaces(t *testing.T) {
+	idx := newTestIndex(t)
+
+	edited := articles()[2]
+	edited.Headline = "Timnas Indonesia Tahan Imbang Vietnam"
+	if err := idx.Add(edited); err != nil {
+		t.Fatal(err)
+	}
+
+	if idx.Len() != 3 {
+		t.Errorf("expecting 3 articles, got %d", idx.Len())
+	}
+
+	if res := idx.Search(Query{Text: "imbang"}); res.Total != 1 {
+		t.Errorf("expecting edited headline to be found, got %v", ids(res))
+	}
+
+	if err := idx.Delete("timnas"); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := idx.Search(Query{Text: "vietnam"}); res.Total != 0 {
+		t.Errorf("expecting deleted article not to be found, got %v", ids(res))
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+
+	idx, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	arts := articles()
+	for _, art := range arts[:2] {
+		idx.Add(art)
+	}
+
+	if err := idx.Compact(); err != nil {
+		t.Fatal(err)
+	}
+
+	// updates after the snapshot is in the log
+	idx.Add(arts[2])
+	idx.Delete("banjir")
+
+	// a partially written record is dropped
+	f, _ := os.OpenFile(filepath.Join(dir, logFile), os.O_APPEND|os.O_WRONLY, 0)
+	f.Write([]byte{0, 0, 1})
+	f.Close()
+
+	// the index is locked until it is closed
+	if _, err := Open(dir); err != ErrLocked {
+		t.Fatalf("expecting ErrLocked, got %v", err)
+	}
+
+	// like on crash, the log and the lock is closed
+	// without compacting
+	idx.log.Close()
+	idx.lock.Close()
+
+	idx, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if idx.Len() != 2 {
+		t.Errorf("expecting 2 articles, got %d", idx.Len())
+	}
+
+	if res := idx.Search(Query{Text: `"tanggul laut" vietnam`}); res.Total != 1 || res.Hits[0].ID != "tol" {
+		t.Errorf("unexpected result %v", ids(res))
+	}
+
+	if res := idx.Search(Query{Text: "vietnam"}); res.Total != 1 {
+		t.Errorf("expecting logged article to be found, got %v", ids(res))
+	}
+
+	if err := idx.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Stat(filepath.Join(dir, logFile)); err != nil || info.Size() != 0 {
+		t.Errorf("expecting log to be compacted, got %v", info)
+	}
+}
+
+func TestCompactInBackground(t *testing.T) {
+	dir := t.TempDir()
+
+	idx, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	art := articles()[0]
+	for i := 0; i <= compactEvery; i++ {
+		art.ID = fmt.Sprint(i)
+		if err := idx.Add(art); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := <-idx.compacting; err != nil {
+		t.Fatal(err)
+	}
+
+	idx.compacting = nil
+
+	// the update after the rotation is in the new log
+	if _, err := os.Stat(filepath.Join(dir, compactingLogFile)); !os.IsNotExist(err) {
+		t.Errorf("expecting compacting log to be removed, got %v", err)
+	}
+
+	if idx.logged != 1 {
+		t.Errorf("expecting 1 logged update, got %d", idx.logged)
+	}
+
+	// like on crash while compacting, the compacting log
+	// is left and replayed before the log
+	idx.log.Close()
+	idx.lock.Close()
+	os.Rename(filepath.Join(dir, logFile), filepath.Join(dir, compactingLogFile))
+
+	idx, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if idx.Len() != compactEvery+1 {
+		t.Errorf("expecting %d articles, got %d", compactEvery+1, idx.Len())
+	}
+
+	if err := idx.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, compactingLogFile)); !os.IsNotExist(err) {
+		t.Errorf("expecting compacting log to be removed, got %v", err)
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	dir := t.TempDir()
+
+	idx, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer idx.Close()
+
+	arts := articles()
+	idx.Add(arts[0])
+	idx.Compact()
+	idx.Add(arts[1])
+
+	logInfo, _ := os.Stat(filepath.Join(dir, logFile))
+	snapInfo, _ := os.Stat(filepath.Join(dir, snapshotFile))
+
+	// the index is read while it is opened
+	ro, err := OpenReadOnly(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ro.Len() != 2 {
+		t.Errorf("expecting 2 articles, got %d", ro.Len())
+	}
+
+	ro.Add(arts[2])
+	if err := ro.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the snapshot and the log is not changed
+	if info, err := os.Stat(filepath.Join(dir, logFile)); err != nil || info.Size() != logInfo.Size() {
+		t.Errorf("expecting log size %d, got %v", logInfo.Size(), info)
+	}
+
+	if info, err := os.Stat(filepath.Join(dir, snapshotFile)); err != nil || !info.ModTime().Equal(snapInfo.ModTime()) {
+		t.Errorf("expecting snapshot unchanged, got %v", info)
+	}
+
+	if idx.Len() != 2 {
+		t.Errorf("expecting 2 articles in the opened index, got %d", idx.Len())
+	}
+}
@@ -0,0 +1,440 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	snapshotFile = "index.gob"
+	logFile      = "index.log"
+	// compactingLogFile is the log being compacted in the
+	// background, replayed before the log until it is done
+	compactingLogFile = "index.log.compacting"
+	lockFileName      = "index.lock"
+	// compactEvery is the number of logged updates after
+	// which the index is compacted
+	compactEvery = 1000
+)
+
+// ErrLocked is returned by Open when the index is opened by
+// another process
+var ErrLocked = errors.New("search index is opened by another process")
+
+// record is an update of the index in the log, either an
+// added document or a deleted document ID
+type record struct {
+	Doc     *Document
+	Deleted string
+}
+
+// Open opens the index persisted in dir, creating it when there is
+// none. The index is loaded from the snapshot, then the updates in
+// the log is replayed. Every Add and Delete is appended to the log,
+// and the log is compacted into a new snapshot in the background
+// after a while, or with Compact. A record partially written, like on crash, is
+// dropped. The index is locked until it is closed, only a process
+// at a time can open it, otherwise ErrLocked is returned
+func Open(dir string) (*Index, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	lock, err := os.OpenFile(filepath.Join(dir, lockFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	idx := NewIndex()
+	idx.dir = dir
+	idx.lock = lock
+
+	if err := idx.loadSnapshot(); err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	if err := idx.replayCompactingLog(); err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	if err := idx.replayLog(); err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	return idx, nil
+}
+
+// OpenReadOnly loads the index persisted in dir, like Open, without
+// locking, compacting or writing to it, so it can be read while
+// another process opens it. The index is a copy at the time it is
+// loaded, Add and Delete is in memory only
+func OpenReadOnly(dir string) (*Index, error) {
+	idx := NewIndex()
+	idx.dir = dir
+
+	if err := idx.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	if err := idx.replayCompactingLog(); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(dir, logFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err == nil {
+		defer f.Close()
+		idx.readLog(f)
+	}
+
+	idx.dir = ""
+	idx.logged = 0
+
+	return idx, nil
+}
+
+// Compact writes the snapshot of the index and clears the log
+func (idx *Index) Compact() error {
+	idx.mx.Lock()
+	defer idx.mx.Unlock()
+
+	return idx.compact()
+}
+
+// Close compacts the index, closes the log and releases the lock
+func (idx *Index) Close() error {
+	idx.mx.Lock()
+	defer idx.mx.Unlock()
+
+	if idx.dir == "" {
+		return nil
+	}
+
+	err := idx.compact()
+	if idx.log != nil {
+		err = errors.Join(err, idx.log.Close())
+		idx.log = nil
+	}
+
+	if idx.lock != nil {
+		err = errors.Join(err, idx.lock.Close())
+		idx.lock = nil
+	}
+
+	// the index is not persisted anymore, closing it
+	// again does nothing
+	idx.dir = ""
+
+	return err
+}
+
+func (idx *Index) loadSnapshot() error {
+	f, err := os.Open(filepath.Join(idx.dir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	var docs []*Document
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(&docs); err != nil {
+		return fmt.Errorf("error loading search index snapshot: %w", err)
+	}
+
+	for _, doc := range docs {
+		idx.add(doc)
+	}
+
+	return nil
+}
+
+// replayCompactingLog applies the updates of the log left by the
+// background compaction, like on crash, when there is one
+func (idx *Index) replayCompactingLog() error {
+	f, err := os.Open(filepath.Join(idx.dir, compactingLogFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+	idx.readLog(f)
+
+	return nil
+}
+
+// replayLog applies the logged updates and opens the log for
+// appending, truncated after the last complete record
+func (idx *Index) replayLog() error {
+	f, err := os.OpenFile(filepath.Join(idx.dir, logFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+
+	offset := idx.readLog(f)
+	if err := f.Truncate(offset); err != nil {
+		f.Close()
+		return err
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+
+	idx.log = f
+
+	return nil
+}
+
+// readLog applies the complete records of the log and gets
+// the offset after the last one
+func (idx *Index) readLog(f io.Reader) int64 {
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		rec, n, err := readRecord(r)
+		if err != nil {
+			return offset
+		}
+
+		idx.apply(rec)
+		idx.logged++
+		offset += n
+	}
+}
+
+func (idx *Index) apply(rec record) {
+	if rec.Doc != nil {
+		idx.add(rec.Doc)
+		return
+	}
+
+	idx.delete(rec.Deleted)
+}
+
+// append appends the update to the log, when the index is persisted.
+// The log is reopened when the previous rotation failed to
+func (idx *Index) append(rec record) error {
+	if idx.dir == "" {
+		return nil
+	}
+
+	if idx.log == nil {
+		if err := idx.openLog(); err != nil {
+			return err
+		}
+	}
+
+	if err := writeRecord(idx.log, rec); err != nil {
+		return err
+	}
+
+	idx.logged++
+	if idx.logged >= compactEvery {
+		return idx.compactInBackground()
+	}
+
+	return nil
+}
+
+func (idx *Index) openLog() error {
+	f, err := os.OpenFile(filepath.Join(idx.dir, logFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	idx.log = f
+
+	return nil
+}
+
+// compactInBackground renames the log to the compacting log, starts
+// a new log and writes the snapshot of the documents in the
+// background, so the updates is not blocked while it is written.
+// The documents is not modified once indexed, only replaced, so
+// the snapshot can share them. The compacting log is removed after
+// the snapshot is written, when it is left by a crash or a failed
+// compaction, the index is compacted in the foreground instead
+func (idx *Index) compactInBackground() error {
+	if idx.compacting != nil {
+		select {
+		case <-idx.compacting:
+			idx.compacting = nil
+		default:
+			// the log keeps growing until the previous
+			// compaction is done
+			return nil
+		}
+	}
+
+	compactingLog := filepath.Join(idx.dir, compactingLogFile)
+	if _, err := os.Stat(compactingLog); err == nil {
+		return idx.compact()
+	}
+
+	if err := idx.log.Close(); err != nil {
+		return err
+	}
+
+	idx.log = nil
+	if err := os.Rename(filepath.Join(idx.dir, logFile), compactingLog); err != nil {
+		return errors.Join(err, idx.openLog())
+	}
+
+	if err := idx.openLog(); err != nil {
+		return err
+	}
+
+	idx.logged = 0
+
+	dir := idx.dir
+	docs := idx.documents()
+	done := make(chan error, 1)
+	idx.compacting = done
+	go func() {
+		err := writeSnapshot(dir, docs)
+		if err == nil {
+			err = os.Remove(compactingLog)
+		}
+
+		done <- err
+	}()
+
+	return nil
+}
+
+// compact waits for the background compaction, writes the snapshot
+// and clears the logs. The snapshot supersedes the one of the
+// background compaction, so its error is dropped
+func (idx *Index) compact() error {
+	if idx.dir == "" {
+		return nil
+	}
+
+	if idx.compacting != nil {
+		<-idx.compacting
+		idx.compacting = nil
+	}
+
+	if err := writeSnapshot(idx.dir, idx.documents()); err != nil {
+		return err
+	}
+
+	err := os.Remove(filepath.Join(idx.dir, compactingLogFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if idx.log != nil {
+		if err := idx.log.Truncate(0); err != nil {
+			return err
+		}
+
+		if _, err := idx.log.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	idx.logged = 0
+
+	return nil
+}
+
+func (idx *Index) documents() []*Document {
+	docs := make([]*Document, 0, len(idx.docs))
+	for _, doc := range idx.docs {
+		docs = append(docs, doc)
+	}
+
+	return docs
+}
+
+// writeSnapshot writes the snapshot to a temporary file and renames
+// it, so a crash while writing keeps the previous snapshot and log
+func writeSnapshot(dir string, docs []*Document) error {
+	tmp, err := os.CreateTemp(dir, snapshotFile+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if err := gob.NewEncoder(w).Encode(docs); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(dir, snapshotFile))
+}
+
+// writeRecord writes the record, gob encoded on its own and
+// prefixed with its length
+func writeRecord(w io.Writer, rec record) error {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, 4))
+	if err := gob.NewEncoder(&buf).Encode(rec); err != nil {
+		return err
+	}
+
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data, uint32(len(data)-4))
+	_, err := w.Write(data)
+
+	return err
+}
+
+// readRecord reads a record and gets its size in bytes
+func readRecord(r io.Reader) (record, int64, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return record{}, 0, err
+	}
+
+	data := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return record{}, 0, err
+	}
+
+	var rec record
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&rec); err != nil {
+		return record{}, 0, err
+	}
+
+	return rec, int64(len(data)) + 4, nil
+}
@@ -0,0 +1,312 @@
+// Package sentiment scores the sentiment of the articles with an
+// Indonesian polarity lexicon. Each sentence of the headline and
+// paragraphs is scored by the polarity of its words, flipped by the
+// negations before it, like tidak baik, and multiplied by the
+// intensifiers around it, like sangat baik or baik sekali. The article
+// score is the mean of its sentences with any polar word, and the
+// entity score is the mean of the sentences mentioning the entity.
+package sentiment
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idnlp"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed data/lexicon.yaml
+var defaultLexicon []byte
+
+const (
+	// negationWindow is how many words after a negation
+	// is negated
+	negationWindow = 3
+	// normalizeAlpha normalizes the sum of the polarities to
+	// -1 to 1, the higher it is the more words is needed to
+	// get a strong score
+	normalizeAlpha = 15
+	// neutralBand is the maximum absolute score of
+	// the neutral sentiment
+	neutralBand = 0.05
+)
+
+// Lexicon is the polarity of the words
+type Lexicon struct {
+	// Version is stored along the scores
+	Version      string             `yaml:"version"`
+	Negations    []string           `yaml:"negations"`
+	Intensifiers map[string]float64 `yaml:"intensifiers"`
+	// Words is the polarity of the words, from -3 to 3
+	Words map[string]float64 `yaml:"words"`
+}
+
+// ParseLexicon parses the lexicon YAML
+func ParseLexicon(data []byte) (*Lexicon, error) {
+	var lex Lexicon
+	if err := yaml.Unmarshal(data, &lex); err != nil {
+		return nil, fmt.Errorf("error parsing lexicon: %w", err)
+	}
+
+	if lex.Version == "" {
+		return nil, fmt.Errorf("error parsing lexicon: version is required")
+	}
+
+	return &lex, nil
+}
+
+// LoadLexicon loads the lexicon YAML file
+func LoadLexicon(path string) (*Lexicon, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lex, err := ParseLexicon(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return lex, nil
+}
+
+// DefaultLexicon gets the embedded lexicon
+func DefaultLexicon() *Lexicon {
+	lex, err := ParseLexicon(defaultLexicon)
+	if err != nil {
+		panic(err)
+	}
+
+	return lex
+}
+
+type Scorer struct {
+	lex       *Lexicon
+	negations map[string]bool
+	stemmer   *idnlp.Stemmer
+}
+
+func NewScorer(lex *Lexicon) *Scorer {
+	scorer := &Scorer{
+		lex:       lex,
+		negations: make(map[string]bool),
+		stemmer:   idnlp.DefaultStemmer(),
+	}
+
+	for _, word := range lex.Negations {
+		scorer.negations[strings.ToLower(word)] = true
+	}
+
+	return scorer
+}
+
+// LexiconVersion gets the version of the lexicon used
+func (scorer *Scorer) LexiconVersion() string {
+	return scorer.lex.Version
+}
+
+// Score scores the sentences of the headline and paragraph blocks
+// of the article, the article and its entities
+func (scorer *Scorer) Score(art models.NewsArticle) models.ArticleSentiment {
+	res := models.ArticleSentiment{LexiconVersion: scorer.lex.Version, Sentences: []models.SentenceSentiment{}}
+
+	var sum float64
+	var polar int
+	add := func(block int, text string) {
+		for _, span := range sentences(text) {
+			score, ok := scorer.ScoreSentence(text[span.start:span.end])
+			sent := models.SentenceSentiment{
+				Block: block,
+				Start: span.start,
+				End:   span.end,
+				Score: round(score),
+				Label: Label(score),
+			}
+
+			res.Sentences = append(res.Sentences, sent)
+			switch sent.Label {
+			case models.SentimentPositive:
+				res.Positive++
+
+			case models.SentimentNegative:
+				res.Negative++
+
+			default:
+				res.Neutral++
+			}
+
+			if ok {
+				sum += score
+				polar++
+			}
+		}
+	}
+
+	add(-1, art.Headline)
+	for i, content := range art.Contents {
+		if content.Type != models.ContentParagraphText {
+			continue
+		}
+
+		if text, ok := content.TextContent(); ok && text.Text != "" {
+			add(i, text.Text)
+		}
+	}
+
+	if polar > 0 {
+		res.Score = round(sum / float64(polar))
+	}
+
+	res.Label = Label(res.Score)
+	res.Entities = scoreEntities(art.Entities, res.Sentences)
+
+	return res
+}
+
+// scoreEntities scores the entities by the sentences mentioning it
+func scoreEntities(entities []models.ArticleEntity, sents []models.SentenceSentiment) []models.EntitySentiment {
+	var list []models.EntitySentiment
+	for _, e := range entities {
+		seen := make(map[int]bool)
+		var sum float64
+		for _, m := range e.Mentions {
+			for i, sent := range sents {
+				if sent.Block == m.Block && m.Start >= sent.Start && m.Start < sent.End && !seen[i] {
+					seen[i] = true
+					sum += sent.Score
+				}
+			}
+		}
+
+		if len(seen) == 0 {
+			continue
+		}
+
+		score := round(sum / float64(len(seen)))
+		list = append(list, models.EntitySentiment{
+			Key:       e.Key,
+			Name:      e.Name,
+			Type:      e.Type,
+			Score:     score,
+			Label:     Label(score),
+			Sentences: len(seen),
+		})
+	}
+
+	return list
+}
+
+// ScoreSentence scores the sentence from -1 to 1, ok is false
+// when the sentence has no polar word
+func (scorer *Scorer) ScoreSentence(sentence string) (score float64, ok bool) {
+	tokens := idnlp.Tokenize(sentence)
+
+	var sum float64
+	// negated is the number of words left negated
+	negated := 0
+	for i, tok := range tokens {
+		// the negation stops at a clause boundary
+		if i > 0 && strings.ContainsAny(sentence[tokens[i-1].End:tok.Start], ",;:") {
+			negated = 0
+		}
+
+		word := strings.ToLower(tok.Text)
+		if scorer.negations[word] {
+			negated = negationWindow
+			continue
+		}
+
+		polarity, found := scorer.polarity(word)
+		if !found {
+			if negated > 0 {
+				negated--
+			}
+
+			continue
+		}
+
+		ok = true
+		polarity *= scorer.intensity(tokens, i, sentence)
+		if negated > 0 {
+			polarity = -polarity
+			negated = 0
+		}
+
+		sum += polarity
+	}
+
+	return sum / math.Sqrt(sum*sum+normalizeAlpha), ok
+}
+
+// polarity gets the polarity of the word, or its root
+func (scorer *Scorer) polarity(word string) (float64, bool) {
+	if p, ok := scorer.lex.Words[word]; ok {
+		return p, true
+	}
+
+	p, ok := scorer.lex.Words[scorer.stemmer.Stem(word)]
+
+	return p, ok
+}
+
+// intensity gets the multiplier of the intensifier right before,
+// or right after, the word i
+func (scorer *Scorer) intensity(tokens []idnlp.Token, i int, sentence string) float64 {
+	if i > 0 {
+		if v, ok := scorer.lex.Intensifiers[strings.ToLower(tokens[i-1].Text)]; ok {
+			return v
+		}
+	}
+
+	if i+1 < len(tokens) && strings.TrimSpace(sentence[tokens[i].End:tokens[i+1].Start]) == "" {
+		if v, ok := scorer.lex.Intensifiers[strings.ToLower(tokens[i+1].Text)]; ok {
+			return v
+		}
+	}
+
+	return 1
+}
+
+// Label gets the label of the score
+func Label(score float64) string {
+	switch {
+	case score > neutralBand:
+		return models.SentimentPositive
+
+	case score < -neutralBand:
+		return models.SentimentNegative
+	}
+
+	return models.SentimentNeutral
+}
+
+type span struct {
+	start int
+	end   int
+}
+
+// sentences gets the byte offsets of the sentences of the text
+func sentences(text string) []span {
+	var spans []span
+	pos := 0
+	for _, sent := range idnlp.SplitSentences(text) {
+		i := strings.Index(text[pos:], sent)
+		if i == -1 {
+			continue
+		}
+
+		start := pos + i
+		pos = start + len(sent)
+		spans = append(spans, span{start, pos})
+	}
+
+	return spans
+}
+
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
@@ -0,0 +1,102 @@
+package sentiment
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/entity"
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+func TestScoreSentence(t *testing.T) {
+	scorer := NewScorer(DefaultLexicon())
+
+	tests := []struct {
+		sentence string
+		label    string
+	}{
+		{"Pelayanan rumah sakit itu baik.", models.SentimentPositive},
+		{"Pelayanan rumah sakit itu tidak baik.", models.SentimentNegative},
+		{"Timnas meraih kemenangan gemilang.", models.SentimentPositive},
+		{"Dua orang tewas dalam kecelakaan itu.", models.SentimentNegative},
+		{"Korupsi bukan hal yang wajar, katanya.", models.SentimentNegative},
+		{"Rapat digelar di gedung DPR pada Senin.", models.SentimentNeutral},
+		{"Tidak ada korban, warga merasa aman.", models.SentimentPositive},
+	}
+
+	for _, tt := range tests {
+		score, _ := scorer.ScoreSentence(tt.sentence)
+		if label := Label(score); label != tt.label {
+			t.Errorf("%q: expecting %s, got %s (%.3f)", tt.sentence, tt.label, label, score)
+		}
+	}
+
+	plain, _ := scorer.ScoreSentence("Hasilnya baik.")
+	for _, sentence := range []string{"Hasilnya sangat baik.", "Hasilnya baik sekali."} {
+		if score, _ := scorer.ScoreSentence(sentence); score <= plain {
+			t.Errorf("%q: expecting intensified score above %.3f, got %.3f", sentence, plain, score)
+		}
+	}
+
+	if _, ok := scorer.ScoreSentence("Rapat digelar pada Senin."); ok {
+		t.Error("expecting no polar word")
+	}
+}
+
+func TestScore(t *testing.T) {
+	art := models.NewsArticle{Headline: "Timnas Menang, Pelatih Bangga"}
+	for _, p := range []string{
+		"Timnas Indonesia menang 2-0 atas Vietnam. Pelatih Shin Tae-yong mengaku bangga dengan prestasi itu.",
+		"Sementara itu, Budi Santoso mengatakan suporter Vietnam kecewa dan ricuh di luar stadion.",
+		"Pertandingan digelar di Stadion GBK.",
+	} {
+		data, _ := json.Marshal(models.ArticleTextContent{Text: p})
+		art.Contents = append(art.Contents, models.ArticleContent{Type: models.ContentParagraphText, Data: data})
+	}
+
+	art.Entities = entity.NewExtractor(entity.DefaultGazetteer()).Extract(art)
+
+	res := NewScorer(DefaultLexicon()).Score(art)
+	if res.LexiconVersion != "id-news-1" {
+		t.Errorf("unexpected lexicon version %s", res.LexiconVersion)
+	}
+
+	if len(res.Sentences) != 5 || res.Positive != 3 || res.Negative != 1 || res.Neutral != 1 {
+		t.Errorf("unexpected sentences %+v", res)
+	}
+
+	if res.Label != models.SentimentPositive {
+		t.Errorf("expecting positive article, got %s (%.3f)", res.Label, res.Score)
+	}
+
+	sent := res.Sentences[2]
+	text, _ := art.Contents[sent.Block].TextContent()
+	if text.Text[sent.Start:sent.End] != "Pelatih Shin Tae-yong mengaku bangga dengan prestasi itu." {
+		t.Errorf("unexpected sentence offsets %+v", sent)
+	}
+
+	labels := make(map[string]string)
+	for _, e := range res.Entities {
+		labels[e.Key] = e.Label
+	}
+
+	if labels["indonesia"] != models.SentimentPositive || labels["budi santoso"] != models.SentimentNegative {
+		t.Errorf("unexpected entity sentiments %+v", res.Entities)
+	}
+}
+
+func TestParseLexicon(t *testing.T) {
+	if _, err := ParseLexicon([]byte("words: {baik: 2}")); err == nil {
+		t.Error("expecting error without version")
+	}
+
+	lex, err := ParseLexicon([]byte("version: custom-1\nwords: {untung: 3}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := NewScorer(lex).Score(models.NewsArticle{Headline: "Penjualan Untung Besar"})
+	if res.LexiconVersion != "custom-1" || res.Label != models.SentimentPositive {
+		t.Errorf("unexpected sentiment %+v", res)
+	}
+}
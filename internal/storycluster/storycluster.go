@@ -0,0 +1,326 @@
+// Package storycluster groups the articles about the same event from
+// any source into story clusters. Each stored article is compared to
+// the clusters active in the time window by the cosine similarity of
+// the TF-IDF vectors of its headline and lead paragraphs, and is
+// added to the most similar cluster, or starts a new one. The
+// document frequency is counted over the active clusters, so the
+// terms shared by many stories, like the names in the news all week,
+// weighs less.
+package storycluster
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+const (
+	DefaultThreshold = 0.35
+	DefaultWindow    = 48 * time.Hour
+)
+
+type Repository interface {
+	// ActiveStoryClusters finds the clusters last seen since since
+	ActiveStoryClusters(ctx context.Context, since time.Time) ([]models.StoryCluster, error)
+	StoreStoryCluster(ctx context.Context, cluster models.StoryCluster) error
+	// StoryCluster finds the cluster by its ID, ok is false when
+	// the cluster is not found
+	StoryCluster(ctx context.Context, id string) (cluster models.StoryCluster, ok bool, err error)
+}
+
+type Clusterer struct {
+	repo      Repository
+	threshold float64
+	window    time.Duration
+	now       func() time.Time
+
+	mx       sync.Mutex
+	loaded   bool
+	clusters map[string]*models.StoryCluster
+	// members maps the article ID to its cluster ID
+	members map[string]string
+	// df is the number of active clusters having the term
+	df map[string]int
+}
+
+func NewClusterer(repo Repository) *Clusterer {
+	return &Clusterer{
+		repo:      repo,
+		threshold: DefaultThreshold,
+		window:    DefaultWindow,
+		now:       time.Now,
+		clusters:  make(map[string]*models.StoryCluster),
+		members:   make(map[string]string),
+		df:        make(map[string]int),
+	}
+}
+
+// WithThreshold sets the minimum similarity of an article
+// to a cluster to be added to it
+func (clr *Clusterer) WithThreshold(threshold float64) *Clusterer {
+	clr.threshold = threshold
+	return clr
+}
+
+// WithWindow sets how long a cluster is open for new articles
+// after its last article is published
+func (clr *Clusterer) WithWindow(window time.Duration) *Clusterer {
+	clr.window = window
+	return clr
+}
+
+// Assign adds the article to its story cluster and sets the article
+// StoryID. The article must have its ID set. An article that is
+// already a member, like when it is re-crawled, stays in its cluster,
+// even when the cluster is already closed. Article without any term
+// is not clustered
+func (clr *Clusterer) Assign(ctx context.Context, art *models.NewsArticle) (*models.StoryCluster, error) {
+	clr.mx.Lock()
+	defer clr.mx.Unlock()
+
+	if err := clr.load(ctx); err != nil {
+		return nil, err
+	}
+
+	publishedAt := art.PublishedAt
+	if publishedAt.IsZero() {
+		publishedAt = clr.now()
+	}
+
+	clr.evict()
+
+	if id, ok := clr.members[art.ID]; ok {
+		cluster := clr.clusters[id]
+		clr.updateMember(cluster, *art)
+		art.StoryID = cluster.ID
+
+		return cluster, clr.repo.StoreStoryCluster(ctx, *cluster)
+	}
+
+	// the cluster of a re-crawled article is evicted, it is updated
+	// in place instead of starting a new cluster with the article ID
+	if art.StoryID != "" {
+		cluster, ok, err := clr.repo.StoryCluster(ctx, art.StoryID)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok && isMember(cluster, art.ID) {
+			clr.updateMember(&cluster, *art)
+			return &cluster, clr.repo.StoreStoryCluster(ctx, cluster)
+		}
+	}
+
+	tf := articleTerms(*art)
+	if len(tf) == 0 {
+		return nil, nil
+	}
+
+	vec := clr.tfidf(tf)
+
+	var best *models.StoryCluster
+	var bestSim float64
+	for _, cluster := range clr.clusters {
+		if publishedAt.Before(cluster.FirstSeenAt.Add(-clr.window)) || publishedAt.After(cluster.LastSeenAt.Add(clr.window)) {
+			continue
+		}
+
+		sim := cosine(vec, clr.centroid(cluster))
+		if sim > bestSim || (sim == bestSim && best != nil && cluster.ID < best.ID) {
+			best = cluster
+			bestSim = sim
+		}
+	}
+
+	if best == nil || bestSim < clr.threshold {
+		best = &models.StoryCluster{ID: art.ID, Terms: make(map[string]float64)}
+		clr.clusters[best.ID] = best
+		bestSim = 1
+	}
+
+	clr.addMember(best, *art, publishedAt, tf, bestSim)
+	art.StoryID = best.ID
+
+	return best, clr.repo.StoreStoryCluster(ctx, *best)
+}
+
+// load loads the active clusters on the first use
+func (clr *Clusterer) load(ctx context.Context) error {
+	if clr.loaded {
+		return nil
+	}
+
+	clusters, err := clr.repo.ActiveStoryClusters(ctx, clr.now().Add(-clr.window))
+	if err != nil {
+		return err
+	}
+
+	for i := range clusters {
+		cluster := &clusters[i]
+		if cluster.Terms == nil {
+			cluster.Terms = make(map[string]float64)
+		}
+
+		clr.clusters[cluster.ID] = cluster
+		for _, m := range cluster.Members {
+			clr.members[m.ArticleID] = cluster.ID
+		}
+
+		for term := range cluster.Terms {
+			clr.df[term]++
+		}
+	}
+
+	clr.loaded = true
+
+	return nil
+}
+
+// evict drops the clusters that is closed, those is still stored
+func (clr *Clusterer) evict() {
+	closedBefore := clr.now().Add(-clr.window)
+	for id, cluster := range clr.clusters {
+		if !cluster.LastSeenAt.Before(closedBefore) {
+			continue
+		}
+
+		for _, m := range cluster.Members {
+			delete(clr.members, m.ArticleID)
+		}
+
+		clr.setTerms(cluster, nil)
+		delete(clr.clusters, id)
+	}
+}
+
+func (clr *Clusterer) addMember(cluster *models.StoryCluster, art models.NewsArticle, publishedAt time.Time, tf vector, sim float64) {
+	cluster.Members = append(cluster.Members, models.StoryMember{
+		ArticleID:   art.ID,
+		Link:        art.Link,
+		Source:      art.Source,
+		Headline:    art.Headline,
+		PublishedAt: publishedAt,
+		Similarity:  math.Round(sim*1000) / 1000,
+	})
+
+	clr.members[art.ID] = cluster.ID
+
+	terms := make(vector, len(cluster.Terms)+len(tf))
+	for term, v := range cluster.Terms {
+		terms[term] = v
+	}
+
+	for term, v := range tf {
+		terms[term] += v
+	}
+
+	clr.setTerms(cluster, terms.top(maxTerms))
+	clr.summarize(cluster)
+}
+
+func (clr *Clusterer) updateMember(cluster *models.StoryCluster, art models.NewsArticle) {
+	for i := range cluster.Members {
+		m := &cluster.Members[i]
+		if m.ArticleID != art.ID {
+			continue
+		}
+
+		m.Link = art.Link
+		m.Headline = art.Headline
+		if !art.PublishedAt.IsZero() {
+			m.PublishedAt = art.PublishedAt
+		}
+	}
+
+	clr.summarize(cluster)
+}
+
+func isMember(cluster models.StoryCluster, id string) bool {
+	for _, m := range cluster.Members {
+		if m.ArticleID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setTerms replaces the cluster terms and updates the
+// document frequency
+func (clr *Clusterer) setTerms(cluster *models.StoryCluster, terms vector) {
+	for term := range cluster.Terms {
+		if clr.df[term]--; clr.df[term] <= 0 {
+			delete(clr.df, term)
+		}
+	}
+
+	cluster.Terms = terms
+	for term := range terms {
+		clr.df[term]++
+	}
+}
+
+// summarize updates the first and last seen time, the source
+// spread and the representative headline of the cluster
+func (clr *Clusterer) summarize(cluster *models.StoryCluster) {
+	sort.SliceStable(cluster.Members, func(i, j int) bool {
+		return cluster.Members[i].PublishedAt.Before(cluster.Members[j].PublishedAt)
+	})
+
+	first := cluster.Members[0]
+	cluster.FirstSeenAt = first.PublishedAt
+	cluster.FirstSource = first.Source
+	cluster.LastSeenAt = cluster.Members[len(cluster.Members)-1].PublishedAt
+
+	var sources []models.StorySource
+	idx := make(map[models.ArticleSource]int)
+	for _, m := range cluster.Members {
+		i, ok := idx[m.Source]
+		if !ok {
+			idx[m.Source] = len(sources)
+			sources = append(sources, models.StorySource{Source: m.Source, FirstSeenAt: m.PublishedAt})
+			i = len(sources) - 1
+		}
+
+		sources[i].Count++
+	}
+
+	cluster.Sources = sources
+	cluster.SourceCount = len(sources)
+
+	// the representative is the member whose headline is the
+	// most similar to the cluster
+	centroid := clr.centroid(cluster)
+	bestSim := -1.0
+	for _, m := range cluster.Members {
+		sim := cosine(clr.tfidf(termFrequencies(m.Headline, nil)), centroid)
+		if sim > bestSim {
+			bestSim = sim
+			cluster.Headline = m.Headline
+			cluster.RepresentativeID = m.ArticleID
+		}
+	}
+
+	cluster.UpdatedAt = clr.now()
+}
+
+// centroid gets the TF-IDF vector of the cluster centroid
+func (clr *Clusterer) centroid(cluster *models.StoryCluster) vector {
+	return clr.tfidf(vector(cluster.Terms))
+}
+
+// tfidf weights the term frequencies with the inverse document
+// frequency and normalizes it
+func (clr *Clusterer) tfidf(tf vector) vector {
+	n := float64(len(clr.clusters))
+	vec := make(vector, len(tf))
+	for term, v := range tf {
+		vec[term] = v * (math.Log((n+1)/(float64(clr.df[term])+1)) + 1)
+	}
+
+	return vec.normalizeL2()
+}
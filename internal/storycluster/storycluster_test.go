@@ -0,0 +1,177 @@
+package storycluster
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+type memRepo struct {
+	clusters map[string]models.StoryCluster
+}
+
+func (repo *memRepo) ActiveStoryClusters(ctx context.Context, since time.Time) ([]models.StoryCluster, error) {
+	var list []models.StoryCluster
+	for _, cluster := range repo.clusters {
+		if !cluster.LastSeenAt.Before(since) {
+			list = append(list, cluster)
+		}
+	}
+
+	return list, nil
+}
+
+func (repo *memRepo) StoreStoryCluster(ctx context.Context, cluster models.StoryCluster) error {
+	if repo.clusters == nil {
+		repo.clusters = make(map[string]models.StoryCluster)
+	}
+
+	repo.clusters[cluster.ID] = cluster
+	return nil
+}
+
+func (repo *memRepo) StoryCluster(ctx context.Context, id string) (models.StoryCluster, bool, error) {
+	cluster, ok := repo.clusters[id]
+	return cluster, ok, nil
+}
+
+func article(id string, source models.ArticleSource, publishedAt time.Time, headline string, paragraphs ...string) models.NewsArticle {
+	art := models.NewsArticle{ID: id, Source: source, Headline: headline, PublishedAt: publishedAt}
+	for _, p := range paragraphs {
+		data, _ := json.Marshal(models.ArticleTextContent{Text: p})
+		art.Contents = append(art.Contents, models.ArticleContent{Type: models.ContentParagraphText, Data: data})
+	}
+
+	return art
+}
+
+var base = time.Date(2024, 8, 12, 8, 0, 0, 0, time.UTC)
+
+func stories() []models.NewsArticle {
+	return []models.NewsArticle{
+		article("a", models.Liputan6, base, "Jokowi Resmikan Tol Semarang-Demak Seksi 2",
+			"Presiden Joko Widodo meresmikan jalan tol Semarang-Demak seksi 2 di Kabupaten Demak, Jawa Tengah.",
+			"Tol sepanjang 16 kilometer itu juga berfungsi sebagai tanggul laut untuk mencegah banjir rob."),
+		article("b", models.Detik, base.Add(40*time.Minute), "Tol Semarang-Demak Diresmikan, Jadi Tanggul Laut Cegah Rob",
+			"Presiden Jokowi meresmikan tol Semarang-Demak yang sekaligus menjadi tanggul laut di pesisir Demak.",
+			"Tanggul laut tersebut diharapkan mengatasi banjir rob yang kerap melanda Semarang dan Demak."),
+		article("c", models.Detik, base.Add(30*time.Minute), "Timnas Indonesia Kalahkan Vietnam 2-0 di GBK",
+			"Timnas Indonesia menang 2-0 atas Vietnam dalam laga kualifikasi Piala Dunia di Stadion GBK.",
+			"Gol timnas dicetak pada babak kedua, pelatih memuji permainan para pemain."),
+		article("d", models.Liputan6, base.Add(2*time.Hour), "Kalahkan Vietnam, Timnas Indonesia Jaga Peluang ke Piala Dunia",
+			"Kemenangan 2-0 atas Vietnam di GBK membuat timnas Indonesia menjaga peluang lolos kualifikasi Piala Dunia."),
+	}
+}
+
+func TestAssign(t *testing.T) {
+	repo := new(memRepo)
+	clr := NewClusterer(repo)
+	clr.now = func() time.Time { return base.Add(3 * time.Hour) }
+
+	for _, art := range stories() {
+		if _, err := clr.Assign(context.Background(), &art); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(repo.clusters) != 2 {
+		t.Fatalf("expecting 2 stories, got %d", len(repo.clusters))
+	}
+
+	tol, ok := repo.clusters["a"]
+	if !ok {
+		t.Fatal("expecting story a")
+	}
+
+	if len(tol.Members) != 2 || tol.SourceCount != 2 {
+		t.Errorf("expecting 2 members from 2 sources, got %+v", tol.Members)
+	}
+
+	if tol.FirstSource != models.Liputan6 || !tol.FirstSeenAt.Equal(base) || !tol.LastSeenAt.Equal(base.Add(40*time.Minute)) {
+		t.Errorf("unexpected first source %s at %s", tol.FirstSource, tol.FirstSeenAt)
+	}
+
+	if tol.Headline == "" || tol.RepresentativeID == "" {
+		t.Error("expecting representative headline")
+	}
+
+	timnas := repo.clusters["c"]
+	if len(timnas.Members) != 2 || timnas.Sources[0].Source != models.Detik {
+		t.Errorf("unexpected timnas story %+v", timnas.Members)
+	}
+}
+
+func TestAssignRecrawl(t *testing.T) {
+	repo := new(memRepo)
+	clr := NewClusterer(repo)
+	clr.now = func() time.Time { return base.Add(3 * time.Hour) }
+
+	arts := stories()
+	for i := range arts {
+		clr.Assign(context.Background(), &arts[i])
+	}
+
+	// a new clusterer loads the active clusters from the repository
+	clr = NewClusterer(repo)
+	clr.now = func() time.Time { return base.Add(3 * time.Hour) }
+
+	edited := arts[1]
+	edited.Headline = "Jokowi Resmikan Tol Semarang-Demak"
+	cluster, err := clr.Assign(context.Background(), &edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if edited.StoryID != "a" || len(cluster.Members) != 2 {
+		t.Errorf("expecting re-crawled article to stay in its story, got %s", edited.StoryID)
+	}
+
+	// outside the window is a new story
+	late := article("e", models.Detik, base.Add(5*24*time.Hour), arts[0].Headline, arts[0].Paragraphs()...)
+	clr.now = func() time.Time { return late.PublishedAt }
+	if _, err := clr.Assign(context.Background(), &late); err != nil {
+		t.Fatal(err)
+	}
+
+	if late.StoryID != "e" {
+		t.Errorf("expecting new story, got %s", late.StoryID)
+	}
+}
+
+func TestAssignEvicted(t *testing.T) {
+	repo := new(memRepo)
+	clr := NewClusterer(repo)
+	clr.now = func() time.Time { return base.Add(3 * time.Hour) }
+
+	arts := stories()
+	for i := range arts {
+		clr.Assign(context.Background(), &arts[i])
+	}
+
+	// the story is closed and evicted, the re-crawled article
+	// is updated in its stored story
+	clr.now = func() time.Time { return base.Add(5 * 24 * time.Hour) }
+
+	edited := arts[1]
+	edited.Headline = "Jokowi Resmikan Tol Semarang-Demak"
+	cluster, err := clr.Assign(context.Background(), &edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if edited.StoryID != "a" || len(cluster.Members) != 2 {
+		t.Fatalf("expecting re-crawled article to stay in its story, got %s", edited.StoryID)
+	}
+
+	if _, ok := repo.clusters["b"]; ok {
+		t.Error("expecting no new story for the re-crawled article")
+	}
+
+	tol := repo.clusters["a"]
+	if len(tol.Members) != 2 || !tol.FirstSeenAt.Equal(base) || tol.Members[1].Headline != edited.Headline {
+		t.Errorf("unexpected stored story %+v", tol.Members)
+	}
+}
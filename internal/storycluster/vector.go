@@ -0,0 +1,150 @@
+package storycluster
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idnlp"
+	"github.com/tamboto2000/ivosight-crawler/pkg/minhash"
+)
+
+const (
+	headlineWeight = 3
+	// leadParagraphs is the number of paragraphs used, the lead
+	// of news article tells what the event is
+	leadParagraphs = 5
+	// maxTerms is the maximum terms kept of a cluster centroid
+	maxTerms = 300
+)
+
+// datelineWords is in the dateline or the "baca juga" of almost every
+// article, those tells nothing about the event but is not a stopword
+// in general, see pkg/idnlp.IsStopword
+var datelineWords = map[string]bool{
+	"senin": true, "selasa": true, "rabu": true, "kamis": true, "jumat": true, "sabtu": true,
+	"minggu": true, "wib": true, "com": true, "baca": true, "jakarta": true,
+}
+
+type vector map[string]float64
+
+// tokens gets the terms of text without the stopwords, numbers
+// and too short words
+func tokens(text string) []string {
+	var terms []string
+	for _, word := range minhash.Words(text) {
+		if len([]rune(word)) < 3 || idnlp.IsStopword(word) || datelineWords[word] || isNumber(word) {
+			continue
+		}
+
+		terms = append(terms, word)
+	}
+
+	return terms
+}
+
+func isNumber(word string) bool {
+	return strings.IndexFunc(word, func(r rune) bool { return !unicode.IsDigit(r) }) == -1
+}
+
+// termFrequencies gets the term frequencies of the headline and the
+// lead paragraphs, normalized to sum of 1. The headline terms is
+// weighted more
+func termFrequencies(headline string, paragraphs []string) vector {
+	tf := make(vector)
+	for _, term := range tokens(headline) {
+		tf[term] += headlineWeight
+	}
+
+	if len(paragraphs) > leadParagraphs {
+		paragraphs = paragraphs[:leadParagraphs]
+	}
+
+	for _, term := range tokens(strings.Join(paragraphs, "\n")) {
+		tf[term]++
+	}
+
+	return tf.normalizeSum()
+}
+
+func articleTerms(art models.NewsArticle) vector {
+	return termFrequencies(art.Headline, art.Paragraphs())
+}
+
+func (vec vector) normalizeSum() vector {
+	var sum float64
+	for _, v := range vec {
+		sum += v
+	}
+
+	if sum == 0 {
+		return vec
+	}
+
+	for term := range vec {
+		vec[term] /= sum
+	}
+
+	return vec
+}
+
+func (vec vector) normalizeL2() vector {
+	var sum float64
+	for _, v := range vec {
+		sum += v * v
+	}
+
+	if sum == 0 {
+		return vec
+	}
+
+	norm := math.Sqrt(sum)
+	for term := range vec {
+		vec[term] /= norm
+	}
+
+	return vec
+}
+
+// cosine is the cosine similarity of two L2 normalized vectors
+func cosine(a, b vector) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	var dot float64
+	for term, v := range a {
+		dot += v * b[term]
+	}
+
+	return dot
+}
+
+// top keeps the n terms with the highest weight
+func (vec vector) top(n int) vector {
+	if len(vec) <= n {
+		return vec
+	}
+
+	terms := make([]string, 0, len(vec))
+	for term := range vec {
+		terms = append(terms, term)
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		if vec[terms[i]] == vec[terms[j]] {
+			return terms[i] < terms[j]
+		}
+
+		return vec[terms[i]] > vec[terms[j]]
+	})
+
+	topped := make(vector, n)
+	for _, term := range terms[:n] {
+		topped[term] = vec[term]
+	}
+
+	return topped
+}
@@ -0,0 +1,301 @@
+// Package summary summarizes the articles by extracting the most
+// central sentences of the paragraph blocks with TextRank. The
+// sentences is the nodes of a graph, weighted by the terms they
+// share, and is ranked with PageRank. News is written with the most
+// important first, so the random jump of PageRank is biased toward
+// the early sentences, and the lead paragraph the most.
+package summary
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idnlp"
+)
+
+const (
+	DefaultSentences  = 3
+	DefaultLeadWeight = 2
+	// damping is the PageRank damping factor, the chance of
+	// following an edge instead of jumping
+	damping       = 0.85
+	maxIterations = 100
+	tolerance     = 1e-6
+	// minWords is the minimum words of a sentence to be
+	// a summary sentence
+	minWords = 4
+	// maxOverlap is the maximum share of terms of a sentence with
+	// a selected sentence, the more is redundant
+	maxOverlap = 0.6
+)
+
+var (
+	// readAlsoRgx is the "Baca juga:" links left in a paragraph,
+	// the sentence is cut before it
+	readAlsoRgx = regexp.MustCompile(`(?i)\b(baca|lihat|simak|cek) (juga|pula|selengkapnya|berita lainnya)\s*:`)
+	// datelineRgx is the dateline of the lead, like "Jakarta - "
+	// or "Jakarta, Liputan6.com - "
+	datelineRgx = regexp.MustCompile(`^\p{Lu}[\p{L}\d .,]{0,50}?\s[-–—]\s+`)
+)
+
+type Summarizer struct {
+	sentences  int
+	leadWeight float64
+}
+
+func NewSummarizer() *Summarizer {
+	return &Summarizer{
+		sentences:  DefaultSentences,
+		leadWeight: DefaultLeadWeight,
+	}
+}
+
+// WithSentences sets the number of sentences of the summary
+func (smr *Summarizer) WithSentences(n int) *Summarizer {
+	smr.sentences = n
+	return smr
+}
+
+// WithLeadWeight sets how much more likely the sentences of the
+// lead paragraph is jumped to, 1 is not more than the others
+func (smr *Summarizer) WithLeadWeight(weight float64) *Summarizer {
+	smr.leadWeight = weight
+	return smr
+}
+
+type sentence struct {
+	models.SummarySentence
+	text  string
+	terms map[string]bool
+	// lead is true for the sentences of the first paragraph
+	lead bool
+}
+
+// Summarize summarizes the paragraph blocks of the article, nil is
+// returned when the article has no sentence to summarize
+func (smr *Summarizer) Summarize(art models.NewsArticle) *models.ArticleSummary {
+	sents := articleSentences(art)
+	if len(sents) == 0 {
+		return nil
+	}
+
+	scores := smr.rank(sents)
+
+	order := make([]int, len(sents))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+
+	var selected []sentence
+	for _, i := range order {
+		if len(selected) == smr.sentences {
+			break
+		}
+
+		redundant := false
+		for _, sel := range selected {
+			if overlap(sents[i].terms, sel.terms) > maxOverlap {
+				redundant = true
+				break
+			}
+		}
+
+		if !redundant {
+			selected = append(selected, sents[i])
+		}
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Index < selected[j].Index
+	})
+
+	summary := &models.ArticleSummary{}
+	texts := make([]string, 0, len(selected))
+	for _, sent := range selected {
+		texts = append(texts, sent.text)
+		summary.Sentences = append(summary.Sentences, sent.SummarySentence)
+	}
+
+	summary.Text = strings.Join(texts, " ")
+
+	return summary
+}
+
+// rank scores the sentences with PageRank, the jump is biased
+// toward the early sentences and the lead paragraph
+func (smr *Summarizer) rank(sents []sentence) []float64 {
+	n := len(sents)
+
+	jump := make([]float64, n)
+	var total float64
+	for i, sent := range sents {
+		jump[i] = 1 / math.Sqrt(float64(i+1))
+		if sent.lead {
+			jump[i] *= smr.leadWeight
+		}
+
+		total += jump[i]
+	}
+
+	for i := range jump {
+		jump[i] /= total
+	}
+
+	weights := make([][]float64, n)
+	outs := make([]float64, n)
+	for i := range sents {
+		weights[i] = make([]float64, n)
+		for j := range sents {
+			if i != j {
+				weights[i][j] = similarity(sents[i].terms, sents[j].terms)
+				outs[i] += weights[i][j]
+			}
+		}
+	}
+
+	scores := append([]float64(nil), jump...)
+	next := make([]float64, n)
+	for iter := 0; iter < maxIterations; iter++ {
+		// the score of a sentence without edges is
+		// spread by the jump
+		var dangling float64
+		for j := range sents {
+			if outs[j] == 0 {
+				dangling += scores[j]
+			}
+		}
+
+		var delta float64
+		for i := range sents {
+			var sum float64
+			for j := range sents {
+				if outs[j] > 0 {
+					sum += weights[j][i] / outs[j] * scores[j]
+				}
+			}
+
+			next[i] = (1-damping)*jump[i] + damping*(sum+dangling*jump[i])
+			delta += math.Abs(next[i] - scores[i])
+		}
+
+		scores, next = next, scores
+		if delta < tolerance {
+			break
+		}
+	}
+
+	return scores
+}
+
+// similarity is the TextRank similarity, the shared terms
+// normalized by the length of the sentences
+func similarity(a, b map[string]bool) float64 {
+	common := 0
+	for term := range a {
+		if b[term] {
+			common++
+		}
+	}
+
+	if common == 0 {
+		return 0
+	}
+
+	return float64(common) / (math.Log(float64(len(a)+1)) + math.Log(float64(len(b)+1)))
+}
+
+// overlap is the share of the terms of a in b
+func overlap(a, b map[string]bool) float64 {
+	if len(a) == 0 {
+		return 0
+	}
+
+	common := 0
+	for term := range a {
+		if b[term] {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(a))
+}
+
+// articleSentences gets the sentences of the paragraph blocks,
+// without the "Baca juga" links, the dateline and the sentences
+// too short to be in the summary
+func articleSentences(art models.NewsArticle) []sentence {
+	var sents []sentence
+	index := 0
+	// the lead is the first paragraph with a summary sentence,
+	// not a "Baca juga" link
+	leadBlock := -1
+	for block, content := range art.Contents {
+		if content.Type != models.ContentParagraphText {
+			continue
+		}
+
+		text, ok := content.TextContent()
+		if !ok || strings.TrimSpace(text.Text) == "" {
+			continue
+		}
+
+		pos := 0
+		for _, s := range idnlp.SplitSentences(text.Text) {
+			i := strings.Index(text.Text[pos:], s)
+			if i == -1 {
+				continue
+			}
+
+			start := pos + i
+			end := start + len(s)
+			pos = end
+
+			n := index
+			index++
+
+			if loc := readAlsoRgx.FindStringIndex(s); loc != nil {
+				s = strings.TrimRight(s[:loc[0]], " -–—|")
+				end = start + len(s)
+			}
+
+			if loc := datelineRgx.FindStringIndex(s); loc != nil {
+				s = s[loc[1]:]
+				start += loc[1]
+			}
+
+			if len(idnlp.Words(s)) < minWords {
+				continue
+			}
+
+			if leadBlock == -1 {
+				leadBlock = block
+			}
+
+			terms := make(map[string]bool)
+			for _, term := range idnlp.Terms(s) {
+				terms[term] = true
+			}
+
+			sents = append(sents, sentence{
+				SummarySentence: models.SummarySentence{
+					Index: n,
+					Block: block,
+					Start: start,
+					End:   end,
+				},
+				text:  s,
+				terms: terms,
+				lead:  block == leadBlock,
+			})
+		}
+	}
+
+	return sents
+}
@@ -0,0 +1,70 @@
+package summary
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+func newArticle(paragraphs ...string) models.NewsArticle {
+	art := models.NewsArticle{Headline: "Banjir Rob Landa Pesisir Demak"}
+	for _, p := range paragraphs {
+		data, _ := json.Marshal(models.ArticleTextContent{Text: p})
+		art.Contents = append(art.Contents, models.ArticleContent{Type: models.ContentParagraphText, Data: data})
+	}
+
+	return art
+}
+
+func TestSummarize(t *testing.T) {
+	art := newArticle(
+		"Baca juga: Harga Cabai Naik Jelang Lebaran",
+		"Demak - Banjir rob merendam ratusan rumah warga di pesisir Kecamatan Sayung, Demak, Senin. Ketinggian air banjir rob mencapai 50 sentimeter.",
+		"Kepala BPBD Demak mengatakan banjir rob dipicu air laut pasang dan tanggul yang jebol. Baca juga: Tol Semarang-Demak Segera Dibuka",
+		"Warga berharap pemerintah segera memperbaiki tanggul yang jebol agar banjir rob tidak terulang.",
+		"Sementara itu, cuaca di Semarang cerah berawan sepanjang hari.",
+		"Pertandingan sepak bola antarkampung tetap digelar di lapangan desa pada sore hari.",
+	)
+
+	summary := NewSummarizer().Summarize(art)
+	if summary == nil {
+		t.Fatal("expecting summary")
+	}
+
+	if len(summary.Sentences) != DefaultSentences {
+		t.Fatalf("expecting %d sentences, got %+v", DefaultSentences, summary.Sentences)
+	}
+
+	if strings.Contains(strings.ToLower(summary.Text), "baca juga") || strings.Contains(summary.Text, "sepak bola") {
+		t.Errorf("unexpected summary %q", summary.Text)
+	}
+
+	if !strings.HasPrefix(summary.Text, "Banjir rob merendam ratusan rumah") {
+		t.Errorf("expecting the lead sentence without dateline first, got %q", summary.Text)
+	}
+
+	for i, sent := range summary.Sentences {
+		if i > 0 && sent.Index <= summary.Sentences[i-1].Index {
+			t.Errorf("expecting the article order, got %+v", summary.Sentences)
+		}
+
+		text, _ := art.Contents[sent.Block].TextContent()
+		if !strings.Contains(summary.Text, text.Text[sent.Start:sent.End]) {
+			t.Errorf("unexpected sentence offsets %+v", sent)
+		}
+	}
+}
+
+func TestSummarizeShort(t *testing.T) {
+	art := newArticle("Jakarta - Presiden meresmikan bendungan baru di Jawa Tengah.", "Baca juga:")
+	summary := NewSummarizer().WithSentences(2).Summarize(art)
+	if summary == nil || len(summary.Sentences) != 1 || summary.Text != "Presiden meresmikan bendungan baru di Jawa Tengah." {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+
+	if NewSummarizer().Summarize(newArticle("Baca juga: Harga Cabai Naik")) != nil {
+		t.Error("expecting no summary")
+	}
+}
@@ -0,0 +1,204 @@
+// Package tombstone detects the articles that is deleted or retracted
+// by the publisher. The stored article links is re-checked
+// periodically, and a link that is responded with 404 or 410, or is
+// redirected to the homepage, is marked as removed along with when
+// the removal is observed.
+package tombstone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/pkg/httpreq"
+	"github.com/tamboto2000/ivosight-crawler/pkg/httpstatus"
+	"github.com/tamboto2000/ivosight-crawler/pkg/urlnorm"
+)
+
+const maxRedirects = 10
+
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+type Repository interface {
+	MarkRemoved(ctx context.Context, id string, removal models.ArticleRemoval) error
+	MarkChecked(ctx context.Context, id string, at time.Time) error
+}
+
+// Result is the result of checking an article link, Removal
+// is nil when the article is still there
+type Result struct {
+	StatusCode int
+	Removal    *models.ArticleRemoval
+}
+
+type Checker struct {
+	cl   *http.Client
+	repo Repository
+	now  func() time.Time
+}
+
+// NewChecker creates a checker that requests with cl. The redirects
+// is followed by the checker itself, to see where it goes
+func NewChecker(cl *http.Client, repo Repository) *Checker {
+	if cl == nil {
+		cl = http.DefaultClient
+	}
+
+	nocl := *cl
+	nocl.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return &Checker{
+		cl:   &nocl,
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// Check requests the article link. The status other than 200, 404
+// and 410, like 403 and 5xx, is returned as error since it does not
+// tell whether the article is removed
+func (checker *Checker) Check(ctx context.Context, link string) (Result, error) {
+	orig, err := url.Parse(link)
+	if err != nil {
+		return Result{}, err
+	}
+
+	current := orig
+	for i := 0; i <= maxRedirects; i++ {
+		res, err := checker.request(ctx, current.String())
+		if err != nil {
+			return Result{}, err
+		}
+
+		switch {
+		case res.StatusCode == http.StatusOK:
+			return Result{StatusCode: res.StatusCode}, nil
+
+		case res.StatusCode == http.StatusNotFound:
+			return checker.removed(res.StatusCode, models.RemovalNotFound, ""), nil
+
+		case res.StatusCode == http.StatusGone:
+			return checker.removed(res.StatusCode, models.RemovalGone, ""), nil
+
+		case res.StatusCode >= 300 && res.StatusCode < 400:
+			loc, err := res.Location()
+			if err != nil {
+				return Result{}, fmt.Errorf("redirect without location: %w", err)
+			}
+
+			if IsHomepage(loc, orig) {
+				return checker.removed(res.StatusCode, models.RemovalRedirectHomepage, loc.String()), nil
+			}
+
+			current = loc
+
+		default:
+			return Result{}, &httpstatus.Error{StatusCode: res.StatusCode}
+		}
+	}
+
+	return Result{}, ErrTooManyRedirects
+}
+
+// CheckArticles checks the article links and marks the removed ones.
+// Failing to check an article is logged and skipped, so it is checked
+// again on the next round
+func (checker *Checker) CheckArticles(ctx context.Context, arts []models.NewsArticle) (removed int, err error) {
+	for _, art := range arts {
+		if ctx.Err() != nil {
+			return removed, ctx.Err()
+		}
+
+		res, err := checker.Check(ctx, art.Link)
+		if err != nil {
+			slog.Warn("tombstone check failed", "link", art.Link, "error", err.Error())
+			continue
+		}
+
+		if res.Removal == nil {
+			if err := checker.repo.MarkChecked(ctx, art.ID, checker.now()); err != nil {
+				return removed, err
+			}
+
+			continue
+		}
+
+		if err := checker.repo.MarkRemoved(ctx, art.ID, *res.Removal); err != nil {
+			return removed, err
+		}
+
+		slog.Info("article is removed", "link", art.Link, "reason", res.Removal.Reason)
+		removed++
+	}
+
+	return removed, nil
+}
+
+// RemovalFromError gets the removal from the error of crawling an
+// article, ok is false when the error is not caused by 404 or 410
+func (checker *Checker) RemovalFromError(err error) (models.ArticleRemoval, bool) {
+	code, ok := httpstatus.IsRemoved(err)
+	if !ok {
+		return models.ArticleRemoval{}, false
+	}
+
+	reason := models.RemovalNotFound
+	if code == http.StatusGone {
+		reason = models.RemovalGone
+	}
+
+	return *checker.removed(code, reason, "").Removal, true
+}
+
+func (checker *Checker) removed(code int, reason, redirect string) Result {
+	return Result{
+		StatusCode: code,
+		Removal: &models.ArticleRemoval{
+			Reason:      reason,
+			StatusCode:  code,
+			RedirectURL: redirect,
+			ObservedAt:  checker.now(),
+		},
+	}
+}
+
+func (checker *Checker) request(ctx context.Context, link string) (*http.Response, error) {
+	req, err := httpreq.NewPage(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := checker.cl.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// only the status and headers is needed
+	res.Body.Close()
+
+	return res, nil
+}
+
+// IsHomepage reports whether loc is the homepage of the site of the
+// article, including the homepage of its other subdomains, like
+// a deleted news.detik.com article redirected to www.detik.com
+func IsHomepage(loc, article *url.URL) bool {
+	if loc.Host != "" && urlnorm.SiteDomain(loc.Hostname()) != urlnorm.SiteDomain(article.Hostname()) {
+		return false
+	}
+
+	switch strings.Trim(strings.ToLower(loc.Path), "/") {
+	case "", "index", "index.html", "index.php", "home":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,195 @@
+package tombstone
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/pkg/detik"
+	"github.com/tamboto2000/ivosight-crawler/pkg/httpstatus"
+)
+
+type memRepo struct {
+	removed map[string]models.ArticleRemoval
+	checked map[string]time.Time
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{
+		removed: make(map[string]models.ArticleRemoval),
+		checked: make(map[string]time.Time),
+	}
+}
+
+func (repo *memRepo) MarkRemoved(ctx context.Context, id string, removal models.ArticleRemoval) error {
+	repo.removed[id] = removal
+	return nil
+}
+
+func (repo *memRepo) MarkChecked(ctx context.Context, id string, at time.Time) error {
+	repo.checked[id] = at
+	return nil
+}
+
+func newServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprint(w, "homepage")
+	})
+
+	mux.HandleFunc("/live", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "article")
+	})
+
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/live", http.StatusMovedPermanently)
+	})
+
+	mux.HandleFunc("/retracted", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/", http.StatusFound)
+	})
+
+	mux.HandleFunc("/blocked", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestCheck(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+
+	tests := []struct {
+		path   string
+		reason string
+		err    bool
+	}{
+		{"/live", "", false},
+		{"/moved", "", false},
+		{"/deleted", models.RemovalNotFound, false},
+		{"/gone", models.RemovalGone, false},
+		{"/retracted", models.RemovalRedirectHomepage, false},
+		{"/blocked", "", true},
+	}
+
+	checker := NewChecker(srv.Client(), newMemRepo())
+	for _, tt := range tests {
+		res, err := checker.Check(context.Background(), srv.URL+tt.path)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: expecting error", tt.path)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", tt.path, err)
+			continue
+		}
+
+		if tt.reason == "" {
+			if res.Removal != nil {
+				t.Errorf("%s: expecting not removed, got %s", tt.path, res.Removal.Reason)
+			}
+
+			continue
+		}
+
+		if res.Removal == nil || res.Removal.Reason != tt.reason {
+			t.Errorf("%s: expecting removed as %s, got %+v", tt.path, tt.reason, res.Removal)
+			continue
+		}
+
+		if res.Removal.ObservedAt.IsZero() {
+			t.Errorf("%s: expecting observed time", tt.path)
+		}
+	}
+}
+
+func TestCheckArticles(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+
+	now := time.Date(2024, 8, 12, 10, 0, 0, 0, time.UTC)
+	repo := newMemRepo()
+	checker := NewChecker(srv.Client(), repo)
+	checker.now = func() time.Time { return now }
+
+	arts := []models.NewsArticle{
+		{ID: "live", Link: srv.URL + "/live"},
+		{ID: "deleted", Link: srv.URL + "/deleted"},
+		{ID: "blocked", Link: srv.URL + "/blocked"},
+	}
+
+	removed, err := checker.CheckArticles(context.Background(), arts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if removed != 1 {
+		t.Errorf("expecting 1 removed article, got %d", removed)
+	}
+
+	if rem, ok := repo.removed["deleted"]; !ok || !rem.ObservedAt.Equal(now) || rem.StatusCode != http.StatusNotFound {
+		t.Errorf("unexpected removal %+v", rem)
+	}
+
+	if !repo.checked["live"].Equal(now) {
+		t.Error("expecting live article to be marked as checked")
+	}
+
+	if _, ok := repo.checked["blocked"]; ok {
+		t.Error("expecting failed check to be retried")
+	}
+}
+
+func TestIsHomepage(t *testing.T) {
+	article, _ := url.Parse("https://news.detik.com/berita/d-7483712/judul")
+	tests := []struct {
+		loc  string
+		want bool
+	}{
+		{"https://news.detik.com/", true},
+		{"https://www.detik.com", true},
+		{"https://www.detik.com/index.html", true},
+		{"https://news.detik.com/berita", false},
+		{"https://news.detik.com/berita/d-7483712/judul-baru", false},
+		{"https://www.liputan6.com/", false},
+	}
+
+	for _, tt := range tests {
+		loc, _ := url.Parse(tt.loc)
+		if got := IsHomepage(loc, article); got != tt.want {
+			t.Errorf("%s: expecting %v, got %v", tt.loc, tt.want, got)
+		}
+	}
+}
+
+func TestRemovalFromError(t *testing.T) {
+	checker := NewChecker(nil, newMemRepo())
+
+	err := fmt.Errorf("%w: %w", detik.ErrNotFound, &httpstatus.Error{StatusCode: http.StatusGone})
+	if rem, ok := checker.RemovalFromError(err); !ok || rem.Reason != models.RemovalGone {
+		t.Errorf("unexpected removal %+v", rem)
+	}
+
+	if _, ok := checker.RemovalFromError(&httpstatus.Error{StatusCode: http.StatusBadGateway}); ok {
+		t.Error("expecting 502 not to be removal")
+	}
+}
@@ -0,0 +1,77 @@
+package trend
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idnlp"
+)
+
+const (
+	// maxPhraseWords is the maximum words of a phrase
+	maxPhraseWords = 3
+	minWordLength  = 3
+)
+
+type itemKey struct {
+	kind string
+	key  string
+}
+
+type item struct {
+	itemKey
+	text       string
+	entityType string
+}
+
+// articleItems gets the unique terms, phrases and entities of the
+// article. The terms is the words without the stopwords, and the
+// phrases is the 2 and 3 words n-grams of adjacent terms in a
+// sentence, so a phrase does not span a stopword, like "tol
+// Semarang Demak" but not "tol di Demak"
+func articleItems(art models.NewsArticle) []item {
+	seen := make(map[itemKey]bool)
+	var items []item
+	add := func(it item) {
+		if !seen[it.itemKey] {
+			seen[it.itemKey] = true
+			items = append(items, it)
+		}
+	}
+
+	for _, text := range append([]string{art.Headline}, art.Paragraphs()...) {
+		for _, sentence := range idnlp.SplitSentences(text) {
+			var run []string
+			flush := func() {
+				for n := 2; n <= maxPhraseWords; n++ {
+					for i := 0; i+n <= len(run); i++ {
+						phrase := strings.Join(run[i:i+n], " ")
+						add(item{itemKey: itemKey{models.TrendPhrase, phrase}, text: phrase})
+					}
+				}
+
+				run = run[:0]
+			}
+
+			for _, tok := range idnlp.Tokenize(sentence) {
+				word := strings.ToLower(tok.Text)
+				if tok.Kind != idnlp.TokenWord || idnlp.IsStopword(word) || utf8.RuneCountInString(word) < minWordLength {
+					flush()
+					continue
+				}
+
+				add(item{itemKey: itemKey{models.TrendTerm, word}, text: word})
+				run = append(run, word)
+			}
+
+			flush()
+		}
+	}
+
+	for _, e := range art.Entities {
+		add(item{itemKey: itemKey{models.TrendEntity, e.Type + ":" + e.Key}, text: e.Name, entityType: e.Type})
+	}
+
+	return items
+}
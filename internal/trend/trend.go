@@ -0,0 +1,377 @@
+// Package trend finds the trending terms, phrases and entities of the
+// crawl stream. The number of articles having each item is counted in
+// hourly buckets per source and channel. The count in the recent
+// window is compared to the count expected from the baseline before
+// it, and the burst score is how many standard deviations, of a
+// Poisson count, the count is above the expected:
+//
+//	score = (count - expected) / sqrt(expected + 1)
+//
+// So a term always in the news, like the names of the regions, does
+// not trend unless it is mentioned more than usual.
+package trend
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+const (
+	DefaultWindow   = 6 * time.Hour
+	DefaultBaseline = 7 * 24 * time.Hour
+	// bucketSize is the time resolution of the counts
+	bucketSize = time.Hour
+	// minCount is the minimum articles in the window
+	// for an item to trend
+	minCount = 3
+	// minScore is the minimum burst score to trend
+	minScore = 2
+	// snapshotLimit is the number of items of each
+	// kind kept in the snapshots
+	snapshotLimit = 50
+)
+
+type Repository interface {
+	// EachArticleSince calls fn with every stored article published
+	// since since that is not removed
+	EachArticleSince(ctx context.Context, since time.Time, fn func(models.NewsArticle) error) error
+	StoreTrendSnapshot(ctx context.Context, snap models.TrendSnapshot) error
+}
+
+// Scope is the source and channel of the trends,
+// empty is all of it
+type Scope struct {
+	Source  models.ArticleSource
+	Channel string
+}
+
+func (scope Scope) String() string {
+	source := string(scope.Source)
+	if source == "" {
+		source = "all"
+	}
+
+	if scope.Channel == "" {
+		return source
+	}
+
+	return source + "/" + scope.Channel
+}
+
+// bucket is the counts of an hour
+type bucket struct {
+	articles map[Scope]int
+	counts   map[Scope]map[itemKey]int
+}
+
+type Engine struct {
+	repo     Repository
+	window   time.Duration
+	baseline time.Duration
+	now      func() time.Time
+
+	mx      sync.Mutex
+	buckets map[int64]*bucket
+	// texts is the latest text of each item
+	texts map[itemKey]item
+	// seen is the bucket of the counted clusters of each scope,
+	// a re-crawled article and the near-duplicates of a counted
+	// article, like the same wire copy run by several portals,
+	// is not counted again
+	seen map[seenKey]int64
+}
+
+type seenKey struct {
+	scope   Scope
+	cluster string
+}
+
+func NewEngine(repo Repository) *Engine {
+	return &Engine{
+		repo:     repo,
+		window:   DefaultWindow,
+		baseline: DefaultBaseline,
+		now:      time.Now,
+		buckets:  make(map[int64]*bucket),
+		texts:    make(map[itemKey]item),
+		seen:     make(map[seenKey]int64),
+	}
+}
+
+// WithWindow sets the recent window compared to the baseline,
+// rounded up to the hour
+func (eng *Engine) WithWindow(window time.Duration) *Engine {
+	eng.window = max(window.Round(bucketSize), bucketSize)
+	return eng
+}
+
+// WithBaseline sets how long before the window the baseline is
+func (eng *Engine) WithBaseline(baseline time.Duration) *Engine {
+	eng.baseline = max(baseline.Round(bucketSize), bucketSize)
+	return eng
+}
+
+// Load counts the articles stored in the baseline and the window,
+// so the baseline is not empty after restart
+func (eng *Engine) Load(ctx context.Context) error {
+	since := eng.now().Add(-eng.window - eng.baseline)
+	return eng.repo.EachArticleSince(ctx, since, func(art models.NewsArticle) error {
+		eng.Observe(art)
+		return nil
+	})
+}
+
+// Observe counts the items of the article in the buckets of its
+// source and channel. The article must have its ID set, and its
+// cluster ID when it is a near-duplicate, so each cluster is counted
+// once in each scope
+func (eng *Engine) Observe(art models.NewsArticle) {
+	at := art.PublishedAt
+	if at.IsZero() {
+		at = eng.now()
+	}
+
+	items := articleItems(art)
+
+	eng.mx.Lock()
+	defer eng.mx.Unlock()
+
+	eng.evict()
+
+	key := at.Truncate(bucketSize).Unix()
+	if key < eng.oldest() {
+		return
+	}
+
+	cluster := art.ClusterID
+	if cluster == "" {
+		cluster = art.ID
+	}
+
+	for _, scope := range []Scope{{}, {Source: art.Source}, {Source: art.Source, Channel: art.Channel}} {
+		if scope.Channel == "" && scope.Source != "" && art.Channel == "" {
+			// the source scope is already counted
+			continue
+		}
+
+		sk := seenKey{scope: scope, cluster: cluster}
+		if _, ok := eng.seen[sk]; ok {
+			continue
+		}
+
+		eng.seen[sk] = key
+
+		b, ok := eng.buckets[key]
+		if !ok {
+			b = &bucket{articles: make(map[Scope]int), counts: make(map[Scope]map[itemKey]int)}
+			eng.buckets[key] = b
+		}
+
+		b.articles[scope]++
+		counts, ok := b.counts[scope]
+		if !ok {
+			counts = make(map[itemKey]int)
+			b.counts[scope] = counts
+		}
+
+		for _, it := range items {
+			counts[it.itemKey]++
+			eng.texts[it.itemKey] = it
+		}
+	}
+}
+
+// oldest gets the oldest bucket kept
+func (eng *Engine) oldest() int64 {
+	return eng.now().Add(-eng.window - eng.baseline).Truncate(bucketSize).Unix()
+}
+
+// evict drops the buckets older than the baseline
+func (eng *Engine) evict() {
+	oldest := eng.oldest()
+	evicted := false
+	for key := range eng.buckets {
+		if key < oldest {
+			delete(eng.buckets, key)
+			evicted = true
+		}
+	}
+
+	if evicted {
+		// drop the texts of the items no longer counted
+		live := make(map[itemKey]bool)
+		for _, b := range eng.buckets {
+			for _, counts := range b.counts {
+				for k := range counts {
+					live[k] = true
+				}
+			}
+		}
+
+		for k := range eng.texts {
+			if !live[k] {
+				delete(eng.texts, k)
+			}
+		}
+	}
+
+	for sk, key := range eng.seen {
+		if key < oldest {
+			delete(eng.seen, sk)
+		}
+	}
+}
+
+// Scopes gets the scopes having any article, all sources first,
+// then each source followed by its channels
+func (eng *Engine) Scopes() []Scope {
+	eng.mx.Lock()
+	defer eng.mx.Unlock()
+
+	seen := make(map[Scope]bool)
+	for _, b := range eng.buckets {
+		for scope := range b.articles {
+			seen[scope] = true
+		}
+	}
+
+	scopes := make([]Scope, 0, len(seen))
+	for scope := range seen {
+		scopes = append(scopes, scope)
+	}
+
+	sort.Slice(scopes, func(i, j int) bool {
+		if scopes[i].Source != scopes[j].Source {
+			return scopes[i].Source < scopes[j].Source
+		}
+
+		return scopes[i].Channel < scopes[j].Channel
+	})
+
+	return scopes
+}
+
+// Top gets the trending items of the scope, the highest burst score
+// first, limit items of each kind
+func (eng *Engine) Top(scope Scope, limit int) models.TrendSnapshot {
+	eng.mx.Lock()
+	defer eng.mx.Unlock()
+
+	eng.evict()
+
+	now := eng.now()
+	windowEnd := now.Truncate(bucketSize).Add(bucketSize)
+	windowStart := windowEnd.Add(-eng.window)
+	baselineStart := windowStart.Add(-eng.baseline)
+
+	snap := models.TrendSnapshot{
+		ID:            fmt.Sprintf("%s@%d", scope, windowEnd.Unix()),
+		Source:        scope.Source,
+		Channel:       scope.Channel,
+		WindowStart:   windowStart,
+		WindowEnd:     windowEnd,
+		BaselineStart: baselineStart,
+		Terms:         []models.TrendItem{},
+		Phrases:       []models.TrendItem{},
+		Entities:      []models.TrendItem{},
+		CreatedAt:     now,
+	}
+
+	window := make(map[itemKey]int)
+	baseline := make(map[itemKey]int)
+	for key, b := range eng.buckets {
+		if key < baselineStart.Unix() {
+			continue
+		}
+
+		inWindow := key >= windowStart.Unix()
+		if inWindow {
+			snap.Articles += b.articles[scope]
+		}
+
+		for k, n := range b.counts[scope] {
+			if inWindow {
+				window[k] += n
+			} else {
+				baseline[k] += n
+			}
+		}
+	}
+
+	// the baseline rate is scaled to the window length
+	scale := eng.window.Hours() / eng.baseline.Hours()
+	for k, n := range window {
+		if n < minCount {
+			continue
+		}
+
+		expected := float64(baseline[k]) * scale
+		score := (float64(n) - expected) / math.Sqrt(expected+1)
+		if score < minScore {
+			continue
+		}
+
+		it := eng.texts[k]
+		ti := models.TrendItem{
+			Kind:     k.kind,
+			Key:      k.key,
+			Text:     it.text,
+			Count:    n,
+			Expected: math.Round(expected*100) / 100,
+			Score:    math.Round(score*1000) / 1000,
+		}
+
+		switch k.kind {
+		case models.TrendTerm:
+			snap.Terms = append(snap.Terms, ti)
+
+		case models.TrendPhrase:
+			snap.Phrases = append(snap.Phrases, ti)
+
+		case models.TrendEntity:
+			ti.EntityType = it.entityType
+			_, ti.Key, _ = strings.Cut(k.key, ":")
+			snap.Entities = append(snap.Entities, ti)
+		}
+	}
+
+	snap.Terms = top(snap.Terms, limit)
+	snap.Phrases = top(snap.Phrases, limit)
+	snap.Entities = top(snap.Entities, limit)
+
+	return snap
+}
+
+func top(items []models.TrendItem, limit int) []models.TrendItem {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+
+		return items[i].Key < items[j].Key
+	})
+
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+
+	return items
+}
+
+// Snapshot stores the trending items of every scope
+func (eng *Engine) Snapshot(ctx context.Context) error {
+	for _, scope := range eng.Scopes() {
+		if err := eng.repo.StoreTrendSnapshot(ctx, eng.Top(scope, snapshotLimit)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package trend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+type memRepo struct {
+	articles  []models.NewsArticle
+	snapshots []models.TrendSnapshot
+}
+
+func (repo *memRepo) EachArticleSince(ctx context.Context, since time.Time, fn func(models.NewsArticle) error) error {
+	for _, art := range repo.articles {
+		if art.PublishedAt.Before(since) {
+			continue
+		}
+
+		if err := fn(art); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repo *memRepo) StoreTrendSnapshot(ctx context.Context, snap models.TrendSnapshot) error {
+	repo.snapshots = append(repo.snapshots, snap)
+	return nil
+}
+
+// article builds a news channel article of a single paragraph
+func article(id string, source models.ArticleSource, at time.Time, headline, text string) models.NewsArticle {
+	data, _ := json.Marshal(models.ArticleTextContent{Text: text})
+
+	return models.NewsArticle{
+		ID:          id,
+		Source:      source,
+		Channel:     "news",
+		Headline:    headline,
+		PublishedAt: at,
+		Contents:    []models.ArticleContent{{Type: models.ContentParagraphText, Data: data}},
+	}
+}
+
+func TestArticleItems(t *testing.T) {
+	art := article("1", models.Detik, time.Time{}, "Banjir Rob Landa Demak", "Warga mengungsi ke balai desa.")
+	art.Entities = []models.ArticleEntity{{Key: "demak", Name: "Demak", Type: models.EntityLocation}}
+
+	keys := make(map[itemKey]bool)
+	for _, it := range articleItems(art) {
+		keys[it.itemKey] = true
+	}
+
+	for _, k := range []itemKey{
+		{models.TrendTerm, "banjir"},
+		{models.TrendPhrase, "banjir rob"},
+		{models.TrendPhrase, "rob landa demak"},
+		{models.TrendPhrase, "balai desa"},
+		{models.TrendEntity, models.EntityLocation + ":demak"},
+	} {
+		if !keys[k] {
+			t.Errorf("expecting %+v", k)
+		}
+	}
+
+	// the phrase does not span the stopword
+	if keys[itemKey{models.TrendPhrase, "mengungsi balai"}] {
+		t.Error("unexpected phrase across stopword")
+	}
+}
+
+func TestTop(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 30, 0, 0, time.UTC)
+	repo := &memRepo{}
+
+	// jalan is in the news every day, banjir only bursts
+	// in the window
+	id := 0
+	for hour := 6; hour < 7*24+6; hour += 2 {
+		id++
+		at := now.Add(-time.Duration(hour) * time.Hour)
+		repo.articles = append(repo.articles, article(fmt.Sprint(id), models.Detik, at, "Perbaikan Jalan Provinsi", "Jalan provinsi diperbaiki."))
+	}
+
+	for i := 0; i < 4; i++ {
+		id++
+		at := now.Add(-time.Duration(i) * time.Hour)
+		art := article(fmt.Sprint(id), models.Detik, at, "Banjir Rob Landa Demak", "Jalan provinsi tergenang banjir rob.")
+		art.Entities = []models.ArticleEntity{{Key: "demak", Name: "Demak", Type: models.EntityLocation}}
+		repo.articles = append(repo.articles, art)
+	}
+
+	eng := NewEngine(repo)
+	eng.now = func() time.Time { return now }
+
+	if err := eng.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// re-crawled article is not counted again
+	eng.Observe(repo.articles[len(repo.articles)-1])
+
+	snap := eng.Top(Scope{Source: models.Detik}, 10)
+	if snap.Articles != 4 {
+		t.Errorf("expecting 4 articles in the window, got %d", snap.Articles)
+	}
+
+	if len(snap.Terms) == 0 || snap.Terms[0].Key != "banjir" && snap.Terms[0].Key != "rob" {
+		t.Errorf("expecting banjir or rob first, got %+v", snap.Terms)
+	}
+
+	for _, it := range snap.Terms {
+		if it.Key == "jalan" {
+			t.Errorf("unexpected usual term %+v", it)
+		}
+	}
+
+	if len(snap.Phrases) == 0 || snap.Phrases[0].Key != "banjir rob" {
+		t.Errorf("expecting banjir rob first, got %+v", snap.Phrases)
+	}
+
+	if len(snap.Entities) != 1 || snap.Entities[0].Key != "demak" || snap.Entities[0].EntityType != models.EntityLocation || snap.Entities[0].Count != 4 {
+		t.Errorf("unexpected entities %+v", snap.Entities)
+	}
+
+	if other := eng.Top(Scope{Source: models.Liputan6}, 10); other.Articles != 0 || len(other.Terms) != 0 {
+		t.Errorf("unexpected trends of other source %+v", other)
+	}
+
+	if err := eng.Snapshot(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var scopes []string
+	for _, snap := range repo.snapshots {
+		scopes = append(scopes, Scope{snap.Source, snap.Channel}.String())
+	}
+
+	if fmt.Sprint(scopes) != "[all Detik.com Detik.com/news]" {
+		t.Errorf("unexpected snapshot scopes %v", scopes)
+	}
+}
+
+func TestObserveCluster(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 30, 0, 0, time.UTC)
+	eng := NewEngine(&memRepo{})
+	eng.now = func() time.Time { return now }
+
+	// the same wire copy is run by two portals, the copies of
+	// the same portal is counted once in its scope as well
+	for _, art := range []models.NewsArticle{
+		article("a", models.Detik, now, "Banjir Rob Landa Demak", "Warga mengungsi."),
+		article("b", models.Liputan6, now, "Banjir Rob Landa Demak", "Warga mengungsi."),
+		article("c", models.Detik, now, "Banjir Rob Landa Demak", "Warga mengungsi."),
+	} {
+		art.ClusterID = "a"
+		eng.Observe(art)
+	}
+
+	tests := map[Scope]int{
+		{}:                        1,
+		{Source: models.Detik}:    1,
+		{Source: models.Liputan6}: 1,
+	}
+
+	for scope, expected := range tests {
+		if snap := eng.Top(scope, 10); snap.Articles != expected {
+			t.Errorf("%s: expecting %d articles, got %d", scope, expected, snap.Articles)
+		}
+	}
+}
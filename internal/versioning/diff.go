@@ -0,0 +1,151 @@
+package versioning
+
+import (
+	"encoding/json"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+// block is the comparable form of a content block
+type block struct {
+	typ  string
+	text string
+}
+
+func blocksOf(art models.NewsArticle) []block {
+	blocks := make([]block, len(art.Contents))
+	for i, content := range art.Contents {
+		blocks[i] = block{typ: content.Type, text: blockText(content)}
+	}
+
+	return blocks
+}
+
+// blockText gets the text of the block that is compared, the raw
+// data is used for non text blocks
+func blockText(content models.ArticleContent) string {
+	switch content.Type {
+	case models.ContentParagraphText, models.ContentSectionTitle:
+		if text, ok := content.TextContent(); ok {
+			return text.Text
+		}
+
+	case models.ContentImage:
+		var img models.ArticleImageContent
+		if err := json.Unmarshal(content.Data, &img); err == nil {
+			return img.URL + "\n" + img.Caption
+		}
+
+	case models.ContentVideo:
+		var vid models.ArticleVideoContent
+		if err := json.Unmarshal(content.Data, &vid); err == nil {
+			return vid.URL + "\n" + vid.EmbeddedURL
+		}
+
+	case models.ContentReferencedArticle:
+		var ref models.ArticleReferenceContent
+		if err := json.Unmarshal(content.Data, &ref); err == nil {
+			return ref.ArticleLink
+		}
+	}
+
+	return string(content.Data)
+}
+
+// Diff compares the headline, description and content blocks of two
+// versions of an article. The blocks is matched with the longest
+// common subsequence, a deleted block that is directly replaced by
+// an inserted block of the same type is reported as modified
+func Diff(old, new models.NewsArticle) models.ArticleDiff {
+	var diff models.ArticleDiff
+	if old.Headline != new.Headline {
+		diff.Headline = &models.TextChange{Old: old.Headline, New: new.Headline}
+	}
+
+	if old.Description != new.Description {
+		diff.Description = &models.TextChange{Old: old.Description, New: new.Description}
+	}
+
+	diff.Blocks = diffBlocks(blocksOf(old), blocksOf(new))
+
+	return diff
+}
+
+func diffBlocks(a, b []block) []models.BlockChange {
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var changes []models.BlockChange
+	var deleted, inserted []models.BlockChange
+
+	// flush pairs the pending deleted and inserted blocks of
+	// the same type into modified blocks
+	flush := func() {
+		for len(deleted) != 0 && len(inserted) != 0 && deleted[0].Type == inserted[0].Type {
+			changes = append(changes, models.BlockChange{
+				Op:       models.BlockModified,
+				Type:     deleted[0].Type,
+				OldIndex: deleted[0].OldIndex,
+				NewIndex: inserted[0].NewIndex,
+				Old:      deleted[0].Old,
+				New:      inserted[0].New,
+			})
+
+			deleted = deleted[1:]
+			inserted = inserted[1:]
+		}
+
+		changes = append(changes, deleted...)
+		changes = append(changes, inserted...)
+		deleted, inserted = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			flush()
+			i++
+			j++
+
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			inserted = append(inserted, models.BlockChange{
+				Op:       models.BlockInserted,
+				Type:     b[j].typ,
+				OldIndex: -1,
+				NewIndex: j,
+				New:      b[j].text,
+			})
+
+			j++
+
+		default:
+			deleted = append(deleted, models.BlockChange{
+				Op:       models.BlockDeleted,
+				Type:     a[i].typ,
+				OldIndex: i,
+				NewIndex: -1,
+				Old:      a[i].text,
+			})
+
+			i++
+		}
+	}
+
+	flush()
+
+	return changes
+}
@@ -0,0 +1,116 @@
+// Package versioning keeps the version history of the articles. When
+// a re-crawled article is changed, either its content hash or its
+// UpdatedAt, the stored article is archived as a version along with
+// the block level diff to the new one, so the edits made after the
+// article is published, including the silent ones, can be traced.
+package versioning
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+type Repository interface {
+	// FindArticleByLink finds the stored article, ok is false
+	// when the article is not stored yet
+	FindArticleByLink(ctx context.Context, link string) (art models.NewsArticle, ok bool, err error)
+	StoreVersion(ctx context.Context, version models.ArticleVersion) error
+}
+
+// ContentHash hashes the headline, description and content blocks
+// of the article
+func ContentHash(art models.NewsArticle) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", art.Headline, art.Description)
+	for _, b := range blocksOf(art) {
+		fmt.Fprintf(h, "%s\x00%s\x00", b.typ, b.text)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type Tracker struct {
+	repo Repository
+	now  func() time.Time
+}
+
+func NewTracker(repo Repository) *Tracker {
+	return &Tracker{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// Track sets the content hash and the version of the article before
+// it is stored. If the stored article is changed, it is archived as
+// a version and changed is true
+func (tracker *Tracker) Track(ctx context.Context, art *models.NewsArticle) (changed bool, err error) {
+	art.ContentHash = ContentHash(*art)
+
+	prev, ok, err := tracker.repo.FindArticleByLink(ctx, art.Link)
+	if err != nil {
+		return false, err
+	}
+
+	if !ok {
+		art.Version = 1
+		return false, nil
+	}
+
+	// articles stored before the versioning has no hash
+	// and version, those is treated as the first version
+	if prev.Version == 0 {
+		prev.Version = 1
+	}
+
+	if prev.ContentHash == "" {
+		prev.ContentHash = ContentHash(prev)
+	}
+
+	art.ID = prev.ID
+	art.StoryID = prev.StoryID
+	art.ChangedAt = prev.ChangedAt
+	art.Version = prev.Version
+	art.Aliases = mergeAliases(prev, *art)
+
+	if prev.ContentHash == art.ContentHash && prev.UpdatedAt.Equal(art.UpdatedAt) {
+		return false, nil
+	}
+
+	version := models.ArticleVersion{
+		ID:         fmt.Sprintf("%s-%d", prev.ID, prev.Version),
+		ArticleID:  prev.ID,
+		Link:       prev.Link,
+		Version:    prev.Version,
+		Article:    prev,
+		Diff:       Diff(prev, *art),
+		DetectedAt: tracker.now(),
+	}
+
+	if err := tracker.repo.StoreVersion(ctx, version); err != nil {
+		return false, err
+	}
+
+	art.Version = prev.Version + 1
+
+	return true, nil
+}
+
+// mergeAliases merges the aliases of the stored article, its link
+// is an alias as well when the canonical URL is changed
+func mergeAliases(prev, art models.NewsArticle) []string {
+	aliases := append([]string(nil), art.Aliases...)
+	for _, alias := range append([]string{prev.Link}, prev.Aliases...) {
+		if alias != art.Link && !slices.Contains(aliases, alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	return aliases
+}
@@ -0,0 +1,145 @@
+package versioning
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+)
+
+func paragraph(text string) models.ArticleContent {
+	data, _ := json.Marshal(models.ArticleTextContent{Text: text})
+	return models.ArticleContent{Type: models.ContentParagraphText, Data: data}
+}
+
+func image(url string) models.ArticleContent {
+	data, _ := json.Marshal(models.ArticleImageContent{URL: url})
+	return models.ArticleContent{Type: models.ContentImage, Data: data}
+}
+
+func TestDiff(t *testing.T) {
+	old := models.NewsArticle{
+		Headline: "Judul Lama",
+		Contents: []models.ArticleContent{
+			paragraph("satu"),
+			paragraph("dua"),
+			image("https://cdn.com/1.jpg"),
+			paragraph("tiga"),
+			paragraph("empat"),
+		},
+	}
+
+	new := models.NewsArticle{
+		Headline: "Judul Baru",
+		Contents: []models.ArticleContent{
+			paragraph("satu"),
+			paragraph("dua diubah"),
+			image("https://cdn.com/1.jpg"),
+			paragraph("empat"),
+			paragraph("lima"),
+		},
+	}
+
+	diff := Diff(old, new)
+	if diff.Headline == nil || diff.Headline.Old != "Judul Lama" || diff.Headline.New != "Judul Baru" {
+		t.Errorf("unexpected headline change %+v", diff.Headline)
+	}
+
+	if diff.Description != nil {
+		t.Errorf("expecting no description change")
+	}
+
+	want := []models.BlockChange{
+		{Op: models.BlockModified, Type: models.ContentParagraphText, OldIndex: 1, NewIndex: 1, Old: "dua", New: "dua diubah"},
+		{Op: models.BlockDeleted, Type: models.ContentParagraphText, OldIndex: 3, NewIndex: -1, Old: "tiga"},
+		{Op: models.BlockInserted, Type: models.ContentParagraphText, OldIndex: -1, NewIndex: 4, New: "lima"},
+	}
+
+	if len(diff.Blocks) != len(want) {
+		t.Fatalf("expecting %d changes, got %+v", len(want), diff.Blocks)
+	}
+
+	for i, change := range diff.Blocks {
+		if change != want[i] {
+			t.Errorf("change %d: expecting %+v, got %+v", i, want[i], change)
+		}
+	}
+
+	if !Diff(old, old).IsEmpty() {
+		t.Error("expecting empty diff of the same article")
+	}
+}
+
+type memRepo struct {
+	articles map[string]models.NewsArticle
+	versions []models.ArticleVersion
+}
+
+func (repo *memRepo) FindArticleByLink(ctx context.Context, link string) (models.NewsArticle, bool, error) {
+	art, ok := repo.articles[link]
+	return art, ok, nil
+}
+
+func (repo *memRepo) StoreVersion(ctx context.Context, version models.ArticleVersion) error {
+	repo.versions = append(repo.versions, version)
+	return nil
+}
+
+func TestTracker(t *testing.T) {
+	ctx := context.Background()
+	repo := &memRepo{articles: make(map[string]models.NewsArticle)}
+	tracker := NewTracker(repo)
+
+	art := models.NewsArticle{
+		ID:       "a1",
+		Link:     "https://news.detik.com/d-1",
+		Headline: "Judul",
+		Contents: []models.ArticleContent{paragraph("satu")},
+	}
+
+	if changed, err := tracker.Track(ctx, &art); err != nil || changed || art.Version != 1 {
+		t.Fatalf("expecting first version, got %d, %v, %v", art.Version, changed, err)
+	}
+
+	art.StoryID = "s1"
+	repo.articles[art.Link] = art
+
+	// re-crawled without changes
+	same := art
+	same.ID = ""
+	same.StoryID = ""
+	if changed, _ := tracker.Track(ctx, &same); changed || same.Version != 1 || same.ID != "a1" || same.StoryID != "s1" {
+		t.Errorf("expecting unchanged version 1, got %d, %v", same.Version, changed)
+	}
+
+	// stealth edit, the body is changed without UpdatedAt
+	edited := art
+	edited.Contents = []models.ArticleContent{paragraph("satu diubah")}
+	if changed, _ := tracker.Track(ctx, &edited); !changed || edited.Version != 2 {
+		t.Fatalf("expecting changed version 2, got %d, %v", edited.Version, changed)
+	}
+
+	if len(repo.versions) != 1 {
+		t.Fatalf("expecting 1 version, got %d", len(repo.versions))
+	}
+
+	version := repo.versions[0]
+	if version.ID != "a1-1" || version.Version != 1 || version.Article.ContentHash != art.ContentHash {
+		t.Errorf("unexpected version %+v", version)
+	}
+
+	if len(version.Diff.Blocks) != 1 || version.Diff.Blocks[0].Op != models.BlockModified {
+		t.Errorf("unexpected diff %+v", version.Diff)
+	}
+
+	repo.articles[art.Link] = edited
+
+	// only UpdatedAt is changed
+	updated := edited
+	updated.UpdatedAt = time.Now()
+	if changed, _ := tracker.Track(ctx, &updated); !changed || updated.Version != 3 {
+		t.Errorf("expecting changed version 3, got %d, %v", updated.Version, changed)
+	}
+}
@@ -0,0 +1,384 @@
+// Package webhook delivers the stored articles to the registered
+// endpoints. Each matching endpoint gets a delivery, stored before
+// it is attempted so it survives restart, and POSTed as JSON signed
+// with the endpoint secret:
+//
+//	X-Webhook-Signature: sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+//
+// where timestamp is X-Webhook-Timestamp, the Unix seconds of the
+// attempt. A failed attempt is retried with exponential backoff, and
+// after the maximum attempts the delivery is kept as a dead letter.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idnlp"
+)
+
+const (
+	DefaultMaxAttempts = 8
+	DefaultTimeout     = 10 * time.Second
+	// DefaultBackoff is the delay of the first retry, doubled
+	// on each retry up to DefaultMaxBackoff
+	DefaultBackoff    = 30 * time.Second
+	DefaultMaxBackoff = time.Hour
+	// pollInterval is how often the due deliveries is checked
+	pollInterval = 10 * time.Second
+	batchSize    = 100
+	// workers is the maximum concurrent attempts
+	workers = 4
+)
+
+type Repository interface {
+	WebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error)
+	// StoreWebhookDelivery stores the delivery, replacing the
+	// delivery with the same ID
+	StoreWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error
+	// DueWebhookDeliveries finds the pending deliveries with next
+	// attempt at or before now, the earliest first
+	DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error)
+}
+
+// Payload is the JSON body of a delivery
+type Payload struct {
+	// ID is the delivery ID, the same on every attempt so
+	// the receiver can ignore the repeated delivery
+	ID      string               `json:"id"`
+	Event   string               `json:"event"`
+	Article models.ArticleWithID `json:"article"`
+}
+
+type Dispatcher struct {
+	repo        Repository
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	now         func() time.Time
+	// wake is signalled on enqueue, so the new deliveries
+	// is attempted without waiting for the poll
+	wake chan struct{}
+}
+
+func NewDispatcher(repo Repository) *Dispatcher {
+	return &Dispatcher{
+		repo:        repo,
+		client:      &http.Client{Timeout: DefaultTimeout},
+		maxAttempts: DefaultMaxAttempts,
+		backoff:     DefaultBackoff,
+		maxBackoff:  DefaultMaxBackoff,
+		now:         time.Now,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// WithClient sets the HTTP client of the attempts
+func (disp *Dispatcher) WithClient(cl *http.Client) *Dispatcher {
+	disp.client = cl
+	return disp
+}
+
+// WithMaxAttempts sets the attempts before the delivery is dead
+func (disp *Dispatcher) WithMaxAttempts(n int) *Dispatcher {
+	disp.maxAttempts = n
+	return disp
+}
+
+// WithBackoff sets the delay of the first retry, and
+// the maximum delay it is doubled to
+func (disp *Dispatcher) WithBackoff(backoff, maxBackoff time.Duration) *Dispatcher {
+	disp.backoff = backoff
+	disp.maxBackoff = maxBackoff
+	return disp
+}
+
+// Enqueue stores a delivery of the article to each matching
+// endpoint, n is the number of deliveries
+func (disp *Dispatcher) Enqueue(ctx context.Context, event string, art models.NewsArticle) (n int, err error) {
+	endpoints, err := disp.repo.WebhookEndpoints(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := disp.now()
+	for _, ep := range endpoints {
+		if !Match(ep, art) {
+			continue
+		}
+
+		id, err := NewID()
+		if err != nil {
+			return n, err
+		}
+
+		payload, err := json.Marshal(Payload{ID: id, Event: event, Article: art.WithID()})
+		if err != nil {
+			return n, err
+		}
+
+		delivery := models.WebhookDelivery{
+			ID:            id,
+			EndpointID:    ep.ID,
+			ArticleID:     art.ID,
+			Event:         event,
+			Payload:       payload,
+			Status:        models.WebhookPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+
+		if err := disp.repo.StoreWebhookDelivery(ctx, delivery); err != nil {
+			return n, err
+		}
+
+		n++
+	}
+
+	if n > 0 {
+		select {
+		case disp.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	return n, nil
+}
+
+// Run attempts the due deliveries until ctx is cancelled
+func (disp *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := disp.deliverDue(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("failed to deliver webhooks", "error", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		case <-disp.wake:
+		}
+	}
+}
+
+// deliverDue attempts the due deliveries
+func (disp *Dispatcher) deliverDue(ctx context.Context) error {
+	endpoints, err := disp.repo.WebhookEndpoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]models.WebhookEndpoint)
+	for _, ep := range endpoints {
+		byID[ep.ID] = ep
+	}
+
+	for {
+		deliveries, err := disp.repo.DueWebhookDeliveries(ctx, disp.now(), batchSize)
+		if err != nil {
+			return err
+		}
+
+		sem := make(chan struct{}, workers)
+		var wg sync.WaitGroup
+		var mx sync.Mutex
+		var errs []error
+		for _, delivery := range deliveries {
+			ep, ok := byID[delivery.EndpointID]
+
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+
+				if ok {
+					disp.attempt(ctx, ep, &delivery)
+				} else {
+					delivery.Status = models.WebhookDead
+					delivery.LastError = "endpoint is removed"
+					delivery.UpdatedAt = disp.now()
+				}
+
+				if err := disp.repo.StoreWebhookDelivery(ctx, delivery); err != nil {
+					mx.Lock()
+					errs = append(errs, err)
+					mx.Unlock()
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		if len(errs) != 0 {
+			return errs[0]
+		}
+
+		if len(deliveries) < batchSize || ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// attempt POSTs the delivery to the endpoint, and updates
+// the delivery with the result
+func (disp *Dispatcher) attempt(ctx context.Context, ep models.WebhookEndpoint, delivery *models.WebhookDelivery) {
+	now := disp.now()
+	delivery.Attempts++
+	delivery.UpdatedAt = now
+
+	code, err := disp.post(ctx, ep, *delivery, now)
+	delivery.LastStatusCode = code
+	if err == nil {
+		delivery.Status = models.WebhookDelivered
+		delivery.DeliveredAt = now
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = err.Error()
+
+	if delivery.Attempts >= disp.maxAttempts {
+		delivery.Status = models.WebhookDead
+		slog.Warn("webhook delivery is dead", "id", delivery.ID, "endpoint", ep.URL, "attempts", delivery.Attempts, "error", delivery.LastError)
+		return
+	}
+
+	delivery.NextAttemptAt = now.Add(disp.retryDelay(delivery.Attempts))
+}
+
+func (disp *Dispatcher) post(ctx context.Context, ep models.WebhookEndpoint, delivery models.WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ivosight-crawler-webhook")
+	req.Header.Set("X-Webhook-ID", delivery.ID)
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", Sign(ep.Secret, timestamp, delivery.Payload))
+
+	res, err := disp.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer res.Body.Close()
+
+	// the connection is reused only when the body is read
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
+
+// retryDelay gets the delay after the attempts
+func (disp *Dispatcher) retryDelay(attempts int) time.Duration {
+	delay := disp.backoff
+	for i := 1; i < attempts && delay < disp.maxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, disp.maxBackoff)
+}
+
+// Match reports whether the article matches the
+// filters of the endpoint
+func Match(ep models.WebhookEndpoint, art models.NewsArticle) bool {
+	if len(ep.Sources) != 0 && !slices.Contains(ep.Sources, string(art.Source)) {
+		return false
+	}
+
+	if len(ep.Channels) != 0 && !slices.Contains(ep.Channels, art.Channel) {
+		return false
+	}
+
+	if len(ep.Keywords) == 0 {
+		return true
+	}
+
+	for _, keyword := range ep.Keywords {
+		terms := idnlp.Terms(keyword)
+		if len(terms) == 0 {
+			continue
+		}
+
+		all := true
+		for _, term := range terms {
+			if !slices.Contains(art.Terms, term) {
+				all = false
+				break
+			}
+		}
+
+		if all {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sign signs the body sent at timestamp, the Unix seconds, with
+// the secret. It is the value of X-Webhook-Signature header
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the signature is of the body sent at
+// timestamp, the receivers should reject an old timestamp as well
+func Verify(secret, signature string, timestamp int64, body []byte) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
+
+// NewSecret generates a random endpoint secret
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// NewID generates a random endpoint or delivery ID
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,385 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/internal/models"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idnlp"
+)
+
+type memRepo struct {
+	mx         sync.Mutex
+	endpoints  []models.WebhookEndpoint
+	deliveries map[string]models.WebhookDelivery
+}
+
+func newMemRepo(endpoints ...models.WebhookEndpoint) *memRepo {
+	return &memRepo{endpoints: endpoints, deliveries: make(map[string]models.WebhookDelivery)}
+}
+
+func (repo *memRepo) WebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	return repo.endpoints, nil
+}
+
+func (repo *memRepo) StoreWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+
+	repo.deliveries[delivery.ID] = delivery
+
+	return nil
+}
+
+func (repo *memRepo) DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+
+	var due []models.WebhookDelivery
+	for _, delivery := range repo.deliveries {
+		if delivery.Status == models.WebhookPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+
+	slices.SortFunc(due, func(a, b models.WebhookDelivery) int { return a.NextAttemptAt.Compare(b.NextAttemptAt) })
+
+	return due[:min(len(due), limit)], nil
+}
+
+// only is the only delivery of the repo
+func (repo *memRepo) only(t *testing.T) models.WebhookDelivery {
+	t.Helper()
+
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+
+	if len(repo.deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(repo.deliveries))
+	}
+
+	for _, delivery := range repo.deliveries {
+		return delivery
+	}
+
+	return models.WebhookDelivery{}
+}
+
+// receiver records the verified payloads, and responds
+// with the statuses in order, then 200
+type receiver struct {
+	t        *testing.T
+	secret   string
+	mx       sync.Mutex
+	statuses []int
+	payloads []Payload
+}
+
+func (rcv *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	ts, _ := strconv.ParseInt(r.Header.Get("X-Webhook-Timestamp"), 10, 64)
+	if !Verify(rcv.secret, r.Header.Get("X-Webhook-Signature"), ts, body) {
+		rcv.t.Errorf("invalid signature %q", r.Header.Get("X-Webhook-Signature"))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		rcv.t.Error(err)
+	}
+
+	if r.Header.Get("X-Webhook-ID") != payload.ID {
+		rcv.t.Errorf("expected X-Webhook-ID %s, got %s", payload.ID, r.Header.Get("X-Webhook-ID"))
+	}
+
+	rcv.mx.Lock()
+	defer rcv.mx.Unlock()
+
+	rcv.payloads = append(rcv.payloads, payload)
+
+	status := http.StatusOK
+	if len(rcv.statuses) != 0 {
+		status, rcv.statuses = rcv.statuses[0], rcv.statuses[1:]
+	}
+
+	w.WriteHeader(status)
+}
+
+func testArticle() models.NewsArticle {
+	art := models.NewsArticle{
+		ID:       "art-1",
+		Source:   models.Detik,
+		Channel:  "news",
+		Headline: "Banjir rob rendam pesisir Demak",
+	}
+
+	art.Terms = idnlp.Terms(art.Headline)
+
+	return art
+}
+
+// newTestDispatcher creates a dispatcher with a clock
+// the test moves
+func newTestDispatcher(repo Repository) (*Dispatcher, *time.Time) {
+	now := time.Date(2024, 8, 1, 7, 0, 0, 0, time.UTC)
+	disp := NewDispatcher(repo).WithBackoff(time.Minute, 10*time.Minute)
+	disp.now = func() time.Time { return now }
+
+	return disp, &now
+}
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	sig := Sign("secret", 1722495600, body)
+
+	if !Verify("secret", sig, 1722495600, body) {
+		t.Error("expected the signature is verified")
+	}
+
+	if Verify("other", sig, 1722495600, body) {
+		t.Error("expected the signature of other secret is rejected")
+	}
+
+	if Verify("secret", sig, 1722495601, body) {
+		t.Error("expected the signature of other timestamp is rejected")
+	}
+
+	if Verify("secret", sig, 1722495600, []byte(`{"id":"2"}`)) {
+		t.Error("expected the signature of other body is rejected")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	art := testArticle()
+
+	tests := []struct {
+		ep    models.WebhookEndpoint
+		match bool
+	}{
+		{models.WebhookEndpoint{}, true},
+		{models.WebhookEndpoint{Sources: []string{models.Liputan6, models.Detik}}, true},
+		{models.WebhookEndpoint{Sources: []string{models.Liputan6}}, false},
+		{models.WebhookEndpoint{Channels: []string{"news"}}, true},
+		{models.WebhookEndpoint{Channels: []string{"finance"}}, false},
+		{models.WebhookEndpoint{Keywords: []string{"banjir rob"}}, true},
+		{models.WebhookEndpoint{Keywords: []string{"banjir bandang"}}, false},
+		{models.WebhookEndpoint{Keywords: []string{"gempa", "Demak"}}, true},
+		{models.WebhookEndpoint{Sources: []string{models.Detik}, Keywords: []string{"gempa"}}, false},
+	}
+
+	for _, tt := range tests {
+		if Match(tt.ep, art) != tt.match {
+			t.Errorf("expected %+v match to be %v", tt.ep, tt.match)
+		}
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	repo := newMemRepo(
+		models.WebhookEndpoint{ID: "all"},
+		models.WebhookEndpoint{ID: "liputan6", Sources: []string{models.Liputan6}},
+		models.WebhookEndpoint{ID: "banjir", Keywords: []string{"banjir"}},
+	)
+
+	disp, _ := newTestDispatcher(repo)
+
+	n, err := disp.Enqueue(context.Background(), "created", testArticle())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 {
+		t.Fatalf("expected 2 deliveries, got %d", n)
+	}
+
+	var endpoints []string
+	for _, delivery := range repo.deliveries {
+		endpoints = append(endpoints, delivery.EndpointID)
+
+		if delivery.Status != models.WebhookPending || delivery.ArticleID != "art-1" || delivery.Event != "created" {
+			t.Errorf("unexpected delivery %+v", delivery)
+		}
+	}
+
+	slices.Sort(endpoints)
+	if !slices.Equal(endpoints, []string{"all", "banjir"}) {
+		t.Errorf("expected deliveries to all and banjir, got %v", endpoints)
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	rcv := &receiver{t: t, secret: "s3cret"}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	repo := newMemRepo(models.WebhookEndpoint{ID: "ep", URL: srv.URL, Secret: "s3cret"})
+	disp, _ := newTestDispatcher(repo)
+
+	ctx := context.Background()
+	if _, err := disp.Enqueue(ctx, "created", testArticle()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := disp.deliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	delivery := repo.only(t)
+	if delivery.Status != models.WebhookDelivered || delivery.Attempts != 1 || delivery.LastStatusCode != http.StatusOK {
+		t.Errorf("unexpected delivery %+v", delivery)
+	}
+
+	if len(rcv.payloads) != 1 {
+		t.Fatalf("expected 1 payload, got %d", len(rcv.payloads))
+	}
+
+	payload := rcv.payloads[0]
+	if payload.ID != delivery.ID || payload.Event != "created" || payload.Article.ID != "art-1" || payload.Article.Headline != testArticle().Headline {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+
+	// the delivered delivery is not attempted again
+	if err := disp.deliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rcv.payloads) != 1 {
+		t.Errorf("expected 1 payload, got %d", len(rcv.payloads))
+	}
+}
+
+func TestRetry(t *testing.T) {
+	rcv := &receiver{t: t, secret: "s3cret", statuses: []int{http.StatusInternalServerError, http.StatusServiceUnavailable}}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	repo := newMemRepo(models.WebhookEndpoint{ID: "ep", URL: srv.URL, Secret: "s3cret"})
+	disp, now := newTestDispatcher(repo)
+
+	ctx := context.Background()
+	if _, err := disp.Enqueue(ctx, "updated", testArticle()); err != nil {
+		t.Fatal(err)
+	}
+
+	start := *now
+
+	// the delays is 1m then 2m
+	steps := []struct {
+		after    time.Duration
+		attempts int
+		status   string
+		next     time.Duration
+	}{
+		{0, 1, models.WebhookPending, time.Minute},
+		{30 * time.Second, 1, models.WebhookPending, time.Minute},
+		{time.Minute, 2, models.WebhookPending, 3 * time.Minute},
+		{3 * time.Minute, 3, models.WebhookDelivered, 0},
+	}
+
+	for _, step := range steps {
+		*now = start.Add(step.after)
+		if err := disp.deliverDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		delivery := repo.only(t)
+		if delivery.Attempts != step.attempts || delivery.Status != step.status {
+			t.Fatalf("after %s, expected %d attempts and %s, got %d and %s", step.after, step.attempts, step.status, delivery.Attempts, delivery.Status)
+		}
+
+		if step.next != 0 && !delivery.NextAttemptAt.Equal(start.Add(step.next)) {
+			t.Errorf("after %s, expected next attempt at %s, got %s", step.after, start.Add(step.next), delivery.NextAttemptAt)
+		}
+	}
+
+	// every attempt is the same delivery
+	for _, payload := range rcv.payloads {
+		if payload.ID != rcv.payloads[0].ID {
+			t.Errorf("expected delivery ID %s, got %s", rcv.payloads[0].ID, payload.ID)
+		}
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	defer srv.Close()
+
+	repo := newMemRepo(models.WebhookEndpoint{ID: "ep", URL: srv.URL, Secret: "s3cret"})
+	disp, now := newTestDispatcher(repo)
+	disp.WithMaxAttempts(3)
+
+	ctx := context.Background()
+	if _, err := disp.Enqueue(ctx, "created", testArticle()); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := disp.deliverDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		*now = now.Add(time.Hour)
+	}
+
+	delivery := repo.only(t)
+	if delivery.Status != models.WebhookDead || delivery.Attempts != 3 {
+		t.Errorf("expected dead after 3 attempts, got %s after %d", delivery.Status, delivery.Attempts)
+	}
+
+	if delivery.LastStatusCode != http.StatusBadGateway || delivery.LastError == "" {
+		t.Errorf("expected the last error to be kept, got %d %q", delivery.LastStatusCode, delivery.LastError)
+	}
+}
+
+func TestRemovedEndpoint(t *testing.T) {
+	repo := newMemRepo(models.WebhookEndpoint{ID: "ep", URL: "http://127.0.0.1:0"})
+	disp, _ := newTestDispatcher(repo)
+
+	ctx := context.Background()
+	if _, err := disp.Enqueue(ctx, "created", testArticle()); err != nil {
+		t.Fatal(err)
+	}
+
+	repo.endpoints = nil
+	if err := disp.deliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if delivery := repo.only(t); delivery.Status != models.WebhookDead || delivery.Attempts != 0 {
+		t.Errorf("expected dead without attempt, got %s after %d", delivery.Status, delivery.Attempts)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	disp := NewDispatcher(nil)
+
+	tests := []struct {
+		attempts int
+		delay    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{50, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if delay := disp.retryDelay(tt.attempts); delay != tt.delay {
+			t.Errorf("expected delay after %d attempts to be %s, got %s", tt.attempts, tt.delay, delay)
+		}
+	}
+}
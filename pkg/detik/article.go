@@ -3,13 +3,14 @@ package detik
 import (
 	"bytes"
 	"context"
-	"encoding/json"
-	"regexp"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/tamboto2000/ivosight-crawler/pkg/htmlutil"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idtime"
+	"github.com/tamboto2000/ivosight-crawler/pkg/metadata"
 	"golang.org/x/net/html"
 )
 
@@ -21,31 +22,6 @@ const (
 	VideoArticle         ArticleType = "video"
 )
 
-type articleJsonScript struct {
-	Headline string `json:"headline"`
-	Image    struct {
-		URL             string `json:"url"`
-		ContentLocation string `json:"contentLocation"`
-	} `json:"image"`
-	Video struct {
-		Name         string `json:"name"`
-		Description  string `json:"description"`
-		ThumbnailURL string `json:"thumbnailUrl"`
-		Duration     string `json:"duration"`
-		Keywords     string `json:"keywords"`
-		URL          string `json:"url"`
-		ContentURL   string `json:"contentUrl"`
-		EmbedURL     string `json:"embedUrl"`
-	} `json:"video"`
-	DatePublished time.Time `json:"datePublished"`
-	DateModified  time.Time `json:"dateModified"`
-	Author        struct {
-		Type string `json:"@type"`
-		Name string `json:"name"`
-	} `json:"author"`
-	Description string `json:"description"`
-}
-
 type ArticleContentType string
 
 const (
@@ -85,6 +61,13 @@ type Article struct {
 	UpdatedAt       time.Time
 	Contents        []ArticleContent
 	RelatedArticles []RelatedArticle
+	Tags            []Tag
+	// Categories is the category path of the article, taken
+	// from the page breadcrumb
+	Categories []string
+	// Pages is how many pages the article is split into
+	Pages    int
+	Metadata metadata.Metadata
 }
 
 type ArticleContent struct {
@@ -92,13 +75,26 @@ type ArticleContent struct {
 	Data any
 }
 
+// String gets the sanitized HTML of a text block
 func (art ArticleContent) String() string {
-	str, ok := art.Data.(string)
+	switch data := art.Data.(type) {
+	case string:
+		return data
+
+	case htmlutil.ContentText:
+		return data.HTML
+	}
+
+	panic("not a string")
+}
+
+func (art ArticleContent) ContentText() htmlutil.ContentText {
+	text, ok := art.Data.(htmlutil.ContentText)
 	if !ok {
-		panic("not a string")
+		panic("not a text")
 	}
 
-	return str
+	return text
 }
 
 func (art ArticleContent) ContentImage() ContentImage {
@@ -129,10 +125,46 @@ type RelatedArticle struct {
 	ArticleLink string
 }
 
+type Tag struct {
+	Name string
+	Link string
+}
+
+// maxPages is the most pages of an article followed, in case
+// the "next" links of a broken pager never ends
+const maxPages = 50
+
+// Selectors of the article page blocks
+var (
+	mainContentSel          = htmlutil.MustCompile("div.detail__body-text")
+	headlineImageSel        = htmlutil.MustCompile("figure.detail__media-image")
+	referencedArticleSel    = htmlutil.MustCompile("div.lihatjg")
+	relatedArticleListSel   = htmlutil.MustCompile("div#bt_tkt")
+	imageSel                = htmlutil.MustCompile("div.pic_artikel_sisip")
+	newsFotoMainContentSel  = htmlutil.MustCompile("article.detail")
+	newsFotoDetailHeaderSel = htmlutil.MustCompile("div.detail__header")
+	newsFotoImageContentSel = htmlutil.MustCompile("div#slider-foto__detail")
+	authorMetaSel           = htmlutil.MustCompile("meta[name=author]")
+	videoDurationMetaSel    = htmlutil.MustCompile("meta[name=duration]")
+	newsVideoMainContentSel = htmlutil.MustCompile("div.detail")
+	articleTypeMetaSel      = htmlutil.MustCompile("meta[name=articletype]")
+	tagListSel              = htmlutil.MustCompile("div.detail__body-tag")
+	pageNavSel              = htmlutil.MustCompile("div.detail__anchor-numb, div.detail__long-nav")
+	publishDateSel          = htmlutil.MustCompile("meta[name=publishdate], div.detail__date")
+)
+
 type newsArticleParser struct {
-	art                Article
-	isJsonScriptParsed bool
-	contVid            ContentVideo
+	art     Article
+	contVid ContentVideo
+	// page is the number of the page being parsed, and pageLink
+	// is its link, the page navigation links is resolved with it
+	page     int
+	pageLink string
+	// nextLink is the "next" link of the page navigation, and
+	// nextNumLink is the link numbered page+1, used when the
+	// navigation has no "next" link
+	nextLink    string
+	nextNumLink string
 }
 
 func (nap *newsArticleParser) parseArticle(ctx context.Context, dtk *Detik, link string) (Article, error) {
@@ -142,11 +174,69 @@ func (nap *newsArticleParser) parseArticle(ctx context.Context, dtk *Detik, link
 	}
 
 	nap.art.Link = link
+	nap.page = 1
+	nap.pageLink = link
+	nap.art.Metadata = metadata.Extract(node)
+	nap.applyMetadata()
 
 	htmlutil.WalkSkipNodes(node, nap.walkNodesNewsArticle)
+
+	nap.art.Categories = nap.art.Metadata.Categories()
+
+	if nap.art.Type == MultiplePhotoArticle && nap.art.PublishedFrom == "" {
+		if art := nap.art.Metadata.Article; art != nil && len(art.Images) != 0 {
+			nap.art.PublishedFrom = art.Images[0].ContentLocation
+		}
+	}
+
+	// Long articles are split into several pages, the next page is
+	// followed until there is none and its contents is appended to
+	// the contents of the first page. The pager might only list some
+	// of the page numbers, like "1 2 3 ... 10", so it is the "next"
+	// link of each page that is followed
+	visited := map[string]bool{link: true}
+	for nap.page < maxPages {
+		next := nap.nextPageLink()
+		if next == "" || visited[next] {
+			break
+		}
+
+		visited[next] = true
+
+		node, err := dtk.commonReq(ctx, next)
+		if err != nil {
+			return Article{}, err
+		}
+
+		nap.page++
+		nap.pageLink = next
+		nap.nextLink = ""
+		nap.nextNumLink = ""
+		htmlutil.WalkSkipNodes(node, nap.walkNodesNextPage)
+	}
+
+	nap.art.Pages = nap.page
+
 	return nap.art, nil
 }
 
+// walkNodesNextPage walks the second page onward of a multi-page
+// article. Only the main content is parsed as everything else
+// is already parsed from the first page
+func (nap *newsArticleParser) walkNodesNextPage(node *html.Node) (bool, bool) {
+	if nap.isMainContent(node) {
+		nap.parseMainContent(node)
+		return false, true
+	}
+
+	if nap.isPageNav(node) {
+		nap.parsePageNav(node)
+		return false, true
+	}
+
+	return true, true
+}
+
 func (nap *newsArticleParser) walkNodesNewsArticle(node *html.Node) (bool, bool) {
 	if nap.isArticleTypeMeta(node) {
 		nap.parseArticleType(node)
@@ -158,6 +248,11 @@ func (nap *newsArticleParser) walkNodesNewsArticle(node *html.Node) (bool, bool)
 		return false, true
 	}
 
+	if nap.isPublishDate(node) {
+		nap.parsePublishDate(node)
+		return false, true
+	}
+
 	if nap.art.Type == VideoArticle {
 		if nap.isNewsVideoDuration(node) {
 			nap.parseNewsVideoDuration(node)
@@ -165,11 +260,6 @@ func (nap *newsArticleParser) walkNodesNewsArticle(node *html.Node) (bool, bool)
 		}
 	}
 
-	if nap.isJsonScript(node) {
-		nap.parseJsonScript(node)
-		return false, true
-	}
-
 	switch nap.art.Type {
 	case SinglePageArticle:
 		if nap.isMainContent(node) {
@@ -200,19 +290,21 @@ func (nap *newsArticleParser) walkNodesNewsArticle(node *html.Node) (bool, bool)
 		return false, true
 	}
 
+	if nap.isPageNav(node) {
+		nap.parsePageNav(node)
+		return false, true
+	}
+
+	if nap.isTagList(node) {
+		nap.parseTagList(node)
+		return false, true
+	}
+
 	return true, true
 }
 
 func (nap *newsArticleParser) isMainContent(node *html.Node) bool {
-	if node.Type == html.ElementNode && node.Data == "div" {
-		for _, attr := range node.Attr {
-			if attr.Key == "class" && attr.Val == "detail__body-text itp_bodycontent" {
-				return true
-			}
-		}
-	}
-
-	return false
+	return mainContentSel.IsMatch(node)
 }
 
 func (nap *newsArticleParser) parseMainContent(node *html.Node) {
@@ -253,20 +345,22 @@ func (nap *newsArticleParser) parseMainContent(node *html.Node) {
 			return false, true
 		}
 
+		if nap.isPageNav(node) {
+			nap.parsePageNav(node)
+			return false, true
+		}
+
+		if nap.isTagList(node) {
+			nap.parseTagList(node)
+			return false, true
+		}
+
 		return true, true
 	})
 }
 
 func (nap *newsArticleParser) isHeadlineImageFig(node *html.Node) bool {
-	if node.Type == html.ElementNode && node.Data == "figure" {
-		for _, attr := range node.Attr {
-			if attr.Key == "class" && attr.Val == "detail__media-image" {
-				return true
-			}
-		}
-	}
-
-	return false
+	return headlineImageSel.IsMatch(node)
 }
 
 func (nap *newsArticleParser) parseHeadlineImage(node *html.Node) {
@@ -313,64 +407,31 @@ func (nap *newsArticleParser) isNodeParagraph(node *html.Node) bool {
 	return false
 }
 
-func (nap *newsArticleParser) parseParagraph(node *html.Node) {
-	artType := ParagraphText
-	var paragraph string
-	var data any
-
-	htmlutil.WalkSkipNodes(node, func(node *html.Node) (bool, bool) {
-		if node.Type == html.TextNode {
-			paragraph += node.Data
-			return false, true
-		}
-
-		if node.Type == html.ElementNode {
-			if node.Data == "p" {
-				return true, true
-			}
-
-			if node.Data == "a" {
-				for _, attr := range node.Attr {
-					if attr.Key == "href" {
-						data = ContentVideo{
-							EmbeddedURL: attr.Val,
-						}
-					}
+var embeddedVideoSel = htmlutil.MustCompile("a.embed.video20detik")
 
-					// embedded video
-					if attr.Key == "class" && attr.Val == "embed video20detik" {
-						artType = Video
-						break
-					}
-				}
-
-				if artType != ParagraphText {
-					return false, false
-				}
+func (nap *newsArticleParser) parseParagraph(node *html.Node) {
+	// embedded video is put inside a paragraph
+	if embed := embeddedVideoSel.SelectOne(node); embed != nil {
+		var vid ContentVideo
+		for _, attr := range embed.Attr {
+			if attr.Key == "href" {
+				vid.EmbeddedURL = attr.Val
 			}
-
-			var buff bytes.Buffer
-			html.Render(&buff, node)
-
-			paragraph += buff.String()
-
-			return false, true
 		}
 
-		return true, true
-	})
-
-	if paragraph != "" && artType == ParagraphText {
 		nap.art.Contents = append(nap.art.Contents, ArticleContent{
-			Type: ParagraphText,
-			Data: paragraph,
+			Type: Video,
+			Data: vid,
 		})
+
+		return
 	}
 
-	if artType == Video {
+	paragraph := htmlutil.NewContentText(node)
+	if paragraph.Text != "" {
 		nap.art.Contents = append(nap.art.Contents, ArticleContent{
-			Type: Video,
-			Data: data,
+			Type: ParagraphText,
+			Data: paragraph,
 		})
 	}
 }
@@ -384,25 +445,10 @@ func (nap *newsArticleParser) isSectionTitle(node *html.Node) bool {
 }
 
 func (nap *newsArticleParser) parseSectionTitle(node *html.Node) {
-	var sectionTitle string
-
-	htmlutil.WalkNodes(node, func(node *html.Node) bool {
-		if node.Type == html.ElementNode {
-			if node.Data == "h2" {
-				return true
-			}
-
-			var buff bytes.Buffer
-			html.Render(&buff, node)
-			sectionTitle += buff.String()
-		}
-
-		if node.Type == html.TextNode {
-			sectionTitle += node.Data
-		}
-
-		return true
-	})
+	sectionTitle := htmlutil.NewContentText(node)
+	if sectionTitle.Text == "" {
+		return
+	}
 
 	nap.art.Contents = append(nap.art.Contents, ArticleContent{
 		Type: SectionTitle,
@@ -411,15 +457,7 @@ func (nap *newsArticleParser) parseSectionTitle(node *html.Node) {
 }
 
 func (nap *newsArticleParser) isNodeReferencedArticle(node *html.Node) bool {
-	if node.Type == html.ElementNode && node.Data == "div" {
-		for _, attr := range node.Attr {
-			if attr.Key == "class" && attr.Val == "lihatjg" {
-				return true
-			}
-		}
-	}
-
-	return false
+	return referencedArticleSel.IsMatch(node)
 }
 
 func (nap *newsArticleParser) parseReferencedArticle(node *html.Node) {
@@ -460,78 +498,34 @@ func (nap *newsArticleParser) isStrongTag(node *html.Node) bool {
 	return false
 }
 
-func (nap *newsArticleParser) isJsonScript(node *html.Node) bool {
-	if node.Type == html.ElementNode && node.Data == "script" {
-		for _, attr := range node.Attr {
-			if attr.Key == "type" && attr.Val == "application/ld+json" {
-				return true
-			}
-		}
-	}
-
-	return false
-}
-
-var newsartJsonRgx = regexp.MustCompile(`"@type"\s?:\s?"NewsArticle"`)
-
-func (nap *newsArticleParser) parseJsonScript(node *html.Node) {
-	if nap.isJsonScriptParsed {
+// applyMetadata applies the JSON-LD NewsArticle metadata to the
+// article. The video is only kept for now, as it will be appended
+// to the contents when the main content of video article is parsed
+func (nap *newsArticleParser) applyMetadata() {
+	art := nap.art.Metadata.Article
+	if art == nil {
 		return
 	}
 
-	var script string
-	isTypeArticle := false
+	nap.art.Headline = art.Headline
+	nap.art.Description = art.Description
+	nap.art.PublishedAt = art.DatePublished
+	nap.art.UpdatedAt = art.DateModified
 
-	htmlutil.WalkNodes(node, func(node *html.Node) bool {
-		if node.Type == html.TextNode {
-			script += node.Data
-			if newsartJsonRgx.MatchString(node.Data) {
-				isTypeArticle = true
-			}
+	if vid := art.Video; vid != nil {
+		nap.contVid = ContentVideo{
+			Title:        vid.Name,
+			Description:  vid.Description,
+			ThumbnailURL: vid.ThumbnailURL,
+			URL:          vid.ContentURL,
+			EmbeddedURL:  vid.EmbedURL,
+			Duration:     vid.Duration,
 		}
-
-		return true
-	})
-
-	if isTypeArticle {
-		var obj articleJsonScript
-		json.Unmarshal([]byte(script), &obj)
-
-		nap.art.Headline = obj.Headline
-		nap.art.Description = obj.Description
-		nap.art.PublishedAt = obj.DatePublished
-		nap.art.UpdatedAt = obj.DateModified
-
-		if nap.art.Type == MultiplePhotoArticle {
-			nap.art.PublishedFrom = obj.Image.ContentLocation
-		}
-
-		if nap.art.Type == VideoArticle {
-			vid := obj.Video
-			nap.contVid = ContentVideo{
-				Title:        vid.Name,
-				Description:  vid.Description,
-				ThumbnailURL: vid.ThumbnailURL,
-				URL:          vid.ContentURL,
-				EmbeddedURL:  vid.EmbedURL,
-				Duration:     nap.contVid.Duration,
-			}
-		}
-
-		nap.isJsonScriptParsed = true
 	}
 }
 
 func (nap *newsArticleParser) isRelatedArticleList(node *html.Node) bool {
-	if node.Type == html.ElementNode && node.Data == "div" {
-		for _, attr := range node.Attr {
-			if attr.Key == "id" && attr.Val == "bt_tkt" {
-				return true
-			}
-		}
-	}
-
-	return false
+	return relatedArticleListSel.IsMatch(node)
 }
 
 func (nap *newsArticleParser) parseRelatedArticleList(node *html.Node) {
@@ -567,15 +561,7 @@ func (nap *newsArticleParser) parseRelatedArticleList(node *html.Node) {
 }
 
 func (nap *newsArticleParser) isImage(node *html.Node) bool {
-	if node.Type == html.ElementNode && node.Data == "div" {
-		for _, attr := range node.Attr {
-			if attr.Key == "class" && attr.Val == "pic_artikel_sisip" {
-				return true
-			}
-		}
-	}
-
-	return false
+	return imageSel.IsMatch(node)
 }
 
 func (nap *newsArticleParser) parseImage(node *html.Node) {
@@ -624,17 +610,7 @@ func (nap *newsArticleParser) parseImage(node *html.Node) {
 }
 
 func (nap *newsArticleParser) isNewsFotoMainContent(node *html.Node) bool {
-	if node.Type != html.ElementNode && node.Data != "article" {
-		return false
-	}
-
-	for _, attr := range node.Attr {
-		if attr.Key == "class" && attr.Val == "detail" {
-			return true
-		}
-	}
-
-	return false
+	return newsFotoMainContentSel.IsMatch(node)
 }
 
 func (nap *newsArticleParser) parseNewsFotoMainContent(node *html.Node) {
@@ -659,17 +635,7 @@ func (nap *newsArticleParser) parseNewsFotoMainContent(node *html.Node) {
 }
 
 func (nap *newsArticleParser) isNewsFotoDetailHeader(node *html.Node) bool {
-	if node.Type != html.ElementNode && node.Data != "div" {
-		return false
-	}
-
-	for _, attr := range node.Attr {
-		if attr.Key == "class" && attr.Val == "detail__header" {
-			return true
-		}
-	}
-
-	return false
+	return newsFotoDetailHeaderSel.IsMatch(node)
 }
 
 func (nap *newsArticleParser) parseNewsFotoDetailHeader(node *html.Node) {
@@ -696,17 +662,7 @@ func (nap *newsArticleParser) parseNewsFotoDetailHeader(node *html.Node) {
 }
 
 func (nap *newsArticleParser) isNewsFotoImageContent(node *html.Node) bool {
-	if node.Type != html.ElementNode && node.Data != "div" {
-		return false
-	}
-
-	for _, attr := range node.Attr {
-		if attr.Key == "id" && attr.Val == "slider-foto__detail" {
-			return true
-		}
-	}
-
-	return false
+	return newsFotoImageContentSel.IsMatch(node)
 }
 
 func (nap *newsArticleParser) parseNewsFotoImageContent(node *html.Node) {
@@ -790,15 +746,7 @@ func (nap *newsArticleParser) parseNewsFotoImageContent(node *html.Node) {
 }
 
 func (nap *newsArticleParser) isAuthor(node *html.Node) bool {
-	if node.Type == html.ElementNode && node.Data == "meta" {
-		for _, attr := range node.Attr {
-			if attr.Key == "name" && attr.Val == "author" {
-				return true
-			}
-		}
-	}
-
-	return false
+	return authorMetaSel.IsMatch(node)
 }
 
 func (nap *newsArticleParser) parseAuthor(node *html.Node) {
@@ -810,16 +758,34 @@ func (nap *newsArticleParser) parseAuthor(node *html.Node) {
 	}
 }
 
-func (nap *newsArticleParser) isNewsVideoDuration(node *html.Node) bool {
-	if node.Type == html.ElementNode && node.Data == "meta" {
+func (nap *newsArticleParser) isPublishDate(node *html.Node) bool {
+	return publishDateSel.IsMatch(node)
+}
+
+// parsePublishDate parses the publish date shown on the page, like
+// "Senin, 12 Agu 2024 10:30 WIB". It is only a fallback for the
+// pages without JSON-LD date
+func (nap *newsArticleParser) parsePublishDate(node *html.Node) {
+	if !nap.art.PublishedAt.IsZero() {
+		return
+	}
+
+	str := htmlutil.Text(node)
+	if node.Data == "meta" {
 		for _, attr := range node.Attr {
-			if attr.Key == "name" && attr.Val == "duration" {
-				return true
+			if attr.Key == "content" {
+				str = attr.Val
 			}
 		}
 	}
 
-	return false
+	if t, err := idtime.Parse(str); err == nil {
+		nap.art.PublishedAt = t
+	}
+}
+
+func (nap *newsArticleParser) isNewsVideoDuration(node *html.Node) bool {
+	return videoDurationMetaSel.IsMatch(node)
 }
 
 func (nap *newsArticleParser) parseNewsVideoDuration(node *html.Node) {
@@ -832,15 +798,7 @@ func (nap *newsArticleParser) parseNewsVideoDuration(node *html.Node) {
 }
 
 func (nap *newsArticleParser) isNewsVideoMainContent(node *html.Node) bool {
-	if node.Type == html.ElementNode && node.Data == "div" {
-		for _, attr := range node.Attr {
-			if attr.Key == "class" && attr.Val == "detail" {
-				return true
-			}
-		}
-	}
-
-	return false
+	return newsVideoMainContentSel.IsMatch(node)
 }
 
 func (nap *newsArticleParser) parseNewsVideoMainContent(node *html.Node) {
@@ -862,15 +820,7 @@ func (nap *newsArticleParser) parseNewsVideoMainContent(node *html.Node) {
 }
 
 func (nap *newsArticleParser) isArticleTypeMeta(node *html.Node) bool {
-	if node.Type == html.ElementNode && node.Data == "meta" {
-		for _, attr := range node.Attr {
-			if attr.Key == "name" && attr.Val == "articletype" {
-				return true
-			}
-		}
-	}
-
-	return false
+	return articleTypeMetaSel.IsMatch(node)
 }
 
 func (nap *newsArticleParser) parseArticleType(node *html.Node) {
@@ -881,3 +831,133 @@ func (nap *newsArticleParser) parseArticleType(node *html.Node) {
 		}
 	}
 }
+
+func (nap *newsArticleParser) isTagList(node *html.Node) bool {
+	return tagListSel.IsMatch(node)
+}
+
+func (nap *newsArticleParser) parseTagList(node *html.Node) {
+	htmlutil.WalkSkipNodes(node, func(node *html.Node) (bool, bool) {
+		if node.Type != html.ElementNode || node.Data != "a" {
+			return true, true
+		}
+
+		var tag Tag
+		for _, attr := range node.Attr {
+			if attr.Key == "href" {
+				tag.Link = attr.Val
+			}
+		}
+
+		htmlutil.WalkNodes(node, func(node *html.Node) bool {
+			if node.Type == html.TextNode {
+				tag.Name += node.Data
+			}
+
+			return true
+		})
+
+		tag.Name = strings.TrimSpace(tag.Name)
+		if tag.Name != "" {
+			nap.art.Tags = append(nap.art.Tags, tag)
+		}
+
+		return false, true
+	})
+}
+
+func (nap *newsArticleParser) isPageNav(node *html.Node) bool {
+	return pageNavSel.IsMatch(node)
+}
+
+func (nap *newsArticleParser) parsePageNav(node *html.Node) {
+	base, err := url.Parse(nap.pageLink)
+	if err != nil {
+		return
+	}
+
+	htmlutil.WalkSkipNodes(node, func(node *html.Node) (bool, bool) {
+		if node.Type != html.ElementNode || node.Data != "a" {
+			return true, true
+		}
+
+		var href, rel, class string
+		for _, attr := range node.Attr {
+			switch attr.Key {
+			case "href":
+				href = strings.TrimSpace(attr.Val)
+
+			case "rel":
+				rel = attr.Val
+
+			case "class":
+				class = attr.Val
+			}
+		}
+
+		ref, err := url.Parse(href)
+		if href == "" || err != nil {
+			return false, true
+		}
+
+		// the page links is usually relative, like ?page=2
+		linkURL := base.ResolveReference(ref)
+		linkURL.Fragment = ""
+		if linkURL.Scheme != "http" && linkURL.Scheme != "https" {
+			return false, true
+		}
+
+		link := linkURL.String()
+		if link == nap.pageLink {
+			return false, true
+		}
+
+		text := strings.ToLower(strings.TrimSpace(htmlutil.Text(node)))
+		if isNextPageLink(text, rel, class) {
+			if nap.nextLink == "" {
+				nap.nextLink = link
+			}
+
+			return false, true
+		}
+
+		if page, err := strconv.Atoi(text); err == nil && page == nap.page+1 && nap.nextNumLink == "" {
+			nap.nextNumLink = link
+		}
+
+		return false, true
+	})
+}
+
+// nextPageLink returns the link of the page after the
+// parsed page, empty when it is the last page
+func (nap *newsArticleParser) nextPageLink() string {
+	if nap.nextLink != "" {
+		return nap.nextLink
+	}
+
+	return nap.nextNumLink
+}
+
+// isNextPageLink reports whether the page navigation link with
+// the lowercased text, rel and class is the "next" link
+func isNextPageLink(text, rel, class string) bool {
+	for _, tok := range strings.Fields(rel) {
+		if strings.EqualFold(tok, "next") {
+			return true
+		}
+	}
+
+	for _, tok := range strings.Fields(class) {
+		if strings.Contains(strings.ToLower(tok), "next") {
+			return true
+		}
+	}
+
+	switch text {
+	case "next", "selanjutnya", "berikutnya", "halaman selanjutnya", "halaman berikutnya", ">", "›", "»":
+		return true
+	}
+
+	return false
+}
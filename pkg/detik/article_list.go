@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/tamboto2000/ivosight-crawler/pkg/htmlutil"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idtime"
 	"golang.org/x/net/html"
 )
 
@@ -198,7 +199,11 @@ func (nif *newsItemFinder) parseDatetimeUnix(node *html.Node) bool {
 				unix, err := strconv.ParseInt(attr.Val, 10, 64)
 				if err != nil {
 					// We got the timestamp, but not a valid one,
-					// the news article would be still valid
+					// the news article would be still valid. The
+					// date is taken from the title, which is like
+					// "Senin, 12 Agu 2024 10:30 WIB", or the text,
+					// which is like "2 jam yang lalu"
+					nif.parseDatetimeText(node)
 					return true
 				}
 
@@ -213,6 +218,21 @@ func (nif *newsItemFinder) parseDatetimeUnix(node *html.Node) bool {
 	return false
 }
 
+func (nif *newsItemFinder) parseDatetimeText(node *html.Node) {
+	for _, attr := range node.Attr {
+		if attr.Key == "title" {
+			if t, err := idtime.Parse(attr.Val); err == nil {
+				nif.onProgress.PublishedAt = t
+				return
+			}
+		}
+	}
+
+	if t, err := idtime.Parse(htmlutil.Text(node)); err == nil {
+		nif.onProgress.PublishedAt = t
+	}
+}
+
 func (nif *newsItemFinder) walkNodes(node *html.Node) bool {
 	switch nif.state {
 	case newsItemFinderStateFindArticle:
@@ -9,9 +9,14 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/tamboto2000/ivosight-crawler/pkg/httpstatus"
 	"golang.org/x/net/html"
 )
 
+// ErrNotFound is returned when the page is responded with 404 or 410
+// status, which usually means the article is deleted
+var ErrNotFound = errors.New("news not found")
+
 type Detik struct {
 	cl *http.Client
 }
@@ -69,6 +74,12 @@ func (dtk *Detik) commonReq(ctx context.Context, url string) (*html.Node, error)
 
 	defer res.Body.Close()
 
+	// the error page might not be gzipped
+	switch res.StatusCode {
+	case http.StatusNotFound, http.StatusGone:
+		return nil, fmt.Errorf("%w: %w", ErrNotFound, &httpstatus.Error{StatusCode: res.StatusCode})
+	}
+
 	gzipread, err := gzip.NewReader(res.Body)
 	if err != nil {
 		return nil, err
@@ -110,10 +121,6 @@ func (dtk *Detik) commonReq(ctx context.Context, url string) (*html.Node, error)
 	// -- TESTING --
 
 	if res.StatusCode != 200 {
-		if res.StatusCode == http.StatusNotFound {
-			return nil, errors.New("news not found")
-		}
-
 		body, err := io.ReadAll(gzipread)
 		if err != nil {
 			return nil, err
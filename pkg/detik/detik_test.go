@@ -0,0 +1,96 @@
+package detik
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+)
+
+const multiPagePath = "/berita/d-7461234/jalan-panjang-tol-semarang-demak"
+
+// newFixtureServer serves the pages of the multi-page article from
+// testdata, gzipped like detik does
+func newFixtureServer(t *testing.T, requests map[string]int) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.RequestURI()]++
+
+		page := r.URL.Query().Get("page")
+		if page == "" {
+			page = "1"
+		}
+
+		body, err := os.ReadFile("testdata/multipage-" + page + ".html")
+		if r.URL.Path != multiPagePath || err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+		gz := gzip.NewWriter(w)
+		gz.Write(body)
+		gz.Close()
+	}))
+}
+
+func TestArticleFromLinkMultiPage(t *testing.T) {
+	requests := make(map[string]int)
+	ts := newFixtureServer(t, requests)
+	defer ts.Close()
+
+	art, err := NewDetik(ts.Client()).ArticleFromLink(context.Background(), ts.URL+multiPagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if art.Pages != 3 {
+		t.Errorf("expecting 3 pages, got %d", art.Pages)
+	}
+
+	var texts []string
+	for _, content := range art.Contents {
+		texts = append(texts, content.ContentText().Text)
+	}
+
+	expected := []string{
+		"Demak - Tol Semarang-Demak seksi II diresmikan.",
+		"Pembangunan tol dimulai sejak 2020.",
+		"Tanggul Laut",
+		"Tol sekaligus menjadi tanggul laut.",
+		"Tol ditargetkan rampung pada 2027.",
+	}
+
+	if !slices.Equal(texts, expected) {
+		t.Errorf("unexpected contents %q", texts)
+	}
+
+	// the "next" link of the last page is back to the
+	// first page, which is not fetched again
+	for _, uri := range []string{multiPagePath, multiPagePath + "?page=2", multiPagePath + "?page=3"} {
+		if requests[uri] != 1 {
+			t.Errorf("expecting %s fetched once, got %d", uri, requests[uri])
+		}
+	}
+
+	if len(art.Tags) != 1 || art.Tags[0].Name != "tol" {
+		t.Errorf("expecting the tags of the first page, got %+v", art.Tags)
+	}
+}
+
+func TestArticleFromLinkNotFound(t *testing.T) {
+	ts := newFixtureServer(t, make(map[string]int))
+	defer ts.Close()
+
+	_, err := NewDetik(ts.Client()).ArticleFromLink(context.Background(), ts.URL+"/berita/d-1/x")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expecting not found, got %v", err)
+	}
+}
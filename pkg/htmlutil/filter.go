@@ -0,0 +1,175 @@
+package htmlutil
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// FilterFunc is an adapter to use ordinary function as [Filter]
+type FilterFunc func(node *html.Node) bool
+
+func (f FilterFunc) IsMatch(node *html.Node) bool {
+	return f(node)
+}
+
+// And matches node that matches all of the filters
+func And(filters ...Filter) Filter {
+	return FilterFunc(func(node *html.Node) bool {
+		for _, f := range filters {
+			if !f.IsMatch(node) {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// Or matches node that matches any of the filters
+func Or(filters ...Filter) Filter {
+	return FilterFunc(func(node *html.Node) bool {
+		for _, f := range filters {
+			if f.IsMatch(node) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// Not matches node that does not match the filter
+func Not(filter Filter) Filter {
+	return FilterFunc(func(node *html.Node) bool {
+		return !filter.IsMatch(node)
+	})
+}
+
+// Tag matches element with the tag name
+func Tag(name string) Filter {
+	return FilterFunc(func(node *html.Node) bool {
+		return node.Type == html.ElementNode && node.Data == name
+	})
+}
+
+// HasClass matches element that has all of the classes. Unlike
+// matching the class attribute with [DefaultFilter], the order
+// of the classes and the other classes of the element is ignored
+func HasClass(classes ...string) Filter {
+	return FilterFunc(func(node *html.Node) bool {
+		if node.Type != html.ElementNode {
+			return false
+		}
+
+		nodeClasses := strings.Fields(attrVal(node, "class"))
+		for _, class := range classes {
+			if !containsStr(nodeClasses, class) {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// HasAttr matches element that has the attribute, regardless
+// of its value
+func HasAttr(key string) Filter {
+	return FilterFunc(func(node *html.Node) bool {
+		if node.Type != html.ElementNode {
+			return false
+		}
+
+		for _, attr := range node.Attr {
+			if attr.Key == key {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// AttrRegex matches element which attribute value matches rgx
+func AttrRegex(key string, rgx *regexp.Regexp) Filter {
+	return FilterFunc(func(node *html.Node) bool {
+		if node.Type != html.ElementNode {
+			return false
+		}
+
+		for _, attr := range node.Attr {
+			if attr.Key == key && rgx.MatchString(attr.Val) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// AttrPrefix matches element which attribute value starts
+// with prefix
+func AttrPrefix(key, prefix string) Filter {
+	return FilterFunc(func(node *html.Node) bool {
+		if node.Type != html.ElementNode {
+			return false
+		}
+
+		for _, attr := range node.Attr {
+			if attr.Key == key && strings.HasPrefix(attr.Val, prefix) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// TextContains matches node which text content, the text of
+// itself and all of its descendants, contains substr
+func TextContains(substr string) Filter {
+	return FilterFunc(func(node *html.Node) bool {
+		return strings.Contains(textContent(node), substr)
+	})
+}
+
+// ParentMatches matches node which direct parent matches
+// the filter
+func ParentMatches(filter Filter) Filter {
+	return FilterFunc(func(node *html.Node) bool {
+		return node.Parent != nil && filter.IsMatch(node.Parent)
+	})
+}
+
+// HasAncestor matches node which any of its ancestors matches
+// the filter
+func HasAncestor(filter Filter) Filter {
+	return FilterFunc(func(node *html.Node) bool {
+		for p := node.Parent; p != nil; p = p.Parent {
+			if filter.IsMatch(p) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+func textContent(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+
+	var sb strings.Builder
+	WalkNodes(node, func(node *html.Node) bool {
+		if node.Type == html.TextNode {
+			sb.WriteString(node.Data)
+		}
+
+		return true
+	})
+
+	return sb.String()
+}
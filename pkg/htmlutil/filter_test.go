@@ -0,0 +1,62 @@
+package htmlutil
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestFilters(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(selectorHtml))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	body := HasClass("itp_bodycontent", "detail__body-text")
+
+	tests := []struct {
+		name   string
+		filter Filter
+		count  int
+	}{
+		{name: "HasClass ignores class order", filter: body, count: 1},
+		{name: "And", filter: And(Tag("p"), HasAttr("class")), count: 1},
+		{name: "Or", filter: Or(Tag("h2"), Tag("strong")), count: 2},
+		{name: "Not", filter: And(Tag("p"), Not(HasAttr("class"))), count: 3},
+		{name: "AttrRegex", filter: AttrRegex("href", regexp.MustCompile(`/d-\d+$`)), count: 1},
+		{name: "AttrPrefix", filter: AttrPrefix("class", "detail__"), count: 1},
+		{name: "TextContains", filter: And(Tag("p"), TextContains("pemilu")), count: 1},
+		{name: "ParentMatches", filter: And(Tag("a"), ParentMatches(HasClass("lihatjg"))), count: 1},
+		{name: "HasAncestor", filter: And(Tag("a"), HasAncestor(body)), count: 2},
+		{name: "HasAncestor no match", filter: And(Tag("li"), HasAncestor(body)), count: 0},
+	}
+
+	for _, tt := range tests {
+		nodes := FindAllNode(doc, tt.filter)
+		if len(nodes) != tt.count {
+			t.Errorf("%s: expect %d nodes, got %d", tt.name, tt.count, len(nodes))
+		}
+	}
+}
+
+func TestFindAllNodeLimit(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(selectorHtml))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	nodes := FindAllNodeLimit(doc, Tag("li"), 2)
+	if len(nodes) != 2 {
+		t.Fatalf("expect 2 nodes, got %d", len(nodes))
+	}
+
+	if textContent(nodes[1]) != "2" {
+		t.Errorf("expect the first 2 nodes in document order")
+	}
+
+	if nodes := FindAllNodeLimit(doc, Tag("li"), 0); len(nodes) != 5 {
+		t.Errorf("expect all nodes when limit is 0, got %d", len(nodes))
+	}
+}
@@ -0,0 +1,85 @@
+package readability
+
+import (
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/pkg/htmlutil"
+	"github.com/tamboto2000/ivosight-crawler/pkg/metadata"
+)
+
+type ArticleContentType string
+
+const (
+	SectionTitle  ArticleContentType = "section-title"
+	ParagraphText ArticleContentType = "paragraph-text"
+	Image         ArticleContentType = "image"
+)
+
+type ContentImage struct {
+	URL     string
+	Title   string
+	Caption string
+	Alt     string
+	Width   int
+	Height  int
+}
+
+type Author struct {
+	Name string
+	URL  string
+}
+
+type Article struct {
+	// Source is the hostname of the link, without www
+	Source      string
+	Link        string
+	Headline    string
+	Description string
+	Author      Author
+	PublishedAt time.Time
+	UpdatedAt   time.Time
+	// Image is the lead image, taken from the metadata or
+	// the first image of the contents
+	Image    ContentImage
+	Contents []ArticleContent
+	// Categories is the category path of the article, taken
+	// from the page breadcrumb
+	Categories []string
+	WordCount  int
+	Metadata   metadata.Metadata
+}
+
+// Paragraphs gets the plain text of the paragraphs
+func (art Article) Paragraphs() []string {
+	var paragraphs []string
+	for _, content := range art.Contents {
+		if content.Type == ParagraphText {
+			paragraphs = append(paragraphs, content.Text().Text)
+		}
+	}
+
+	return paragraphs
+}
+
+type ArticleContent struct {
+	Type ArticleContentType
+	Data any
+}
+
+func (ct ArticleContent) Text() htmlutil.ContentText {
+	text, ok := ct.Data.(htmlutil.ContentText)
+	if !ok {
+		panic("not a text")
+	}
+
+	return text
+}
+
+func (ct ArticleContent) Image() ContentImage {
+	img, ok := ct.Data.(ContentImage)
+	if !ok {
+		panic("not an image")
+	}
+
+	return img
+}
@@ -0,0 +1,483 @@
+// Package readability provides site-agnostic news article extractor.
+// Instead of relying on the site specific markup, the main content
+// is found by scoring the text density and link density of the page
+// blocks, while the title, date, author and lead image is taken from
+// the page metadata with fallbacks to the page markup.
+package readability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/tamboto2000/ivosight-crawler/pkg/htmlutil"
+	"github.com/tamboto2000/ivosight-crawler/pkg/httpreq"
+	"github.com/tamboto2000/ivosight-crawler/pkg/httpstatus"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idtime"
+	"github.com/tamboto2000/ivosight-crawler/pkg/metadata"
+	"golang.org/x/net/html"
+)
+
+var ErrNoContent = errors.New("no article content is found")
+
+// ArticleFromLink fetches the page and extracts the article
+func ArticleFromLink(ctx context.Context, cl *http.Client, link string) (Article, error) {
+	if cl == nil {
+		cl = http.DefaultClient
+	}
+
+	req, err := httpreq.NewPage(ctx, link)
+	if err != nil {
+		return Article{}, err
+	}
+
+	res, err := cl.Do(req)
+	if err != nil {
+		return Article{}, err
+	}
+
+	defer res.Body.Close()
+
+	if err := httpstatus.Check(res); err != nil {
+		return Article{}, err
+	}
+
+	node, err := html.Parse(res.Body)
+	if err != nil {
+		return Article{}, err
+	}
+
+	return Extract(node, link)
+}
+
+// Extract extracts the article from a parsed page. The link
+// is used to resolve relative URLs and to name the source
+func Extract(node *html.Node, link string) (Article, error) {
+	base, err := url.Parse(link)
+	if err != nil {
+		return Article{}, err
+	}
+
+	ext := extractor{
+		base:   base,
+		scores: make(map[*html.Node]float64),
+	}
+
+	return ext.extract(node)
+}
+
+var (
+	// blocks that is unlikely to be a part of the main content
+	unlikelyRgx = regexp.MustCompile(`(?i)(^|[\s_-])(ads?|advert\w*|banner|breadcrumbs?|comments?|community|footer|header|menu|modal|nav\w*|newsletter|popup|promo|related|share|sidebar|social|sponsor\w*|widget|baca-?juga|lihatjg|tags?)([\s_-]|$)`)
+	// blocks that is likely to be the main content, this
+	// outweighs unlikelyRgx
+	likelyRgx = regexp.MustCompile(`(?i)article|body|content|detail|entry|main|post|read|story|text`)
+)
+
+// skippedTags is never a part of the main content
+var skippedTags = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"nav":      true,
+	"header":   true,
+	"footer":   true,
+	"aside":    true,
+	"form":     true,
+	"iframe":   true,
+	"button":   true,
+	"svg":      true,
+	"select":   true,
+	"template": true,
+}
+
+type extractor struct {
+	base   *url.URL
+	scores map[*html.Node]float64
+	// candidates is the scored nodes in the order it is found,
+	// so the ties of the top candidate is broken the same way
+	// on every run
+	candidates []*html.Node
+	art        Article
+}
+
+func (ext *extractor) extract(node *html.Node) (Article, error) {
+	md := metadata.Extract(node)
+
+	body := htmlutil.FindNode(node, htmlutil.DefaultFilter{
+		Type: html.ElementNode,
+		Data: "body",
+	})
+
+	if body == nil {
+		return Article{}, ErrNoContent
+	}
+
+	ext.scoreParagraphs(body)
+	top := ext.topCandidate()
+	if top == nil {
+		return Article{}, ErrNoContent
+	}
+
+	ext.art.Source = strings.TrimPrefix(ext.base.Hostname(), "www.")
+	ext.art.Link = ext.base.String()
+	ext.parseContents(top)
+
+	if len(ext.art.Contents) == 0 {
+		return Article{}, ErrNoContent
+	}
+
+	ext.applyMetadata(node, md)
+
+	return ext.art, nil
+}
+
+// scoreParagraphs scores every paragraph by its text length and
+// commas, the score is propagated to its parent and grandparent
+// which is the candidates for the main content block
+func (ext *extractor) scoreParagraphs(body *html.Node) {
+	htmlutil.WalkSkipNodes(body, func(node *html.Node) (bool, bool) {
+		if node.Type != html.ElementNode {
+			return false, true
+		}
+
+		if isUnlikely(node) {
+			return false, true
+		}
+
+		if node.Data != "p" && node.Data != "pre" {
+			return true, true
+		}
+
+		text := nodeText(node)
+		if len(text) < 25 {
+			return false, true
+		}
+
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+
+		parent := node.Parent
+		if parent != nil && parent.Type == html.ElementNode {
+			ext.initCandidate(parent)
+			ext.scores[parent] += score
+
+			grandparent := parent.Parent
+			if grandparent != nil && grandparent.Type == html.ElementNode {
+				ext.initCandidate(grandparent)
+				ext.scores[grandparent] += score / 2
+			}
+		}
+
+		return false, true
+	})
+}
+
+func (ext *extractor) initCandidate(node *html.Node) {
+	if _, ok := ext.scores[node]; ok {
+		return
+	}
+
+	var score float64
+	switch node.Data {
+	case "article":
+		score += 10
+	case "div", "section", "main":
+		score += 5
+	case "ol", "ul", "dl", "td", "th", "li":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		score -= 5
+	}
+
+	classID := attrVal(node, "class") + " " + attrVal(node, "id")
+	if likelyRgx.MatchString(classID) {
+		score += 25
+	}
+
+	if unlikelyRgx.MatchString(classID) {
+		score -= 25
+	}
+
+	ext.scores[node] = score
+	ext.candidates = append(ext.candidates, node)
+}
+
+func (ext *extractor) topCandidate() *html.Node {
+	var top *html.Node
+	var topScore float64
+	for _, node := range ext.candidates {
+		score := ext.scores[node] * (1 - linkDensity(node))
+		if top == nil || score > topScore {
+			top = node
+			topScore = score
+		}
+	}
+
+	return top
+}
+
+func (ext *extractor) parseContents(top *html.Node) {
+	htmlutil.WalkSkipNodes(top, func(node *html.Node) (bool, bool) {
+		if node.Type != html.ElementNode {
+			return false, true
+		}
+
+		if node != top && isUnlikely(node) {
+			return false, true
+		}
+
+		switch node.Data {
+		case "h2", "h3", "h4":
+			if text := htmlutil.NewContentText(node); text.Text != "" {
+				ext.appendContent(SectionTitle, text)
+			}
+
+			return false, true
+
+		case "p", "pre", "blockquote":
+			text := htmlutil.NewContentText(node)
+			if text.Text != "" && linkDensity(node) < 0.5 {
+				ext.appendContent(ParagraphText, text)
+			}
+
+			// images can be put inside paragraph
+			for _, img := range htmlutil.FindAllNode(node, htmlutil.DefaultFilter{Type: html.ElementNode, Data: "img"}) {
+				ext.parseImage(img, nil)
+			}
+
+			return false, true
+
+		case "figure":
+			img := htmlutil.FindNode(node, htmlutil.DefaultFilter{Type: html.ElementNode, Data: "img"})
+			if img != nil {
+				ext.parseImage(img, htmlutil.FindNode(node, htmlutil.DefaultFilter{Type: html.ElementNode, Data: "figcaption"}))
+			}
+
+			return false, true
+
+		case "img":
+			ext.parseImage(node, nil)
+			return false, true
+
+		case "div", "span":
+			// some sites put the text directly in div separated
+			// with <br> instead of wrapping it in paragraphs
+			if !hasBlockChild(node) {
+				text := htmlutil.NewContentText(node)
+				if len(text.Text) >= 80 && linkDensity(node) < 0.5 {
+					ext.appendContent(ParagraphText, text)
+				}
+
+				return false, true
+			}
+		}
+
+		return true, true
+	})
+}
+
+func (ext *extractor) parseImage(node *html.Node, caption *html.Node) {
+	src := attrVal(node, "data-src")
+	if src == "" {
+		src = attrVal(node, "src")
+	}
+
+	if src == "" || strings.HasPrefix(src, "data:") {
+		return
+	}
+
+	img := ContentImage{
+		URL:   ext.resolve(src),
+		Title: attrVal(node, "title"),
+		Alt:   attrVal(node, "alt"),
+	}
+
+	fmt.Sscan(attrVal(node, "width"), &img.Width)
+	fmt.Sscan(attrVal(node, "height"), &img.Height)
+
+	if caption != nil {
+		img.Caption = nodeText(caption)
+	}
+
+	ext.appendContent(Image, img)
+}
+
+func (ext *extractor) appendContent(contentType ArticleContentType, data any) {
+	ext.art.Contents = append(ext.art.Contents, ArticleContent{
+		Type: contentType,
+		Data: data,
+	})
+}
+
+// applyMetadata fills the article fields from the page metadata,
+// with fallbacks to the page markup. The rest of the metadata is
+// kept on the article as is
+func (ext *extractor) applyMetadata(node *html.Node, md metadata.Metadata) {
+	art := &ext.art
+
+	art.Headline = md.Title
+	if art.Headline == "" {
+		if h1 := htmlutil.FindNode(node, htmlutil.DefaultFilter{Type: html.ElementNode, Data: "h1"}); h1 != nil {
+			art.Headline = nodeText(h1)
+		}
+	}
+
+	if art.Headline == "" {
+		if title := htmlutil.FindNode(node, htmlutil.DefaultFilter{Type: html.ElementNode, Data: "title"}); title != nil {
+			art.Headline = nodeText(title)
+		}
+	}
+
+	art.Description = md.Description
+	art.Categories = md.Categories()
+	art.WordCount = md.WordCount
+	art.Metadata = md
+
+	art.PublishedAt = md.PublishedAt
+	if art.PublishedAt.IsZero() {
+		if t := htmlutil.FindNode(node, htmlutil.DefaultFilter{Type: html.ElementNode, Data: "time"}); t != nil {
+			// the shown date, like "Senin, 12 Agu 2024 10:30 WIB",
+			// is used when the datetime attribute is missing
+			pt, err := idtime.Parse(attrVal(t, "datetime"))
+			if err != nil {
+				pt, _ = idtime.Parse(nodeText(t))
+			}
+
+			art.PublishedAt = pt
+		}
+	}
+
+	art.UpdatedAt = md.ModifiedAt
+
+	if len(md.Authors) != 0 {
+		art.Author = Author{
+			Name: md.Authors[0].Name,
+			URL:  md.Authors[0].URL,
+		}
+	} else {
+		art.Author.Name = md.Meta.Get("author")
+	}
+
+	art.Image = ContentImage{
+		URL:     md.Image.URL,
+		Caption: md.Image.Caption,
+		Width:   md.Image.Width,
+		Height:  md.Image.Height,
+	}
+
+	// lead image falls back to the first image of the contents
+	if art.Image.URL == "" {
+		for _, content := range art.Contents {
+			if content.Type == Image {
+				art.Image = content.Image()
+				break
+			}
+		}
+	}
+
+	if art.WordCount == 0 {
+		for _, paragraph := range art.Paragraphs() {
+			art.WordCount += len(strings.Fields(paragraph))
+		}
+	}
+}
+
+func (ext *extractor) resolve(ref string) string {
+	u, err := ext.base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+
+	return u.String()
+}
+
+func isUnlikely(node *html.Node) bool {
+	if skippedTags[node.Data] {
+		return true
+	}
+
+	if node.Data == "body" || node.Data == "article" || node.Data == "main" {
+		return false
+	}
+
+	classID := attrVal(node, "class") + " " + attrVal(node, "id")
+	return unlikelyRgx.MatchString(classID) && !likelyRgx.MatchString(classID)
+}
+
+var blockTags = map[string]bool{
+	"p":          true,
+	"div":        true,
+	"section":    true,
+	"article":    true,
+	"table":      true,
+	"ul":         true,
+	"ol":         true,
+	"figure":     true,
+	"blockquote": true,
+	"h1":         true,
+	"h2":         true,
+	"h3":         true,
+	"h4":         true,
+	"pre":        true,
+}
+
+func hasBlockChild(node *html.Node) bool {
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && blockTags[c.Data] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// linkDensity is the ratio of text inside links to all text
+func linkDensity(node *html.Node) float64 {
+	textLen := len(nodeText(node))
+	if textLen == 0 {
+		return 0
+	}
+
+	var linkLen int
+	for _, a := range htmlutil.FindAllNode(node, htmlutil.DefaultFilter{Type: html.ElementNode, Data: "a"}) {
+		linkLen += len(nodeText(a))
+	}
+
+	return float64(linkLen) / float64(textLen)
+}
+
+func attrVal(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+
+	return ""
+}
+
+// nodeText gets the text of the node with the whitespaces collapsed,
+// text of the skipped tags is excluded
+func nodeText(node *html.Node) string {
+	var sb strings.Builder
+	htmlutil.WalkSkipNodes(node, func(node *html.Node) (bool, bool) {
+		if node.Type == html.ElementNode && skippedTags[node.Data] {
+			return false, true
+		}
+
+		if node.Type == html.TextNode {
+			sb.WriteString(node.Data)
+			sb.WriteString(" ")
+		}
+
+		return true, true
+	})
+
+	// the NBSP is split as well
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
@@ -0,0 +1,290 @@
+package readability
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/pkg/htmlutil"
+	"github.com/tamboto2000/ivosight-crawler/pkg/httpstatus"
+	"golang.org/x/net/html"
+)
+
+const articleLink = "https://www.kabarpantura.id/berita/banjir-rob-demak"
+
+func parseFixture(t *testing.T, name string) *html.Node {
+	t.Helper()
+
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	node, err := html.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return node
+}
+
+func extractFixture(t *testing.T, name string) Article {
+	t.Helper()
+
+	art, err := Extract(parseFixture(t, name), articleLink)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return art
+}
+
+// texts gets the text of the content blocks of the type
+func texts(art Article, typ ArticleContentType) []string {
+	var list []string
+	for _, content := range art.Contents {
+		if content.Type == typ {
+			list = append(list, content.Text().Text)
+		}
+	}
+
+	return list
+}
+
+func images(art Article) []ContentImage {
+	var imgs []ContentImage
+	for _, content := range art.Contents {
+		if content.Type == Image {
+			imgs = append(imgs, content.Image())
+		}
+	}
+
+	return imgs
+}
+
+func TestExtractContents(t *testing.T) {
+	art := extractFixture(t, "article.html")
+
+	expected := []string{
+		"Banjir rob kembali merendam ribuan rumah di pesisir Demak, Jawa Tengah, sejak Senin pagi. Air laut pasang setinggi 60 sentimeter masuk ke permukiman warga di empat desa.",
+		"Kepala desa setempat mengatakan, warga sudah mengungsi ke balai desa dan masjid terdekat, sementara sebagian lainnya memilih bertahan di lantai dua rumah mereka.",
+		"Pemerintah kabupaten telah mengirimkan bantuan logistik berupa beras, mi instan, dan air bersih ke posko pengungsian. Petugas juga menyiagakan perahu karet.",
+		"BMKG memperkirakan pasang air laut masih akan terjadi hingga akhir pekan, sehingga warga diminta tetap waspada dan mengikuti arahan petugas di lapangan.",
+	}
+
+	// the sidebar, "baca juga", related posts, comments and
+	// footer is not a part of the contents
+	if paragraphs := texts(art, ParagraphText); !slices.Equal(paragraphs, expected) {
+		t.Errorf("expected paragraphs\n%q\ngot\n%q", expected, paragraphs)
+	}
+
+	if titles := texts(art, SectionTitle); !slices.Equal(titles, []string{"Bantuan logistik"}) {
+		t.Errorf("expected section title Bantuan logistik, got %q", titles)
+	}
+
+	imgs := images(art)
+	if len(imgs) != 1 {
+		t.Fatalf("expected 1 image, got %d", len(imgs))
+	}
+
+	img := imgs[0]
+	if img.URL != "https://www.kabarpantura.id/img/rob-demak.jpg" || img.Caption != "Warga melintasi banjir rob di Sayung, Demak." || img.Width != 800 || img.Height != 450 {
+		t.Errorf("unexpected image %+v", img)
+	}
+}
+
+func TestExtractMetadata(t *testing.T) {
+	art := extractFixture(t, "article.html")
+
+	wib := time.FixedZone("WIB", 7*60*60)
+
+	tests := []struct {
+		field    string
+		got      any
+		expected any
+	}{
+		{"source", art.Source, "kabarpantura.id"},
+		{"link", art.Link, articleLink},
+		// JSON-LD headline is preferred over <h1> and <title>
+		{"headline", art.Headline, "Banjir Rob Rendam Ribuan Rumah di Pesisir Demak"},
+		{"description", art.Description, "Banjir rob kembali merendam pesisir Demak."},
+		{"categories", strings.Join(art.Categories, "|"), "Regional|Jawa Tengah"},
+		{"published at", art.PublishedAt.Equal(time.Date(2024, 8, 12, 10, 30, 0, 0, wib)), true},
+		{"updated at", art.UpdatedAt.Equal(time.Date(2024, 8, 12, 12, 0, 0, 0, wib)), true},
+		// JSON-LD author is preferred over <meta name=author>
+		{"author", art.Author, Author{Name: "Siti Aminah", URL: "https://www.kabarpantura.id/penulis/siti"}},
+		// the rest of the metadata is kept as is
+		{"canonical URL", art.Metadata.CanonicalURL, "https://www.kabarpantura.id/berita/banjir-rob-demak"},
+		{"publisher", art.Metadata.Publisher.Name, "Kabar Pantura"},
+		// metadata image is preferred over the contents image
+		{"image", art.Image.URL, "https://cdn.kabarpantura.id/rob.jpg"},
+		{"word count", art.WordCount, 93},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("expected %s to be %v, got %v", tt.field, tt.expected, tt.got)
+		}
+	}
+}
+
+func TestExtractFallbacks(t *testing.T) {
+	art := extractFixture(t, "nometa.html")
+
+	// without metadata, the headline is taken from <h1>
+	if art.Headline != "Gempa Magnitudo 5,2 Guncang Cianjur" {
+		t.Errorf("expected headline from <h1>, got %q", art.Headline)
+	}
+
+	// the shown date without datetime attribute
+	wib := time.FixedZone("WIB", 7*60*60)
+	if !art.PublishedAt.Equal(time.Date(2024, 8, 12, 10, 30, 0, 0, wib)) {
+		t.Errorf("expected published at from <time> text, got %s", art.PublishedAt)
+	}
+
+	// the lazy loaded image is taken from data-src, and is the
+	// lead image as there is no metadata image
+	if art.Image.URL != "https://cdn.wartalokal.id/gempa.jpg" {
+		t.Errorf("expected lead image from the contents, got %q", art.Image.URL)
+	}
+
+	// the text put directly in div is a paragraph
+	paragraphs := texts(art, ParagraphText)
+	if len(paragraphs) != 2 || !strings.HasPrefix(paragraphs[0], "Gempa bumi bermagnitudo 5,2") {
+		t.Errorf("unexpected paragraphs %q", paragraphs)
+	}
+
+	if art.WordCount != 50 {
+		t.Errorf("expected word count of the paragraphs to be 50, got %d", art.WordCount)
+	}
+}
+
+func TestExtractNoContent(t *testing.T) {
+	tests := []string{
+		`<html><head><title>Kosong</title></head><body></body></html>`,
+		`<html><body><nav><p>Beranda, Nasional, Regional, Ekonomi, Olahraga, Teknologi</p></nav><p>Singkat.</p></body></html>`,
+	}
+
+	for _, doc := range tests {
+		node, err := html.Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Extract(node, articleLink); !errors.Is(err, ErrNoContent) {
+			t.Errorf("expected ErrNoContent for %q, got %v", doc, err)
+		}
+	}
+}
+
+func TestScoring(t *testing.T) {
+	body := htmlutil.FindNode(parseFixture(t, "article.html"), htmlutil.DefaultFilter{Type: html.ElementNode, Data: "body"})
+
+	ext := extractor{scores: make(map[*html.Node]float64)}
+	ext.scoreParagraphs(body)
+
+	top := ext.topCandidate()
+	if top == nil {
+		t.Fatal("expected a top candidate")
+	}
+
+	// the comments has long paragraphs as well, but it is unlikely,
+	// and the sidebar is mostly links
+	if class := attrVal(top, "class"); class != "article-content" {
+		t.Errorf("expected article-content to be the top candidate, got <%s class=%q>", top.Data, class)
+	}
+}
+
+func TestTopCandidateTie(t *testing.T) {
+	paragraph := "<p>Warga diminta tetap waspada, mengikuti arahan petugas, dan tidak panik.</p>"
+	doc := "<html><body><div id=\"satu\">" + paragraph + "</div><div id=\"dua\">" + paragraph + "</div></body></html>"
+
+	// the tie is broken by the order the candidates is found,
+	// not by the map order
+	for i := 0; i < 20; i++ {
+		node, err := html.Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ext := extractor{scores: make(map[*html.Node]float64)}
+		ext.scoreParagraphs(htmlutil.FindNode(node, htmlutil.DefaultFilter{Type: html.ElementNode, Data: "body"}))
+
+		if id := attrVal(ext.topCandidate(), "id"); id != "satu" {
+			t.Fatalf("expected the first div to be the top candidate, got %q", id)
+		}
+	}
+}
+
+func TestLinkDensity(t *testing.T) {
+	tests := []struct {
+		doc     string
+		density float64
+	}{
+		{`<div>tanpa tautan</div>`, 0},
+		{`<div><a href="/">semua</a></div>`, 1},
+		// the space between is counted as the text
+		{`<div>abcd <a href="/">efgh</a></div>`, 4.0 / 9},
+	}
+
+	for _, tt := range tests {
+		node, err := html.Parse(strings.NewReader(tt.doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		div := htmlutil.FindNode(node, htmlutil.DefaultFilter{Type: html.ElementNode, Data: "div"})
+		if density := linkDensity(div); density < tt.density-0.01 || density > tt.density+0.01 {
+			t.Errorf("expected link density of %s to be %.2f, got %.2f", tt.doc, tt.density, density)
+		}
+	}
+}
+
+func TestNodeText(t *testing.T) {
+	node, err := html.Parse(strings.NewReader("<p>satu  dua\n\ttiga <script>var x;</script><b>empat</b></p>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if text := nodeText(htmlutil.FindNode(node, htmlutil.DefaultFilter{Type: html.ElementNode, Data: "p"})); text != "satu dua tiga empat" {
+		t.Errorf("expected the whitespaces collapsed and script skipped, got %q", text)
+	}
+}
+
+func TestArticleFromLink(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/article.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/berita/banjir-rob-demak", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(fixture)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	art, err := ArticleFromLink(context.Background(), srv.Client(), srv.URL+"/berita/banjir-rob-demak")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the relative image is resolved against the fetched link
+	if imgs := images(art); len(imgs) != 1 || imgs[0].URL != srv.URL+"/img/rob-demak.jpg" {
+		t.Errorf("expected the image resolved against %s, got %+v", srv.URL, imgs)
+	}
+
+	_, err = ArticleFromLink(context.Background(), srv.Client(), srv.URL+"/berita/dihapus")
+	if code, removed := httpstatus.IsRemoved(err); !removed || code != http.StatusNotFound {
+		t.Errorf("expected removed with 404, got %v", err)
+	}
+}
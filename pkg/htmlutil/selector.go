@@ -0,0 +1,736 @@
+package htmlutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// Selector is a compiled CSS selector. Supported syntax:
+//
+//   - type, universal (*), #id and .class selectors
+//   - attribute selectors [attr], [attr=val], [attr~=val], [attr|=val],
+//     [attr^=val], [attr$=val] and [attr*=val], with optional i flag
+//     for case insensitive matching
+//   - descendant ( ), child (>), adjacent sibling (+) and general
+//     sibling (~) combinators
+//   - :not(), :nth-child(), :nth-last-child(), :nth-of-type(),
+//     :nth-last-of-type(), :first-child, :last-child, :only-child,
+//     :first-of-type, :last-of-type and :empty pseudo classes
+//   - selector list separated by comma
+//
+// Selector implements [Filter], so it can be used with [FindNode]
+// and [FindAllNode]. Selector is safe for concurrent use.
+type Selector struct {
+	src  string
+	list []complexSelector
+}
+
+// complexSelector is compound selectors chained with combinators,
+// combinators[i] is the combinator between compounds[i] and
+// compounds[i+1]
+type complexSelector struct {
+	compounds   []compoundSelector
+	combinators []byte
+}
+
+type compoundSelector struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []attrSelector
+	pseudos []pseudoSelector
+}
+
+type attrSelector struct {
+	key        string
+	op         string
+	val        string
+	ignoreCase bool
+}
+
+type pseudoSelector struct {
+	name string
+	// a and b of an+b for nth pseudo classes
+	a, b int
+	not  *Selector
+}
+
+// Compile parses a CSS selector
+func Compile(sel string) (*Selector, error) {
+	p := selectorParser{src: sel}
+	list, err := p.parseSelectorList()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+
+	return &Selector{src: sel, list: list}, nil
+}
+
+// MustCompile is like [Compile] but panics if the selector
+// is invalid. It is meant for package level selectors
+func MustCompile(sel string) *Selector {
+	s, err := Compile(sel)
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+var selectorCache sync.Map
+
+func cachedSelector(sel string) *Selector {
+	if s, ok := selectorCache.Load(sel); ok {
+		return s.(*Selector)
+	}
+
+	s := MustCompile(sel)
+	selectorCache.Store(sel, s)
+
+	return s
+}
+
+// Select finds all descendants of node that matches the selector.
+// The selector is compiled once and cached, it panics if the selector
+// is invalid. Use [Compile] to handle invalid selector
+func Select(node *html.Node, sel string) []*html.Node {
+	return cachedSelector(sel).Select(node)
+}
+
+// SelectOne is like [Select], but only finds the first match
+func SelectOne(node *html.Node, sel string) *html.Node {
+	return cachedSelector(sel).SelectOne(node)
+}
+
+// String returns the source of the selector
+func (sel *Selector) String() string {
+	return sel.src
+}
+
+// IsMatch reports whether the node matches the selector
+func (sel *Selector) IsMatch(node *html.Node) bool {
+	if node == nil || node.Type != html.ElementNode {
+		return false
+	}
+
+	for _, cs := range sel.list {
+		if cs.match(node, len(cs.compounds)-1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Select finds all descendants of node that matches the selector,
+// in document order
+func (sel *Selector) Select(node *html.Node) []*html.Node {
+	var nodes []*html.Node
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		nodes = append(nodes, findAllNode(c, sel)...)
+	}
+
+	return nodes
+}
+
+// SelectOne finds the first descendant of node that matches
+// the selector
+func (sel *Selector) SelectOne(node *html.Node) *html.Node {
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		if found := findSingleNode(c, sel); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// match matches the selector from right to left, starting
+// from compounds[i]
+func (cs complexSelector) match(node *html.Node, i int) bool {
+	if !cs.compounds[i].match(node) {
+		return false
+	}
+
+	if i == 0 {
+		return true
+	}
+
+	switch cs.combinators[i-1] {
+	case ' ':
+		for p := node.Parent; p != nil; p = p.Parent {
+			if cs.match(p, i-1) {
+				return true
+			}
+		}
+
+	case '>':
+		return node.Parent != nil && cs.match(node.Parent, i-1)
+
+	case '+':
+		prev := prevElementSibling(node)
+		return prev != nil && cs.match(prev, i-1)
+
+	case '~':
+		for prev := prevElementSibling(node); prev != nil; prev = prevElementSibling(prev) {
+			if cs.match(prev, i-1) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (cmp compoundSelector) match(node *html.Node) bool {
+	if node.Type != html.ElementNode {
+		return false
+	}
+
+	if cmp.tag != "" && cmp.tag != "*" && node.Data != cmp.tag {
+		return false
+	}
+
+	if cmp.id != "" && attrVal(node, "id") != cmp.id {
+		return false
+	}
+
+	if len(cmp.classes) != 0 {
+		classes := strings.Fields(attrVal(node, "class"))
+		for _, want := range cmp.classes {
+			if !containsStr(classes, want) {
+				return false
+			}
+		}
+	}
+
+	for _, attr := range cmp.attrs {
+		if !attr.match(node) {
+			return false
+		}
+	}
+
+	for _, pseudo := range cmp.pseudos {
+		if !pseudo.match(node) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (as attrSelector) match(node *html.Node) bool {
+	var val string
+	found := false
+	for _, attr := range node.Attr {
+		if attr.Key == as.key {
+			val = attr.Val
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return false
+	}
+
+	want := as.val
+	if as.ignoreCase {
+		val = strings.ToLower(val)
+		want = strings.ToLower(want)
+	}
+
+	switch as.op {
+	case "":
+		return true
+
+	case "=":
+		return val == want
+
+	case "~=":
+		return containsStr(strings.Fields(val), want)
+
+	case "|=":
+		return val == want || strings.HasPrefix(val, want+"-")
+
+	case "^=":
+		return want != "" && strings.HasPrefix(val, want)
+
+	case "$=":
+		return want != "" && strings.HasSuffix(val, want)
+
+	case "*=":
+		return want != "" && strings.Contains(val, want)
+	}
+
+	return false
+}
+
+func (ps pseudoSelector) match(node *html.Node) bool {
+	switch ps.name {
+	case "not":
+		return !ps.not.IsMatch(node)
+
+	case "first-child":
+		return prevElementSibling(node) == nil
+
+	case "last-child":
+		return nextElementSibling(node) == nil
+
+	case "only-child":
+		return prevElementSibling(node) == nil && nextElementSibling(node) == nil
+
+	case "first-of-type":
+		return nthPosition(node, true, false) == 1
+
+	case "last-of-type":
+		return nthPosition(node, true, true) == 1
+
+	case "nth-child":
+		return matchNth(nthPosition(node, false, false), ps.a, ps.b)
+
+	case "nth-last-child":
+		return matchNth(nthPosition(node, false, true), ps.a, ps.b)
+
+	case "nth-of-type":
+		return matchNth(nthPosition(node, true, false), ps.a, ps.b)
+
+	case "nth-last-of-type":
+		return matchNth(nthPosition(node, true, true), ps.a, ps.b)
+
+	case "empty":
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode || (c.Type == html.TextNode && c.Data != "") {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// nthPosition gets the 1-based position of the node among its
+// element siblings, optionally only counting the siblings with
+// the same type and counting from the last sibling
+func nthPosition(node *html.Node, ofType, fromLast bool) int {
+	pos := 1
+	sibling := prevElementSibling
+	if fromLast {
+		sibling = nextElementSibling
+	}
+
+	for s := sibling(node); s != nil; s = sibling(s) {
+		if !ofType || s.Data == node.Data {
+			pos++
+		}
+	}
+
+	return pos
+}
+
+func matchNth(pos, a, b int) bool {
+	if a == 0 {
+		return pos == b
+	}
+
+	n := pos - b
+	return n%a == 0 && n/a >= 0
+}
+
+func prevElementSibling(node *html.Node) *html.Node {
+	for s := node.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+
+	return nil
+}
+
+func nextElementSibling(node *html.Node) *html.Node {
+	for s := node.NextSibling; s != nil; s = s.NextSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+
+	return nil
+}
+
+func attrVal(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+
+	return ""
+}
+
+func containsStr(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+
+	return false
+}
+
+type selectorParser struct {
+	src string
+	pos int
+}
+
+func (p *selectorParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("htmlutil: invalid selector %q at offset %d: %s", p.src, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *selectorParser) parseSelectorList() ([]complexSelector, error) {
+	var list []complexSelector
+	for {
+		p.skipSpaces()
+		cs, err := p.parseComplex()
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, cs)
+
+		p.skipSpaces()
+		if p.pos < len(p.src) && p.src[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+
+		return list, nil
+	}
+}
+
+func (p *selectorParser) parseComplex() (complexSelector, error) {
+	var cs complexSelector
+
+	cmp, err := p.parseCompound()
+	if err != nil {
+		return cs, err
+	}
+
+	cs.compounds = append(cs.compounds, cmp)
+
+	for {
+		hasSpace := p.skipSpaces()
+		if p.pos >= len(p.src) {
+			return cs, nil
+		}
+
+		comb := p.src[p.pos]
+		switch comb {
+		case '>', '+', '~':
+			p.pos++
+			p.skipSpaces()
+
+		case ',', ')':
+			return cs, nil
+
+		default:
+			if !hasSpace {
+				return cs, p.errorf("unexpected %q", comb)
+			}
+
+			comb = ' '
+		}
+
+		cmp, err := p.parseCompound()
+		if err != nil {
+			return cs, err
+		}
+
+		cs.combinators = append(cs.combinators, comb)
+		cs.compounds = append(cs.compounds, cmp)
+	}
+}
+
+func (p *selectorParser) parseCompound() (compoundSelector, error) {
+	var cmp compoundSelector
+	start := p.pos
+
+	if p.pos < len(p.src) && p.src[p.pos] == '*' {
+		cmp.tag = "*"
+		p.pos++
+	} else if ident := p.parseIdent(); ident != "" {
+		cmp.tag = strings.ToLower(ident)
+	}
+
+LOOP:
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '#':
+			p.pos++
+			cmp.id = p.parseIdent()
+			if cmp.id == "" {
+				return cmp, p.errorf("expect id")
+			}
+
+		case '.':
+			p.pos++
+			class := p.parseIdent()
+			if class == "" {
+				return cmp, p.errorf("expect class name")
+			}
+
+			cmp.classes = append(cmp.classes, class)
+
+		case '[':
+			p.pos++
+			attr, err := p.parseAttr()
+			if err != nil {
+				return cmp, err
+			}
+
+			cmp.attrs = append(cmp.attrs, attr)
+
+		case ':':
+			p.pos++
+			pseudo, err := p.parsePseudo()
+			if err != nil {
+				return cmp, err
+			}
+
+			cmp.pseudos = append(cmp.pseudos, pseudo)
+
+		default:
+			break LOOP
+		}
+	}
+
+	if p.pos == start {
+		if p.pos >= len(p.src) {
+			return cmp, p.errorf("unexpected end of selector")
+		}
+
+		return cmp, p.errorf("unexpected %q", p.src[p.pos])
+	}
+
+	return cmp, nil
+}
+
+func (p *selectorParser) parseAttr() (attrSelector, error) {
+	var attr attrSelector
+
+	p.skipSpaces()
+	attr.key = strings.ToLower(p.parseIdent())
+	if attr.key == "" {
+		return attr, p.errorf("expect attribute name")
+	}
+
+	p.skipSpaces()
+	if p.pos >= len(p.src) {
+		return attr, p.errorf("unclosed attribute selector")
+	}
+
+	if p.src[p.pos] == ']' {
+		p.pos++
+		return attr, nil
+	}
+
+	for _, op := range []string{"=", "~=", "|=", "^=", "$=", "*="} {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			attr.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+
+	if attr.op == "" {
+		return attr, p.errorf("unknown attribute operator")
+	}
+
+	p.skipSpaces()
+	val, err := p.parseValue()
+	if err != nil {
+		return attr, err
+	}
+
+	attr.val = val
+
+	p.skipSpaces()
+	if p.pos < len(p.src) && (p.src[p.pos] == 'i' || p.src[p.pos] == 'I') {
+		attr.ignoreCase = true
+		p.pos++
+		p.skipSpaces()
+	}
+
+	if p.pos >= len(p.src) || p.src[p.pos] != ']' {
+		return attr, p.errorf("unclosed attribute selector")
+	}
+
+	p.pos++
+
+	return attr, nil
+}
+
+func (p *selectorParser) parsePseudo() (pseudoSelector, error) {
+	var ps pseudoSelector
+
+	ps.name = strings.ToLower(p.parseIdent())
+	switch ps.name {
+	case "first-child", "last-child", "only-child", "first-of-type", "last-of-type", "empty":
+		return ps, nil
+
+	case "not", "nth-child", "nth-last-child", "nth-of-type", "nth-last-of-type":
+
+	case "":
+		return ps, p.errorf("expect pseudo class name")
+
+	default:
+		return ps, p.errorf("unsupported pseudo class :%s", ps.name)
+	}
+
+	if p.pos >= len(p.src) || p.src[p.pos] != '(' {
+		return ps, p.errorf("expect arguments of :%s", ps.name)
+	}
+
+	p.pos++
+	p.skipSpaces()
+
+	if ps.name == "not" {
+		list, err := p.parseSelectorList()
+		if err != nil {
+			return ps, err
+		}
+
+		ps.not = &Selector{list: list}
+	} else {
+		end := strings.IndexByte(p.src[p.pos:], ')')
+		if end < 0 {
+			return ps, p.errorf("unclosed :%s", ps.name)
+		}
+
+		a, b, err := parseNth(p.src[p.pos : p.pos+end])
+		if err != nil {
+			return ps, p.errorf("%s", err.Error())
+		}
+
+		ps.a, ps.b = a, b
+		p.pos += end
+	}
+
+	p.skipSpaces()
+	if p.pos >= len(p.src) || p.src[p.pos] != ')' {
+		return ps, p.errorf("unclosed :%s", ps.name)
+	}
+
+	p.pos++
+
+	return ps, nil
+}
+
+// parseNth parses an+b, odd and even
+func parseNth(str string) (int, int, error) {
+	str = strings.ToLower(strings.ReplaceAll(str, " ", ""))
+	switch str {
+	case "odd":
+		return 2, 1, nil
+
+	case "even":
+		return 2, 0, nil
+	}
+
+	idx := strings.IndexByte(str, 'n')
+	if idx < 0 {
+		b, err := strconv.Atoi(str)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid nth expression %q", str)
+		}
+
+		return 0, b, nil
+	}
+
+	var a, b int
+	switch aStr := str[:idx]; aStr {
+	case "", "+":
+		a = 1
+
+	case "-":
+		a = -1
+
+	default:
+		var err error
+		a, err = strconv.Atoi(aStr)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid nth expression %q", str)
+		}
+	}
+
+	if bStr := str[idx+1:]; bStr != "" {
+		var err error
+		b, err = strconv.Atoi(bStr)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid nth expression %q", str)
+		}
+	}
+
+	return a, b, nil
+}
+
+func (p *selectorParser) parseValue() (string, error) {
+	if p.pos >= len(p.src) {
+		return "", p.errorf("expect attribute value")
+	}
+
+	quote := p.src[p.pos]
+	if quote != '"' && quote != '\'' {
+		val := p.parseIdent()
+		if val == "" {
+			return "", p.errorf("expect attribute value")
+		}
+
+		return val, nil
+	}
+
+	p.pos++
+	end := strings.IndexByte(p.src[p.pos:], quote)
+	if end < 0 {
+		return "", p.errorf("unclosed string")
+	}
+
+	val := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+
+	return val, nil
+}
+
+func (p *selectorParser) parseIdent() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '-' || c == '_' || c >= 0x80 ||
+			(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			p.pos++
+			continue
+		}
+
+		break
+	}
+
+	return p.src[start:p.pos]
+}
+
+func (p *selectorParser) skipSpaces() bool {
+	start := p.pos
+	for p.pos < len(p.src) && strings.IndexByte(" \t\n\r\f", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+
+	return p.pos > start
+}
@@ -0,0 +1,104 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const selectorHtml = `
+<!DOCTYPE html>
+<html>
+<body>
+	<div class="detail__body-text itp_bodycontent" id="body">
+		<strong>detikNews</strong>
+		<p>first</p>
+		<p class="para">second</p>
+		<div class="lihatjg"><a href="https://news.detik.com/berita/d-1">Baca juga</a></div>
+		<p>third <a href="https://www.detik.com/tag/pemilu" data-tag="pemilu-2024">pemilu</a></p>
+		<h2>Section</h2>
+		<p lang="id-ID">fourth</p>
+	</div>
+	<ul>
+		<li>1</li><li>2</li><li>3</li><li>4</li><li>5</li>
+	</ul>
+</body>
+</html>`
+
+func TestSelect(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(selectorHtml))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tests := []struct {
+		sel   string
+		count int
+	}{
+		{sel: "p", count: 4},
+		{sel: "div.detail__body-text > p:not([class])", count: 3},
+		{sel: "div.itp_bodycontent.detail__body-text p", count: 4},
+		{sel: "#body > .lihatjg a", count: 1},
+		{sel: "div > p.para", count: 1},
+		{sel: "a[href^='https://www.detik.com/tag/']", count: 1},
+		{sel: "a[href*=detik]", count: 2},
+		{sel: "a[href$=d-1]", count: 1},
+		{sel: "div[class~=itp_bodycontent]", count: 1},
+		{sel: "div[class~=itp]", count: 0},
+		{sel: "a[data-tag|=pemilu]", count: 1},
+		{sel: "p[lang=ID-id i]", count: 1},
+		{sel: "h2 + p", count: 1},
+		{sel: "strong ~ p", count: 4},
+		{sel: "li:nth-child(2n+1)", count: 3},
+		{sel: "li:nth-child(odd)", count: 3},
+		{sel: "li:nth-child(even)", count: 2},
+		{sel: "li:nth-child(-n+2)", count: 2},
+		{sel: "li:nth-child(3)", count: 1},
+		{sel: "li:nth-last-child(1)", count: 1},
+		{sel: "li:first-child, li:last-child", count: 2},
+		{sel: "#body p:first-of-type", count: 1},
+		{sel: "p:not(.para, [lang])", count: 2},
+		{sel: "ul *", count: 5},
+	}
+
+	for _, tt := range tests {
+		sel, err := Compile(tt.sel)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tt.sel, err.Error())
+			continue
+		}
+
+		nodes := sel.Select(doc)
+		if len(nodes) != tt.count {
+			t.Errorf("%q: expect %d nodes, got %d", tt.sel, tt.count, len(nodes))
+		}
+	}
+
+	node := SelectOne(doc, "#body > p:nth-of-type(2)")
+	if node == nil || attrVal(node, "class") != "para" {
+		t.Errorf("expect the second paragraph is selected")
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+	invalids := []string{
+		"",
+		"div >",
+		"div[",
+		"div[class",
+		"div[class=]",
+		"div[class=='a']",
+		"p:nth-child(x)",
+		"p:hover",
+		"p:not(.a",
+		"div, ",
+		"a)",
+	}
+
+	for _, sel := range invalids {
+		if _, err := Compile(sel); err == nil {
+			t.Errorf("%q: expect error", sel)
+		}
+	}
+}
@@ -0,0 +1,525 @@
+package htmlutil
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// blockTags is rendered in its own line by Text and Markdown
+var blockTags = map[string]bool{
+	"address":    true,
+	"article":    true,
+	"aside":      true,
+	"blockquote": true,
+	"dd":         true,
+	"div":        true,
+	"dl":         true,
+	"dt":         true,
+	"figcaption": true,
+	"figure":     true,
+	"footer":     true,
+	"h1":         true,
+	"h2":         true,
+	"h3":         true,
+	"h4":         true,
+	"h5":         true,
+	"h6":         true,
+	"header":     true,
+	"hr":         true,
+	"li":         true,
+	"main":       true,
+	"ol":         true,
+	"p":          true,
+	"pre":        true,
+	"section":    true,
+	"table":      true,
+	"tr":         true,
+	"ul":         true,
+}
+
+// droppedTags and its descendants is never rendered as text
+var droppedTags = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"template": true,
+	"iframe":   true,
+	"object":   true,
+	"embed":    true,
+	"svg":      true,
+	"head":     true,
+}
+
+var textReplacer = strings.NewReplacer(
+	"\u00a0", " ", // non-breaking space
+	"\u200b", "", // zero width space
+	"\u200c", "", // zero width non-joiner
+	"\u200d", "", // zero width joiner
+	"\ufeff", "", // byte order mark
+	"\u00ad", "", // soft hyphen
+)
+
+var spacesRgx = regexp.MustCompile(`[ \t\r\f\v]+`)
+
+// NormalizeText unescapes leftover HTML entities, like the double
+// escaped ones, removes invisible characters and collapses the
+// whitespaces in every line. Blank lines is removed
+func NormalizeText(str string) string {
+	str = textReplacer.Replace(html.UnescapeString(str))
+
+	var lines []string
+	for _, line := range strings.Split(str, "\n") {
+		line = strings.TrimSpace(spacesRgx.ReplaceAllString(line, " "))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Text gets the normalized text content of node. Block elements
+// and <br> is put in its own line, scripts and styles is ignored
+func Text(node *html.Node) string {
+	var sb strings.Builder
+	writeText(&sb, node)
+
+	return NormalizeText(sb.String())
+}
+
+// ContentText is a text block, like paragraph and section title,
+// in three representations: sanitized HTML, plain text and Markdown
+type ContentText struct {
+	HTML     string
+	Text     string
+	Markdown string
+}
+
+// NewContentText renders node in all of the ContentText representations
+func NewContentText(node *html.Node) ContentText {
+	return ContentText{
+		HTML:     Sanitize(node),
+		Text:     Text(node),
+		Markdown: Markdown(node),
+	}
+}
+
+func writeText(sb *strings.Builder, node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		// newlines in text is just whitespace
+		sb.WriteString(strings.ReplaceAll(node.Data, "\n", " "))
+		return
+
+	case html.ElementNode:
+		if droppedTags[node.Data] {
+			return
+		}
+
+		if node.Data == "br" {
+			sb.WriteString("\n")
+			return
+		}
+	}
+
+	isBlock := node.Type == html.ElementNode && blockTags[node.Data]
+	if isBlock {
+		sb.WriteString("\n")
+	}
+
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		writeText(sb, c)
+	}
+
+	if isBlock {
+		sb.WriteString("\n")
+	}
+}
+
+// Markdown renders node as Markdown. Links, images, emphasis, headings,
+// lists, blockquotes and code is kept, other elements is rendered as
+// its text
+func Markdown(node *html.Node) string {
+	r := mdRenderer{}
+	r.render(node)
+
+	return cleanMarkdown(r.sb.String())
+}
+
+var (
+	mdEscaper       = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`)
+	mdBlankLinesRgx = regexp.MustCompile(`\n{3,}`)
+	mdSpacesRgx     = regexp.MustCompile(`[ \t]+`)
+)
+
+type mdRenderer struct {
+	sb strings.Builder
+}
+
+func (r *mdRenderer) render(node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		text := textReplacer.Replace(node.Data)
+		text = strings.Join(strings.FieldsFunc(text, isSpace), " ")
+		if text == "" {
+			if node.Data != "" {
+				r.sb.WriteString(" ")
+			}
+
+			return
+		}
+
+		if isSpace(rune(node.Data[0])) {
+			r.sb.WriteString(" ")
+		}
+
+		r.sb.WriteString(mdEscaper.Replace(text))
+
+		if isSpace(rune(node.Data[len(node.Data)-1])) {
+			r.sb.WriteString(" ")
+		}
+
+		return
+
+	case html.ElementNode:
+
+	default:
+		r.renderChildren(node)
+		return
+	}
+
+	if droppedTags[node.Data] {
+		return
+	}
+
+	switch node.Data {
+	case "br":
+		r.sb.WriteString("\n")
+
+	case "hr":
+		r.block()
+		r.sb.WriteString("---")
+		r.block()
+
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level, _ := strconv.Atoi(node.Data[1:])
+		r.block()
+		r.sb.WriteString(strings.Repeat("#", level) + " " + r.inline(node))
+		r.block()
+
+	case "strong", "b":
+		r.wrapInline(node, "**")
+
+	case "em", "i":
+		r.wrapInline(node, "*")
+
+	case "s", "del", "strike":
+		r.wrapInline(node, "~~")
+
+	case "code":
+		if text := Text(node); text != "" {
+			r.sb.WriteString("`" + text + "`")
+		}
+
+	case "pre":
+		r.block()
+		r.sb.WriteString("```\n" + strings.Trim(textContent(node), "\n") + "\n```")
+		r.block()
+
+	case "a":
+		text := r.inline(node)
+		href := strings.TrimSpace(attrVal(node, "href"))
+		if text == "" || href == "" || !isSafeURL(href) {
+			r.sb.WriteString(text)
+			return
+		}
+
+		r.sb.WriteString("[" + text + "](" + href + ")")
+
+	case "img":
+		src := attrVal(node, "src")
+		if src == "" {
+			src = attrVal(node, "data-src")
+		}
+
+		if src = strings.TrimSpace(src); src != "" && isSafeURL(src) {
+			r.sb.WriteString("![" + mdEscaper.Replace(attrVal(node, "alt")) + "](" + src + ")")
+		}
+
+	case "ul", "ol":
+		r.block()
+
+		i := 0
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data != "li" {
+				continue
+			}
+
+			i++
+			marker := "- "
+			if node.Data == "ol" {
+				marker = strconv.Itoa(i) + ". "
+			}
+
+			// the item is rendered separately, so the nested
+			// lists can be indented under the item
+			item := mdRenderer{}
+			item.renderChildren(c)
+
+			lines := strings.Split(cleanMarkdown(item.sb.String()), "\n")
+			r.sb.WriteString("\n" + marker + lines[0])
+			for _, line := range lines[1:] {
+				if line != "" {
+					r.sb.WriteString("\n  " + line)
+				}
+			}
+		}
+
+		r.block()
+
+	case "blockquote":
+		quote := mdRenderer{}
+		quote.renderChildren(node)
+
+		r.block()
+		for i, line := range strings.Split(cleanMarkdown(quote.sb.String()), "\n") {
+			if i != 0 {
+				r.sb.WriteString("\n")
+			}
+
+			r.sb.WriteString(strings.TrimRight("> "+line, " "))
+		}
+
+		r.block()
+
+	default:
+		if blockTags[node.Data] {
+			r.block()
+			r.renderChildren(node)
+			r.block()
+			return
+		}
+
+		r.renderChildren(node)
+	}
+}
+
+func (r *mdRenderer) renderChildren(node *html.Node) {
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		r.render(c)
+	}
+}
+
+// inline renders the children of node in a single line
+func (r *mdRenderer) inline(node *html.Node) string {
+	sub := mdRenderer{}
+	sub.renderChildren(node)
+
+	return strings.Join(strings.Fields(sub.sb.String()), " ")
+}
+
+func (r *mdRenderer) wrapInline(node *html.Node, marker string) {
+	text := r.inline(node)
+	if text == "" {
+		return
+	}
+
+	// keep the surrounding whitespace outside of the marker,
+	// "**bold **" is not a valid emphasis
+	if c := node.FirstChild; c != nil && c.Type == html.TextNode && c.Data != "" && isSpace(rune(c.Data[0])) {
+		r.sb.WriteString(" ")
+	}
+
+	r.sb.WriteString(marker + text + marker)
+
+	if c := node.LastChild; c != nil && c.Type == html.TextNode && c.Data != "" && isSpace(rune(c.Data[len(c.Data)-1])) {
+		r.sb.WriteString(" ")
+	}
+}
+
+// block starts a new block separated by a blank line
+func (r *mdRenderer) block() {
+	if r.sb.Len() != 0 {
+		r.sb.WriteString("\n\n")
+	}
+}
+
+func cleanMarkdown(md string) string {
+	lines := strings.Split(md, "\n")
+	for i, line := range lines {
+		// indentation is kept for nested list items
+		trimmed := strings.TrimLeft(line, " ")
+		indent := line[:len(line)-len(trimmed)]
+		if !strings.HasPrefix(trimmed, "- ") && !isOrderedItem(trimmed) {
+			indent = ""
+		}
+
+		lines[i] = indent + strings.TrimSpace(mdSpacesRgx.ReplaceAllString(trimmed, " "))
+	}
+
+	md = strings.Join(lines, "\n")
+	md = mdBlankLinesRgx.ReplaceAllString(md, "\n\n")
+
+	return strings.Trim(md, "\n ")
+}
+
+func isOrderedItem(line string) bool {
+	dot := strings.Index(line, ". ")
+	if dot <= 0 {
+		return false
+	}
+
+	_, err := strconv.Atoi(line[:dot])
+	return err == nil
+}
+
+func isSpace(r rune) bool {
+	switch r {
+	case ' ', '\t', '\n', '\r', '\f', '\v':
+		return true
+	}
+
+	return false
+}
+
+// Sanitizer renders HTML with only the allowed elements and attributes.
+// Elements outside of the allowlist is unwrapped, its children is still
+// rendered, except for the dropped elements like <script>, which is
+// removed with all of its children
+type Sanitizer struct {
+	// Elements maps the allowed element to its allowed attributes
+	Elements map[string][]string
+}
+
+// DefaultSanitizer allows basic text formatting, links, lists,
+// quotes and images
+var DefaultSanitizer = Sanitizer{
+	Elements: map[string][]string{
+		"a":          {"href", "title"},
+		"b":          nil,
+		"strong":     nil,
+		"i":          nil,
+		"em":         nil,
+		"u":          nil,
+		"s":          nil,
+		"del":        nil,
+		"sub":        nil,
+		"sup":        nil,
+		"br":         nil,
+		"p":          nil,
+		"ul":         nil,
+		"ol":         nil,
+		"li":         nil,
+		"blockquote": nil,
+		"code":       nil,
+		"pre":        nil,
+		"h2":         nil,
+		"h3":         nil,
+		"h4":         nil,
+		"h5":         nil,
+		"h6":         nil,
+		"figure":     nil,
+		"figcaption": nil,
+		"img":        {"src", "alt", "title", "width", "height"},
+	},
+}
+
+// Sanitize renders node with [DefaultSanitizer]
+func Sanitize(node *html.Node) string {
+	return DefaultSanitizer.Sanitize(node)
+}
+
+// Sanitize renders node and its descendants, keeping only the
+// allowed elements and attributes
+func (s Sanitizer) Sanitize(node *html.Node) string {
+	var buff bytes.Buffer
+	s.render(&buff, node)
+
+	return strings.TrimSpace(buff.String())
+}
+
+func (s Sanitizer) render(buff *bytes.Buffer, node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		buff.WriteString(html.EscapeString(textReplacer.Replace(node.Data)))
+		return
+
+	case html.ElementNode:
+
+	default:
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			s.render(buff, c)
+		}
+
+		return
+	}
+
+	if droppedTags[node.Data] {
+		return
+	}
+
+	allowedAttrs, allowed := s.Elements[node.Data]
+	if allowed {
+		clean := &html.Node{
+			Type:     html.ElementNode,
+			Data:     node.Data,
+			DataAtom: node.DataAtom,
+		}
+
+		for _, attr := range node.Attr {
+			if attr.Namespace != "" || !containsStr(allowedAttrs, attr.Key) {
+				continue
+			}
+
+			if (attr.Key == "href" || attr.Key == "src") && !isSafeURL(attr.Val) {
+				continue
+			}
+
+			clean.Attr = append(clean.Attr, html.Attribute{Key: attr.Key, Val: attr.Val})
+		}
+
+		// render only the start tag, the children is sanitized
+		// and rendered separately
+		var tag bytes.Buffer
+		html.Render(&tag, clean)
+		buff.WriteString(strings.TrimSuffix(tag.String(), "</"+node.Data+">"))
+	}
+
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		s.render(buff, c)
+	}
+
+	if allowed && !isVoidElement(node.Data) {
+		buff.WriteString("</" + node.Data + ">")
+	}
+}
+
+func isVoidElement(tag string) bool {
+	switch tag {
+	case "br", "img", "hr", "wbr", "input", "meta", "link", "source":
+		return true
+	}
+
+	return false
+}
+
+// isSafeURL allows only http, https and mailto scheme, and
+// relative URLs
+func isSafeURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,119 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func parseFragment(t *testing.T, str string) *html.Node {
+	doc, err := html.Parse(strings.NewReader("<html><body>" + str + "</body></html>"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return FindNode(doc, DefaultFilter{Type: html.ElementNode, Data: "body"})
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		html string
+		text string
+	}{
+		{html: "<p>Jakarta&nbsp;-  Presiden\n  <a href='/x'>Jokowi</a>&#8203; hadir.</p>", text: "Jakarta - Presiden Jokowi hadir."},
+		{html: "<p>satu<br>dua</p><p>tiga</p>", text: "satu\ndua\ntiga"},
+		{html: "<p>AT&amp;amp;T <script>var x = 1;</script>Indonesia</p>", text: "AT&T Indonesia"},
+		{html: "<div><span>a</span><span>b</span></div>", text: "ab"},
+	}
+
+	for _, tt := range tests {
+		if text := Text(parseFragment(t, tt.html)); text != tt.text {
+			t.Errorf("%q: expect %q, got %q", tt.html, tt.text, text)
+		}
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		html string
+		md   string
+	}{
+		{
+			html: `<p>Kata <strong>tebal </strong>dan <em>miring</em>, lihat <a href="https://detik.com">detik</a>.</p>`,
+			md:   "Kata **tebal** dan *miring*, lihat [detik](https://detik.com).",
+		},
+		{
+			html: `<h2>Judul</h2><p>isi_teks</p>`,
+			md:   "## Judul\n\nisi\\_teks",
+		},
+		{
+			html: `<ul><li>satu</li><li>dua<ol><li>a</li><li>b</li></ol></li></ul>`,
+			md:   "- satu\n- dua\n  1. a\n  2. b",
+		},
+		{
+			html: `<blockquote><p>kutipan</p><p>kedua</p></blockquote>`,
+			md:   "> kutipan\n>\n> kedua",
+		},
+		{
+			html: `<p><img src="/a.jpg" alt="foto"><br>caption</p>`,
+			md:   "![foto](/a.jpg)\ncaption",
+		},
+		{
+			// only the link text is kept for the unsafe URLs
+			html: `<p><a href="JavaScript:alert(1)">a</a> <a href=" javascript:alert(1)">b</a> <a href="data:text/html,x">c</a> <a href="vbscript:x">d</a></p>`,
+			md:   "a b c d",
+		},
+		{
+			html: `<p><img src="javascript:alert(1)" alt="x"><a href="mailto:redaksi@detik.com">surel</a></p>`,
+			md:   "[surel](mailto:redaksi@detik.com)",
+		},
+	}
+
+	for _, tt := range tests {
+		if md := Markdown(parseFragment(t, tt.html)); md != tt.md {
+			t.Errorf("%q: expect %q, got %q", tt.html, tt.md, md)
+		}
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		html      string
+		sanitized string
+	}{
+		{
+			html:      `<p class="x" onclick="alert(1)">teks <a href="javascript:alert(1)" target="_blank">link</a></p>`,
+			sanitized: `<p>teks <a>link</a></p>`,
+		},
+		{
+			html:      `<div><span style="color:red">a &lt;b&gt;</span><script>alert(1)</script><a href="https://detik.com" title="t">c</a></div>`,
+			sanitized: `a &lt;b&gt;<a href="https://detik.com" title="t">c</a>`,
+		},
+		{
+			html:      `<p>satu<br/>dua <img src="/a.jpg" alt="x" data-lazy="y"></p>`,
+			sanitized: `<p>satu<br/>dua <img src="/a.jpg" alt="x"/></p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		body := parseFragment(t, tt.html)
+		var sanitized string
+		for c := body.FirstChild; c != nil; c = c.NextSibling {
+			sanitized += Sanitize(c)
+		}
+
+		if sanitized != tt.sanitized {
+			t.Errorf("%q: expect %q, got %q", tt.html, tt.sanitized, sanitized)
+		}
+	}
+}
+
+func TestNewContentText(t *testing.T) {
+	node := FindNode(parseFragment(t, "<p>Presiden <b>Jokowi</b> hadir.</p>"), DefaultFilter{Type: html.ElementNode, Data: "p"})
+
+	text := NewContentText(node)
+	if text.Text != "Presiden Jokowi hadir." || text.Markdown != "Presiden **Jokowi** hadir." || text.HTML != "<p>Presiden <b>Jokowi</b> hadir.</p>" {
+		t.Errorf("unexpected content text %#v", text)
+	}
+}
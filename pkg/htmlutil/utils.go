@@ -77,6 +77,25 @@ func FindAllNode(node *html.Node, filter Filter) []*html.Node {
 	return findAllNode(node, filter)
 }
 
+// FindAllNodeLimit is like FindAllNode, but stops finding once
+// limit nodes is found. If limit <= 0, all nodes is returned
+func FindAllNodeLimit(node *html.Node, filter Filter, limit int) []*html.Node {
+	if limit <= 0 {
+		return findAllNode(node, filter)
+	}
+
+	var foundNodes []*html.Node
+	WalkNodes(node, func(node *html.Node) bool {
+		if filter.IsMatch(node) {
+			foundNodes = append(foundNodes, node)
+		}
+
+		return len(foundNodes) < limit
+	})
+
+	return foundNodes
+}
+
 func findAllNode(node *html.Node, filter Filter) []*html.Node {
 	var foundNodes []*html.Node
 
@@ -0,0 +1,25 @@
+// Package httpreq builds the page requests of the crawlers, with
+// the headers of a desktop browser so the sites serve the same page
+// as to the readers
+package httpreq
+
+import (
+	"context"
+	"net/http"
+)
+
+const UserAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/127.0.0.0 Safari/537.36"
+
+// NewPage creates a GET request of an HTML page
+func NewPage(ctx context.Context, link string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	req.Header.Set("accept-language", "id-ID,id;q=0.9,en-US;q=0.8,en;q=0.7")
+	req.Header.Set("user-agent", UserAgent)
+
+	return req, nil
+}
@@ -0,0 +1,44 @@
+// Package httpstatus provides the error of unexpected HTTP response
+// status, so the callers can tell a removed page from other failures
+package httpstatus
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error is returned when the page is responded with status
+// other than 200
+type Error struct {
+	StatusCode int
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("error requesting resource: got %d status code", err.StatusCode)
+}
+
+// Check returns *Error when the response status is not 200
+func Check(res *http.Response) error {
+	if res.StatusCode != http.StatusOK {
+		return &Error{StatusCode: res.StatusCode}
+	}
+
+	return nil
+}
+
+// IsRemoved reports whether err is caused by 404 or 410 status,
+// which means the page is deleted
+func IsRemoved(err error) (int, bool) {
+	var serr *Error
+	if !errors.As(err, &serr) {
+		return 0, false
+	}
+
+	switch serr.StatusCode {
+	case http.StatusNotFound, http.StatusGone:
+		return serr.StatusCode, true
+	}
+
+	return serr.StatusCode, false
+}
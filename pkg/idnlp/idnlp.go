@@ -0,0 +1,95 @@
+// Package idnlp provides Indonesian text processing: a sentence
+// splitter and word tokenizer aware of the Indonesian abbreviations,
+// a stopword list and an affix stemmer, used to get the searchable
+// terms of the articles. It is pure Go, the word lists is embedded.
+package idnlp
+
+import (
+	_ "embed"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// minTermLength is the minimum length of a term, in letters
+const minTermLength = 3
+
+//go:embed data/stopwords.txt
+var stopwordsData []byte
+
+var stopwords = ParseDictionary(stopwordsData)
+
+// apostrophes is removed from the words, like Jum'at is jumat
+var apostrophes = strings.NewReplacer("’", "", "'", "")
+
+// IsStopword reports whether the word is a stopword
+func IsStopword(word string) bool {
+	return stopwords[strings.ToLower(word)]
+}
+
+// Stopwords gets the stopwords, sorted
+func Stopwords() []string {
+	list := make([]string, 0, len(stopwords))
+	for word := range stopwords {
+		list = append(list, word)
+	}
+
+	sort.Strings(list)
+
+	return list
+}
+
+// Words gets the words of a word token to stem, in lowercase and
+// without the apostrophes. A hyphenated compound, like
+// Semarang-Demak, is its words, while a reduplication, like
+// anak-anak, is its root
+func (tok Token) Words() []string {
+	word := strings.ToLower(apostrophes.Replace(tok.Text))
+	if !strings.Contains(word, "-") {
+		return []string{word}
+	}
+
+	if stem := DefaultStemmer().Stem(word); stem != word {
+		return []string{stem}
+	}
+
+	return strings.Split(word, "-")
+}
+
+// Terms gets the unique searchable terms of the texts, in the order
+// they first appear. The words is lowercased and stemmed, the
+// stopwords, numbers, abbreviations and words shorter than 3 letters
+// is dropped. A hyphenated compound, like Semarang-Demak, is split
+// into its words, while a reduplication, like anak-anak, is its root
+func Terms(texts ...string) []string {
+	st := DefaultStemmer()
+	seen := make(map[string]bool)
+	var terms []string
+	add := func(word string) {
+		if utf8.RuneCountInString(word) < minTermLength || stopwords[word] {
+			return
+		}
+
+		term := st.Stem(word)
+		if seen[term] || stopwords[term] || utf8.RuneCountInString(term) < minTermLength {
+			return
+		}
+
+		seen[term] = true
+		terms = append(terms, term)
+	}
+
+	for _, text := range texts {
+		for _, tok := range Tokenize(text) {
+			if tok.Kind != TokenWord {
+				continue
+			}
+
+			for _, word := range tok.Words() {
+				add(word)
+			}
+		}
+	}
+
+	return terms
+}
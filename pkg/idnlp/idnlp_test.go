@@ -0,0 +1,50 @@
+package idnlp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTerms(t *testing.T) {
+	terms := Terms(
+		"Presiden Joko Widodo meresmikan jalan tol Semarang-Demak di Kab. Demak, Rabu (12/8/2024).",
+		"Menurutnya, tol itu juga berfungsi sebagai tanggul laut, kata Jokowi kepada anak-anak.",
+	)
+
+	expecting := []string{
+		"presiden", "joko", "widodo", "resmi", "jalan", "tol", "semarang", "demak", "rabu",
+		"fungsi", "tanggul", "laut", "jokowi", "anak",
+	}
+
+	if !reflect.DeepEqual(terms, expecting) {
+		t.Errorf("expecting %v, got %v", expecting, terms)
+	}
+}
+
+func TestTokenWords(t *testing.T) {
+	tests := map[string][]string{
+		"Jum'at":         {"jumat"},
+		"Semarang-Demak": {"semarang", "demak"},
+		"anak-anak":      {"anak"},
+		"buku-bukunya":   {"buku"},
+	}
+
+	for text, expecting := range tests {
+		words := Token{Text: text, Kind: TokenWord}.Words()
+		if !reflect.DeepEqual(words, expecting) {
+			t.Errorf("%s: expecting %v, got %v", text, expecting, words)
+		}
+	}
+}
+
+func TestIsStopword(t *testing.T) {
+	for _, word := range []string{"yang", "Dan", "mengatakan", "katanya"} {
+		if !IsStopword(word) {
+			t.Errorf("expecting %s to be stopword", word)
+		}
+	}
+
+	if IsStopword("banjir") {
+		t.Error("expecting banjir not to be stopword")
+	}
+}
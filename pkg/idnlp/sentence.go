@@ -0,0 +1,107 @@
+package idnlp
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func isTerminator(r rune) bool {
+	return r == '.' || r == '!' || r == '?' || r == '…'
+}
+
+func isClosing(r rune) bool {
+	return strings.ContainsRune("\"'”’)]", r)
+}
+
+func isOpening(r rune) bool {
+	return strings.ContainsRune("\"'“‘([", r)
+}
+
+// SplitSentences splits the text into sentences. A sentence ends at
+// a newline, or at ., !, ? or … followed by optional closing quotes,
+// a space and an uppercase letter, a digit or an opening quote. The
+// dot of an abbreviation or initial, like Kab. or M., does not end
+// the sentence, neither does the dot of a number or an URL
+func SplitSentences(text string) []string {
+	abbrevEnds := make(map[int]bool)
+	for _, tok := range Tokenize(text) {
+		if tok.Kind == TokenAbbrev {
+			abbrevEnds[tok.End] = true
+		}
+	}
+
+	var sentences []string
+	add := func(s string) {
+		if s = strings.TrimSpace(s); s != "" {
+			sentences = append(sentences, s)
+		}
+	}
+
+	start := 0
+	i := 0
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r == '\n' {
+			add(text[start:i])
+			i += size
+			start = i
+			continue
+		}
+
+		if !isTerminator(r) {
+			i += size
+			continue
+		}
+
+		// the whole run of terminators and closing quotes
+		end := i + size
+		terminators := 1
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if isTerminator(r) {
+				terminators++
+			} else if !isClosing(r) {
+				break
+			}
+
+			end += size
+		}
+
+		if terminators == 1 && r == '.' && abbrevEnds[i+size] {
+			i = end
+			continue
+		}
+
+		if isBoundary(text[end:]) {
+			add(text[start:end])
+			start = end
+		}
+
+		i = end
+	}
+
+	add(text[start:])
+
+	return sentences
+}
+
+// isBoundary reports whether the text after the terminator
+// starts a new sentence
+func isBoundary(rest string) bool {
+	trimmed := strings.TrimLeftFunc(rest, func(r rune) bool {
+		return unicode.IsSpace(r) && r != '\n'
+	})
+
+	if trimmed == "" || strings.HasPrefix(trimmed, "\n") {
+		return true
+	}
+
+	if len(trimmed) == len(rest) {
+		return false
+	}
+
+	r, _ := utf8.DecodeRuneInString(trimmed)
+
+	return unicode.IsUpper(r) || unicode.IsDigit(r) || isOpening(r)
+}
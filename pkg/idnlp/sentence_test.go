@@ -0,0 +1,44 @@
+package idnlp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		text      string
+		sentences []string
+	}{
+		{
+			"Banjir melanda Kab. Demak. Warga mengungsi ke Jl. Pemuda No. 12.",
+			[]string{"Banjir melanda Kab. Demak.", "Warga mengungsi ke Jl. Pemuda No. 12."},
+		},
+		{
+			"Hal itu disampaikan Prof. Dr. Ir. H. Ahmad, M.Si. di Jakarta. Ia optimistis.",
+			[]string{"Hal itu disampaikan Prof. Dr. Ir. H. Ahmad, M.Si. di Jakarta.", "Ia optimistis."},
+		},
+		{
+			"\"Kami siap!\" kata Budi. \"Kapan mulai?\" Tanya wartawan.",
+			[]string{"\"Kami siap!\" kata Budi.", "\"Kapan mulai?\"", "Tanya wartawan."},
+		},
+		{
+			"Inflasi naik 2,5 persen menjadi Rp 1.500.000. Baca di detik.com/berita sekarang.",
+			[]string{"Inflasi naik 2,5 persen menjadi Rp 1.500.000.", "Baca di detik.com/berita sekarang."},
+		},
+		{
+			"Tunggu... 3 orang tewas.\nPolisi menyelidiki",
+			[]string{"Tunggu...", "3 orang tewas.", "Polisi menyelidiki"},
+		},
+		{
+			"Saham PT Maju Tbk. naik. nilai itu tinggi.",
+			[]string{"Saham PT Maju Tbk. naik. nilai itu tinggi."},
+		},
+	}
+
+	for _, tt := range tests {
+		if sentences := SplitSentences(tt.text); !reflect.DeepEqual(sentences, tt.sentences) {
+			t.Errorf("%q:\nexpecting %q\ngot %q", tt.text, tt.sentences, sentences)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package idnlp
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+//go:embed data/rootwords.txt
+var rootWordsData []byte
+
+// minStemLength is the minimum length of the word to stem,
+// shorter words is kept as is
+const minStemLength = 4
+
+// maxPrefixes is the maximum number of prefixes removed,
+// like memper- in mempermainkan
+const maxPrefixes = 3
+
+// Dictionary is a set of root words
+type Dictionary map[string]bool
+
+// ParseDictionary parses the words of data, one word per line.
+// Empty lines and lines starting with # is skipped
+func ParseDictionary(data []byte) Dictionary {
+	dict := make(Dictionary)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		word := strings.ToLower(strings.TrimSpace(sc.Text()))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+
+		dict[word] = true
+	}
+
+	return dict
+}
+
+var (
+	defaultStemmer     *Stemmer
+	defaultStemmerOnce sync.Once
+)
+
+// Stemmer is a Nazief-Adriani style affix stemmer. The inflectional
+// suffixes (-lah, -kah, -tah, -pun, then -ku, -mu, -nya), the
+// derivational suffix (-i, -kan, -an) and up to three prefixes (di-,
+// ke-, se-, be-, te-, me-, pe-) is removed, checking the dictionary
+// after each removal. A word is only stemmed to a root in the
+// dictionary, otherwise it is kept as is. Stemmer is safe for
+// concurrent use
+type Stemmer struct {
+	dict Dictionary
+}
+
+// NewStemmer creates a stemmer with the root words of dict
+func NewStemmer(dict Dictionary) *Stemmer {
+	return &Stemmer{dict: dict}
+}
+
+// DefaultStemmer gets the stemmer with the embedded root words
+func DefaultStemmer() *Stemmer {
+	defaultStemmerOnce.Do(func() {
+		defaultStemmer = NewStemmer(ParseDictionary(rootWordsData))
+	})
+
+	return defaultStemmer
+}
+
+// Stem gets the root of the word, in lowercase. A reduplicated word,
+// like anak-anak or buku-bukunya, is stemmed to the root of its parts
+func (st *Stemmer) Stem(word string) string {
+	word = strings.ToLower(word)
+	if first, second, ok := strings.Cut(word, "-"); ok {
+		fs := st.stemWord(first)
+		if fs == st.stemWord(second) {
+			return fs
+		}
+
+		return word
+	}
+
+	return st.stemWord(word)
+}
+
+func (st *Stemmer) stemWord(word string) string {
+	if len(word) < minStemLength || st.dict[word] {
+		return word
+	}
+
+	// inflectional suffixes, particle then possessive pronoun. The
+	// suffix can be a part of the root, like -tah of bantah, so the
+	// word with less suffixes removed is tried first
+	bases := []string{word}
+	base := word
+	for _, suffixes := range [][]string{{"lah", "kah", "tah", "pun"}, {"ku", "mu", "nya"}} {
+		for _, suffix := range suffixes {
+			if trimmed, ok := strings.CutSuffix(base, suffix); ok && len(trimmed) >= minStemLength-1 {
+				base = trimmed
+				bases = append(bases, base)
+				break
+			}
+		}
+
+		if st.dict[base] {
+			return base
+		}
+	}
+
+	for _, base := range bases {
+		if root, ok := st.stemDerived(base); ok {
+			return root
+		}
+	}
+
+	return word
+}
+
+// stemDerived removes the derivational suffix and the prefixes
+// of word, the word without the suffix is tried first. -an is
+// tried before -kan, so perbankan is bank, not ban
+func (st *Stemmer) stemDerived(word string) (string, bool) {
+	type candidate struct {
+		word   string
+		suffix string
+	}
+
+	var cands []candidate
+	for _, suffix := range []string{"an", "kan", "i"} {
+		if trimmed, ok := strings.CutSuffix(word, suffix); ok && len(trimmed) >= minStemLength-1 {
+			cands = append(cands, candidate{trimmed, suffix})
+		}
+	}
+
+	cands = append(cands, candidate{word, ""})
+
+	for _, cand := range cands {
+		if st.dict[cand.word] {
+			return cand.word, true
+		}
+
+		if root, ok := st.removePrefixes(cand.word, cand.suffix, "", 0); ok {
+			return root, true
+		}
+	}
+
+	return "", false
+}
+
+// disallowedConfixes is the prefix and suffix pairs that
+// is not used together
+var disallowedConfixes = map[string]bool{
+	"be-i":   true,
+	"di-an":  true,
+	"ke-i":   true,
+	"ke-kan": true,
+	"me-an":  true,
+	"se-i":   true,
+	"se-kan": true,
+	"te-an":  true,
+}
+
+// removePrefixes removes the prefixes of word recursively until
+// it is a root word. prev is the type of the previously removed
+// prefix, the same prefix is not removed twice
+func (st *Stemmer) removePrefixes(word, suffix, prev string, depth int) (string, bool) {
+	if depth >= maxPrefixes {
+		return "", false
+	}
+
+	for _, rule := range prefixRules {
+		if rule.prefix == prev || !strings.HasPrefix(word, rule.prefix) {
+			continue
+		}
+
+		if depth == 0 && suffix != "" && disallowedConfixes[rule.prefix+"-"+suffix] {
+			continue
+		}
+
+		for _, stripped := range rule.strip(word) {
+			if len(stripped) < 2 {
+				continue
+			}
+
+			if st.dict[stripped] {
+				return stripped, true
+			}
+
+			if root, ok := st.removePrefixes(stripped, suffix, rule.prefix, depth+1); ok {
+				return root, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+type prefixRule struct {
+	// prefix is the type of the prefix, like me of meng-
+	prefix string
+	// strip gets the possible words without the prefix,
+	// restoring the dropped first letter of the root
+	strip func(word string) []string
+}
+
+var prefixRules = []prefixRule{
+	{"di", simplePrefix("di")},
+	{"ke", simplePrefix("ke")},
+	{"se", simplePrefix("se")},
+	{"me", stripMe},
+	{"pe", stripPe},
+	{"be", stripBe},
+	{"te", stripTe},
+}
+
+func simplePrefix(prefix string) func(string) []string {
+	return func(word string) []string {
+		return []string{strings.TrimPrefix(word, prefix)}
+	}
+}
+
+func isVowel(b byte) bool {
+	return strings.IndexByte("aiueo", b) != -1
+}
+
+// at gets the byte of word at i, 0 when i is out of range
+func at(word string, i int) byte {
+	if i < len(word) {
+		return word[i]
+	}
+
+	return 0
+}
+
+// stripNasal strips the nasal form of me- and pe-, like meng-,
+// meny-, mem- and men-. e is me or pe
+func stripNasal(word, e string) []string {
+	rest := strings.TrimPrefix(word, e)
+
+	switch {
+	// menge- before monosyllabic root, like mengecat, or
+	// before root starting with ke, like mengeluarkan
+	case strings.HasPrefix(rest, "nge") && !isVowel(at(rest, 3)):
+		return []string{"k" + rest[2:], rest[3:], rest[2:]}
+
+	case strings.HasPrefix(rest, "ng"):
+		r := rest[2:]
+		if isVowel(at(r, 0)) {
+			return []string{r, "k" + r}
+		}
+
+		return []string{r}
+
+	// meny- is me- before root starting with s, or with ny
+	// like menyanyi
+	case strings.HasPrefix(rest, "ny") && isVowel(at(rest, 2)):
+		return []string{"s" + rest[2:], rest}
+
+	// the root starting with m is tried first, like memulai
+	// is mula, not pula
+	case strings.HasPrefix(rest, "m"):
+		r := rest[1:]
+		if isVowel(at(r, 0)) {
+			return []string{"m" + r, "p" + r}
+		}
+
+		return []string{r}
+
+	case strings.HasPrefix(rest, "n"):
+		r := rest[1:]
+		if isVowel(at(r, 0)) {
+			return []string{"t" + r, "n" + r}
+		}
+
+		return []string{r}
+	}
+
+	return nil
+}
+
+func stripMe(word string) []string {
+	rest := strings.TrimPrefix(word, "me")
+	switch c := at(rest, 0); c {
+	case 'l', 'r', 'w', 'y':
+		return []string{rest}
+
+	case 'm', 'n':
+		return stripNasal(word, "me")
+	}
+
+	return nil
+}
+
+func stripPe(word string) []string {
+	rest := strings.TrimPrefix(word, "pe")
+
+	// pelajar is pe- and ajar
+	if strings.HasPrefix(rest, "lajar") {
+		return []string{rest[1:]}
+	}
+
+	switch c := at(rest, 0); {
+	case c == 'm' || c == 'n':
+		return stripNasal(word, "pe")
+
+	case c == 'r':
+		r := rest[1:]
+		if isVowel(at(r, 0)) {
+			return []string{r, rest}
+		}
+
+		return []string{r}
+
+	case c != 0 && !isVowel(c):
+		return []string{rest}
+	}
+
+	return nil
+}
+
+func stripBe(word string) []string {
+	rest := strings.TrimPrefix(word, "be")
+
+	// belajar is be- and ajar
+	if strings.HasPrefix(rest, "lajar") {
+		return []string{rest[1:]}
+	}
+
+	switch c := at(rest, 0); {
+	case c == 'r':
+		r := rest[1:]
+		if isVowel(at(r, 0)) {
+			return []string{r, rest}
+		}
+
+		return []string{r}
+
+	// be- before root with -er- first syllable, like bekerja
+	case c != 0 && !isVowel(c) && strings.HasPrefix(rest[1:], "er"):
+		return []string{rest}
+	}
+
+	return nil
+}
+
+func stripTe(word string) []string {
+	rest := strings.TrimPrefix(word, "te")
+	if at(rest, 0) != 'r' {
+		return nil
+	}
+
+	r := rest[1:]
+	if isVowel(at(r, 0)) {
+		return []string{r, rest}
+	}
+
+	return []string{r}
+}
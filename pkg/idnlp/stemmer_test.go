@@ -0,0 +1,92 @@
+package idnlp
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		root string
+	}{
+		{"menyapu", "sapu"},
+		{"memukul", "pukul"},
+		{"menulis", "tulis"},
+		{"mengambil", "ambil"},
+		{"mengecat", "cat"},
+		{"mengebom", "bom"},
+		{"membangun", "bangun"},
+		{"perekonomian", "ekonomi"},
+		{"penghasilan", "hasil"},
+		{"dimainkan", "main"},
+		{"mempermainkan", "main"},
+		{"pelajaran", "ajar"},
+		{"belajar", "ajar"},
+		{"diresmikan", "resmi"},
+		{"meresmikan", "resmi"},
+		{"ditangkap", "tangkap"},
+		{"kepolisian", "polisi"},
+		{"memerintahkan", "perintah"},
+		{"pertanyaan", "tanya"},
+		{"bersama-sama", "sama"},
+		{"menyelesaikan", "selesai"},
+		{"kesehatan", "sehat"},
+		{"pendidikan", "didik"},
+		{"penyidik", "sidik"},
+		{"tersangka", "sangka"},
+		{"mengatakan", "kata"},
+		{"terjadinya", "jadi"},
+		{"sebaiknya", "baik"},
+		{"buku-bukunya", "buku"},
+		{"anak-anak", "anak"},
+		{"rumahnya", "rumah"},
+		{"makanlah", "makan"},
+		{"bekerja", "kerja"},
+		{"pertanian", "tani"},
+		{"perdagangan", "dagang"},
+		{"terbakar", "bakar"},
+		{"kepunyaan", "punya"},
+		{"menghadapi", "hadap"},
+		{"penggalian", "gali"},
+		{"diangkat", "angkat"},
+		{"penyelidikan", "selidik"},
+		{"pengiriman", "kirim"},
+		{"Pencarian", "cari"},
+		{"menyatakan", "nyata"},
+		{"menyanyikan", "nyanyi"},
+		{"pertandingan", "tanding"},
+		{"kecelakaan", "celaka"},
+		{"pemerintah", "perintah"},
+		{"kegiatan", "giat"},
+		{"membantah", "bantah"},
+		{"memulai", "mula"},
+		{"memarahi", "marah"},
+		{"pengembangan", "kembang"},
+		{"mengeluarkan", "keluar"},
+		{"penyeberangan", "seberang"},
+		{"mengecewakan", "kecewa"},
+		{"perbankan", "bank"},
+		{"perbaikan", "baik"},
+		// root words and unknown words is kept
+		{"makan", "makan"},
+		{"jokowi", "jokowi"},
+		{"demak", "demak"},
+		{"ada", "ada"},
+	}
+
+	st := DefaultStemmer()
+	for _, tt := range tests {
+		if root := st.Stem(tt.word); root != tt.root {
+			t.Errorf("%s: expecting %s, got %s", tt.word, tt.root, root)
+		}
+	}
+}
+
+func TestStemCustomDictionary(t *testing.T) {
+	st := NewStemmer(ParseDictionary([]byte("# roots\nsawit\n\nTambang\n")))
+	if root := st.Stem("pertambangan"); root != "tambang" {
+		t.Errorf("expecting tambang, got %s", root)
+	}
+
+	if root := st.Stem("perkebunan"); root != "perkebunan" {
+		t.Errorf("expecting unknown root to be kept, got %s", root)
+	}
+}
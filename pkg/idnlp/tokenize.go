@@ -0,0 +1,190 @@
+package idnlp
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type TokenKind int
+
+const (
+	TokenWord TokenKind = iota
+	TokenNumber
+	// TokenAbbrev is an abbreviation with its dot, like Kab. or S.H.
+	TokenAbbrev
+)
+
+// Token is a word of the text, Start and End is the byte
+// offsets of the token in the text
+type Token struct {
+	Text  string
+	Start int
+	End   int
+	Kind  TokenKind
+}
+
+// abbreviations is the lowercased Indonesian abbreviations written
+// with a dot, the dot does not end the sentence
+var abbreviations = map[string]bool{
+	// places and addresses
+	"kab": true, "kec": true, "kel": true, "ds": true, "jl": true, "jln": true,
+	"gg": true, "no": true, "prov": true, "rt": true, "rw": true,
+	// titles and honorifics
+	"dr": true, "drs": true, "dra": true, "ir": true, "prof": true, "h": true,
+	"hj": true, "st": true, "sdr": true, "sdri": true, "bpk": true, "bp": true,
+	"yth": true, "kh": true, "ny": true, "tn": true, "sh": true,
+	"se": true, "mm": true, "mh": true, "msi": true, "mpd": true, "spd": true,
+	"skom": true, "ssos": true, "phd": true,
+	// ranks
+	"jend": true, "brigjen": true, "mayjen": true, "letjen": true, "kapt": true,
+	"akbp": true, "kombes": true, "irjen": true, "komjen": true, "kol": true,
+	"letkol": true, "ipda": true, "iptu": true, "aiptu": true,
+	"bripka": true, "briptu": true, "bripda": true, "serka": true, "sertu": true,
+	// companies
+	"tbk": true, "pt": true, "cv": true, "ud": true,
+	// misc
+	"rp": true, "dll": true, "dsb": true, "dst": true, "tgl": true, "hlm": true,
+	"vs": true, "dkk": true, "telp": true, "hp": true, "sbg": true, "yg": true,
+	"dgn": true, "utk": true, "tsb": true,
+}
+
+// IsAbbreviation reports whether the word, without its dot,
+// is a known abbreviation
+func IsAbbreviation(word string) bool {
+	return abbreviations[strings.ToLower(strings.TrimSuffix(word, "."))]
+}
+
+// Tokenize splits the text into word, number and abbreviation
+// tokens. Hyphen and apostrophe inside a word, like anak-anak or
+// Jum'at, is kept in the word. Dot and comma between digits, like
+// 1.500 or 2,5, is kept in the number. The known abbreviations and
+// initials, like Jl. or S.H., keeps their dot
+func Tokenize(text string) []Token {
+	var tokens []Token
+	i := 0
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !isWordRune(r) {
+			i += size
+			continue
+		}
+
+		start := i
+		i = scanWord(text, i)
+		tok := Token{Text: text[start:i], Start: start, End: i, Kind: TokenWord}
+		if isNumber(tok.Text) {
+			tok.Kind = TokenNumber
+		}
+
+		if tok.Kind == TokenWord && at(text, i) == '.' {
+			if end, ok := scanAbbrev(text, start, i); ok {
+				tok.Text = text[start:end]
+				tok.End = end
+				tok.Kind = TokenAbbrev
+				i = end
+			}
+		}
+
+		tokens = append(tokens, tok)
+	}
+
+	return tokens
+}
+
+// Words gets the text of the word tokens of the text, in lowercase
+func Words(text string) []string {
+	var words []string
+	for _, tok := range Tokenize(text) {
+		if tok.Kind == TokenWord {
+			words = append(words, strings.ToLower(tok.Text))
+		}
+	}
+
+	return words
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isNumber reports whether s is digits, with its separators
+func isNumber(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) && r != '.' && r != ',' && r != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// scanWord scans the word starting at i and gets its end
+func scanWord(text string, i int) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if isWordRune(r) {
+			i += size
+			continue
+		}
+
+		// joiner between two word runes
+		next, _ := utf8.DecodeRuneInString(text[i+size:])
+		if i+size >= len(text) || !isWordRune(next) {
+			break
+		}
+
+		prev, _ := utf8.DecodeLastRuneInString(text[:i])
+		switch {
+		case r == '-' || r == '\'' || r == '’':
+		case (r == '.' || r == ',') && unicode.IsDigit(prev) && unicode.IsDigit(next):
+		default:
+			return i
+		}
+
+		i += size
+	}
+
+	return i
+}
+
+// scanAbbrev checks whether the word from start to end followed
+// by a dot is an abbreviation, either a known one or initials
+// like S.H. or M., and gets the end after the dot
+func scanAbbrev(text string, start, end int) (int, bool) {
+	word := text[start:end]
+	if abbreviations[strings.ToLower(word)] {
+		return end + 1, true
+	}
+
+	// initials is single letters each followed by a dot
+	i := start
+	n := 0
+	for {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !unicode.IsLetter(r) || at(text, i+size) != '.' {
+			break
+		}
+
+		next, _ := utf8.DecodeRuneInString(text[i+size+1:])
+		i += size + 1
+		n++
+		if !unicode.IsLetter(next) {
+			break
+		}
+	}
+
+	if n == 0 || i <= end {
+		return 0, false
+	}
+
+	// single initial must be uppercase, like M. in M. Nasir
+	if n == 1 {
+		r, _ := utf8.DecodeRuneInString(word)
+		if !unicode.IsUpper(r) {
+			return 0, false
+		}
+	}
+
+	return i, true
+}
@@ -0,0 +1,47 @@
+package idnlp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	text := "Kapolres AKBP H. Budi S.H. meninjau Jl. Sudirman No. 5, anak-anak Jum'at, Rp1.500.000 atau 2,5 persen."
+	expecting := []Token{
+		{"Kapolres", 0, 8, TokenWord},
+		{"AKBP", 9, 13, TokenWord},
+		{"H.", 14, 16, TokenAbbrev},
+		{"Budi", 17, 21, TokenWord},
+		{"S.H.", 22, 26, TokenAbbrev},
+		{"meninjau", 27, 35, TokenWord},
+		{"Jl.", 36, 39, TokenAbbrev},
+		{"Sudirman", 40, 48, TokenWord},
+		{"No.", 49, 52, TokenAbbrev},
+		{"5", 53, 54, TokenNumber},
+		{"anak-anak", 56, 65, TokenWord},
+		{"Jum'at", 66, 72, TokenWord},
+		{"Rp1.500.000", 74, 85, TokenWord},
+		{"atau", 86, 90, TokenWord},
+		{"2,5", 91, 94, TokenNumber},
+		{"persen", 95, 101, TokenWord},
+	}
+
+	tokens := Tokenize(text)
+	if !reflect.DeepEqual(tokens, expecting) {
+		t.Fatalf("unexpected tokens\n%+v", tokens)
+	}
+
+	for _, tok := range tokens {
+		if text[tok.Start:tok.End] != tok.Text {
+			t.Errorf("%s: wrong offsets %d-%d", tok.Text, tok.Start, tok.End)
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	words := Words("Warga Kab. Bogor, 17 orang, pergi ke Jakarta.")
+	expecting := []string{"warga", "bogor", "orang", "pergi", "ke", "jakarta"}
+	if !reflect.DeepEqual(words, expecting) {
+		t.Errorf("expecting %v, got %v", expecting, words)
+	}
+}
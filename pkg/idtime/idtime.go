@@ -0,0 +1,407 @@
+// Package idtime parses date and time written the Indonesian way, as
+// found on the Indonesian news portals. It understands day and month
+// names and their abbreviations, the WIB, WITA and WIT timezones and
+// relative expressions like "2 jam yang lalu":
+//
+//	Senin, 12 Agu 2024 10:30 WIB
+//	12 Agustus 2024, 10.30 WITA
+//	12/08/2024, 10:30 WIB
+//	Kamis, 1 Feb 2024 pukul 07.05 WIT
+//	2 jam yang lalu
+//	kemarin pukul 19.00
+//
+// ISO 8601 and unix timestamps is accepted as well, so a date from
+// any source can be passed as is.
+package idtime
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Indonesian timezones
+var (
+	WIB  = time.FixedZone("WIB", 7*60*60)
+	WITA = time.FixedZone("WITA", 8*60*60)
+	WIT  = time.FixedZone("WIT", 9*60*60)
+)
+
+var ErrInvalid = errors.New("invalid date time")
+
+var months = map[string]time.Month{
+	"januari":   time.January,
+	"jan":       time.January,
+	"january":   time.January,
+	"februari":  time.February,
+	"pebruari":  time.February,
+	"feb":       time.February,
+	"peb":       time.February,
+	"february":  time.February,
+	"maret":     time.March,
+	"mar":       time.March,
+	"march":     time.March,
+	"april":     time.April,
+	"apr":       time.April,
+	"mei":       time.May,
+	"may":       time.May,
+	"juni":      time.June,
+	"jun":       time.June,
+	"june":      time.June,
+	"juli":      time.July,
+	"jul":       time.July,
+	"july":      time.July,
+	"agustus":   time.August,
+	"agu":       time.August,
+	"agt":       time.August,
+	"ags":       time.August,
+	"agus":      time.August,
+	"aug":       time.August,
+	"august":    time.August,
+	"september": time.September,
+	"sep":       time.September,
+	"sept":      time.September,
+	"oktober":   time.October,
+	"okt":       time.October,
+	"oct":       time.October,
+	"october":   time.October,
+	"november":  time.November,
+	"nov":       time.November,
+	"nop":       time.November,
+	"desember":  time.December,
+	"des":       time.December,
+	"dec":       time.December,
+	"december":  time.December,
+}
+
+var zones = map[string]*time.Location{
+	"wib":  WIB,
+	"wita": WITA,
+	"wit":  WIT,
+	"utc":  time.UTC,
+	"gmt":  time.UTC,
+}
+
+// Month parses an Indonesian or English month name or its abbreviation
+func Month(name string) (time.Month, bool) {
+	m, ok := months[strings.Trim(strings.ToLower(name), ". ")]
+	return m, ok
+}
+
+// Parser parses date time relative to its clock
+type Parser struct {
+	// Location is the location of date time without timezone,
+	// defaults to WIB
+	Location *time.Location
+	// Now is the reference clock of relative expressions,
+	// defaults to time.Now
+	Now func() time.Time
+}
+
+var defaultParser = Parser{}
+
+// Parse parses the date time with the default parser, that is
+// in WIB and relative to the current time
+func Parse(str string) (time.Time, error) {
+	return defaultParser.Parse(str)
+}
+
+// ParseAt parses the date time in WIB, relative to ref
+func ParseAt(str string, ref time.Time) (time.Time, error) {
+	p := Parser{Now: func() time.Time { return ref }}
+	return p.Parse(str)
+}
+
+// ParseInLocation parses the date time in loc, relative to
+// the current time
+func ParseInLocation(str string, loc *time.Location) (time.Time, error) {
+	p := Parser{Location: loc}
+	return p.Parse(str)
+}
+
+func (p Parser) location() *time.Location {
+	if p.Location == nil {
+		return WIB
+	}
+
+	return p.Location
+}
+
+func (p Parser) now() time.Time {
+	if p.Now == nil {
+		return time.Now().In(p.location())
+	}
+
+	return p.Now().In(p.location())
+}
+
+var isoLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+var (
+	unixRgx     = regexp.MustCompile(`^\d{10}(\d{3})?$`)
+	spaceRgx    = regexp.MustCompile(`\s+`)
+	relativeRgx = regexp.MustCompile(`^(?:sekitar\s+)?(\d+|se|beberapa)\s*(detik|menit|jam|hari|minggu|pekan|bulan|tahun)\s+(?:yang\s+)?(lalu|silam)$`)
+	dayRgx      = regexp.MustCompile(`^(hari ini|kemarin lusa|kemarin|tadi|barusan|baru saja)\b`)
+
+	numDateRgx  = regexp.MustCompile(`\b(\d{1,4})([/.-])(\d{1,2})([/.-])(\d{2,4})\b`)
+	textDateRgx = regexp.MustCompile(`\b(\d{1,2})(?:\s+|-)([a-z]+)\.?(?:\s+|-)(\d{4})\b`)
+	noYearRgx   = regexp.MustCompile(`\b(\d{1,2})\s+([a-z]+)\b`)
+	clockRgx    = regexp.MustCompile(`\b(\d{1,2})[:.](\d{2})(?:[:.](\d{2}))?(?:\s*(am|pm))?\b`)
+	zoneRgx     = regexp.MustCompile(`\b(wib|wita|wit|utc|gmt)(?:\s*([+-])(\d{1,2})(?::?(\d{2}))?)?\b`)
+)
+
+// Parse parses the date time
+func (p Parser) Parse(str string) (time.Time, error) {
+	str = strings.ReplaceAll(str, " ", " ")
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return time.Time{}, ErrInvalid
+	}
+
+	for _, layout := range isoLayouts {
+		if t, err := time.ParseInLocation(layout, str, p.location()); err == nil {
+			return t, nil
+		}
+	}
+
+	if unixRgx.MatchString(str) {
+		n, _ := strconv.ParseInt(str, 10, 64)
+		if len(str) == 13 {
+			return time.UnixMilli(n).In(p.location()), nil
+		}
+
+		return time.Unix(n, 0).In(p.location()), nil
+	}
+
+	lower := strings.ToLower(spaceRgx.ReplaceAllString(str, " "))
+	if t, ok := p.parseRelative(lower); ok {
+		return t, nil
+	}
+
+	t, err := p.parseAbsolute(lower)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q", err, str)
+	}
+
+	return t, nil
+}
+
+func (p Parser) parseRelative(str string) (time.Time, bool) {
+	now := p.now()
+	str = strings.TrimSuffix(str, ".")
+
+	if match := relativeRgx.FindStringSubmatch(str); match != nil {
+		n := 1
+		switch match[1] {
+		case "se":
+		case "beberapa":
+			// "beberapa" is vague, but usually means
+			// a few of the unit
+			n = 3
+		default:
+			n, _ = strconv.Atoi(match[1])
+		}
+
+		switch match[2] {
+		case "detik":
+			return now.Add(-time.Duration(n) * time.Second), true
+		case "menit":
+			return now.Add(-time.Duration(n) * time.Minute), true
+		case "jam":
+			return now.Add(-time.Duration(n) * time.Hour), true
+		case "hari":
+			return now.AddDate(0, 0, -n), true
+		case "minggu", "pekan":
+			return now.AddDate(0, 0, -7*n), true
+		case "bulan":
+			return now.AddDate(0, -n, 0), true
+		case "tahun":
+			return now.AddDate(-n, 0, 0), true
+		}
+	}
+
+	match := dayRgx.FindStringSubmatch(str)
+	if match == nil {
+		return time.Time{}, false
+	}
+
+	loc := p.location()
+	if zone := zoneRgx.FindStringSubmatch(str); zone != nil {
+		loc = zoneLocation(zone)
+	}
+
+	day := now.In(loc)
+	switch match[1] {
+	case "kemarin":
+		day = day.AddDate(0, 0, -1)
+	case "kemarin lusa":
+		day = day.AddDate(0, 0, -2)
+	}
+
+	clock := clockRgx.FindStringSubmatch(str[len(match[0]):])
+	if clock == nil {
+		switch match[1] {
+		case "barusan", "baru saja", "tadi":
+			return now, true
+		}
+	}
+
+	hour, min, sec := 0, 0, 0
+	if clock != nil {
+		var ok bool
+		if hour, min, sec, ok = parseClock(clock); !ok {
+			return time.Time{}, false
+		}
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, min, sec, 0, loc), true
+}
+
+func (p Parser) parseAbsolute(str string) (time.Time, error) {
+	loc := p.location()
+	if zone := zoneRgx.FindStringSubmatch(str); zone != nil {
+		loc = zoneLocation(zone)
+		str = strings.Replace(str, zone[0], " ", 1)
+	}
+
+	var year, day int
+	var month time.Month
+	var ok bool
+
+	noYear := false
+	for _, match := range numDateRgx.FindAllStringSubmatch(str, -1) {
+		if year, month, day, ok = parseNumDate(match); ok {
+			str = strings.Replace(str, match[0], " ", 1)
+			break
+		}
+	}
+
+	if !ok {
+		for _, match := range textDateRgx.FindAllStringSubmatch(str, -1) {
+			if month, ok = Month(match[2]); ok {
+				str = strings.Replace(str, match[0], " ", 1)
+				day, _ = strconv.Atoi(match[1])
+				year, _ = strconv.Atoi(match[3])
+				break
+			}
+		}
+	}
+
+	if !ok {
+		for _, match := range noYearRgx.FindAllStringSubmatch(str, -1) {
+			if month, ok = Month(match[2]); ok {
+				str = strings.Replace(str, match[0], " ", 1)
+				day, _ = strconv.Atoi(match[1])
+				year = p.now().In(loc).Year()
+				noYear = true
+				break
+			}
+		}
+	}
+
+	if !ok {
+		return time.Time{}, ErrInvalid
+	}
+
+	hour, min, sec := 0, 0, 0
+	if clock := clockRgx.FindStringSubmatch(str); clock != nil {
+		if hour, min, sec, ok = parseClock(clock); !ok {
+			return time.Time{}, ErrInvalid
+		}
+	}
+
+	t := time.Date(year, month, day, hour, min, sec, 0, loc)
+	// time.Date normalizes the overflowing date, like
+	// 31 February, which is an invalid date
+	if t.Day() != day || t.Month() != month {
+		return time.Time{}, ErrInvalid
+	}
+
+	// date without year, like "12 Agu 10:30", is the latest
+	// date that is not in the future
+	if noYear && t.After(p.now().AddDate(0, 0, 1)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+
+	return t, nil
+}
+
+// parseNumDate parses numeric date, which is day first unless the
+// year is written first, like 12/08/2024 or 2024-08-12
+func parseNumDate(match []string) (int, time.Month, int, bool) {
+	if match[2] != match[4] {
+		return 0, 0, 0, false
+	}
+
+	a, _ := strconv.Atoi(match[1])
+	b, _ := strconv.Atoi(match[3])
+	c, _ := strconv.Atoi(match[5])
+
+	year, month, day := c, b, a
+	if len(match[1]) == 4 {
+		year, day = a, c
+	} else if len(match[5]) != 4 {
+		if len(match[5]) != 2 {
+			return 0, 0, 0, false
+		}
+
+		year = 2000 + c
+	}
+
+	if month < 1 || month > 12 {
+		return 0, 0, 0, false
+	}
+
+	return year, time.Month(month), day, true
+}
+
+func parseClock(match []string) (int, int, int, bool) {
+	hour, _ := strconv.Atoi(match[1])
+	min, _ := strconv.Atoi(match[2])
+	sec, _ := strconv.Atoi(match[3])
+
+	switch match[4] {
+	case "am":
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+
+	if hour > 23 || min > 59 || sec > 59 {
+		return 0, 0, 0, false
+	}
+
+	return hour, min, sec, true
+}
+
+// zoneLocation gets the location of zoneRgx match, the offset
+// is only used for UTC and GMT, like GMT+7
+func zoneLocation(match []string) *time.Location {
+	loc := zones[match[1]]
+	if match[2] == "" || loc != time.UTC {
+		return loc
+	}
+
+	hour, _ := strconv.Atoi(match[3])
+	min, _ := strconv.Atoi(match[4])
+	offset := hour*60*60 + min*60
+	if match[2] == "-" {
+		offset = -offset
+	}
+
+	return time.FixedZone(strings.ToUpper(match[0]), offset)
+}
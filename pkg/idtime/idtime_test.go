@@ -0,0 +1,214 @@
+package idtime
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// ref is Senin, 12 Agustus 2024 15:00 WIB
+var ref = time.Date(2024, 8, 12, 15, 0, 0, 0, WIB)
+
+func TestParseAbsolute(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		// detik
+		{"Senin, 12 Agu 2024 10:30 WIB", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		{"Senin, 12 Agu 2024 10:30 WITA", time.Date(2024, 8, 12, 10, 30, 0, 0, WITA)},
+		{"Senin, 12 Agu 2024 10:30 WIT", time.Date(2024, 8, 12, 10, 30, 0, 0, WIT)},
+		// liputan6
+		{"12 Agu 2024, 10:30 WIB", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		// kompas
+		{"12/08/2024, 10:30 WIB", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		{"Kompas.com - 12/08/2024, 10:30 WIB", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		// full names, dots as clock separator and "pukul"
+		{"Kamis, 1 Februari 2024 pukul 07.05 WIT", time.Date(2024, 2, 1, 7, 5, 0, 0, WIT)},
+		{"Jumat, 13 September 2024 | 19.45 WIB", time.Date(2024, 9, 13, 19, 45, 0, 0, WIB)},
+		{"Jum'at, 13 Sept 2024 19:45:30 WIB", time.Date(2024, 9, 13, 19, 45, 30, 0, WIB)},
+		{"Minggu, 31 Desember 2023 23:59 WIB", time.Date(2023, 12, 31, 23, 59, 0, 0, WIB)},
+		{"Ahad, 5 Mei 2024 08:00 WIB", time.Date(2024, 5, 5, 8, 0, 0, 0, WIB)},
+		{"SABTU, 17 AGUSTUS 2024 10:00 WIB", time.Date(2024, 8, 17, 10, 0, 0, 0, WIB)},
+		{"10:30 WIB, 12 Agustus 2024", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		{"12-Agu-2024 10:30", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		{"1 Feb. 2024", time.Date(2024, 2, 1, 0, 0, 0, 0, WIB)},
+		{"  12  Agustus   2024  ", time.Date(2024, 8, 12, 0, 0, 0, 0, WIB)},
+		{"12 Agu 2024 10:30 WIB", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		// every month and its abbreviations
+		{"1 Januari 2024", time.Date(2024, 1, 1, 0, 0, 0, 0, WIB)},
+		{"1 Jan 2024", time.Date(2024, 1, 1, 0, 0, 0, 0, WIB)},
+		{"1 Pebruari 2024", time.Date(2024, 2, 1, 0, 0, 0, 0, WIB)},
+		{"1 Peb 2024", time.Date(2024, 2, 1, 0, 0, 0, 0, WIB)},
+		{"1 Maret 2024", time.Date(2024, 3, 1, 0, 0, 0, 0, WIB)},
+		{"1 Mar 2024", time.Date(2024, 3, 1, 0, 0, 0, 0, WIB)},
+		{"1 April 2024", time.Date(2024, 4, 1, 0, 0, 0, 0, WIB)},
+		{"1 Apr 2024", time.Date(2024, 4, 1, 0, 0, 0, 0, WIB)},
+		{"1 Mei 2024", time.Date(2024, 5, 1, 0, 0, 0, 0, WIB)},
+		{"1 Juni 2024", time.Date(2024, 6, 1, 0, 0, 0, 0, WIB)},
+		{"1 Jun 2024", time.Date(2024, 6, 1, 0, 0, 0, 0, WIB)},
+		{"1 Juli 2024", time.Date(2024, 7, 1, 0, 0, 0, 0, WIB)},
+		{"1 Jul 2024", time.Date(2024, 7, 1, 0, 0, 0, 0, WIB)},
+		{"1 Agt 2024", time.Date(2024, 8, 1, 0, 0, 0, 0, WIB)},
+		{"1 Ags 2024", time.Date(2024, 8, 1, 0, 0, 0, 0, WIB)},
+		{"1 Sep 2024", time.Date(2024, 9, 1, 0, 0, 0, 0, WIB)},
+		{"1 Oktober 2024", time.Date(2024, 10, 1, 0, 0, 0, 0, WIB)},
+		{"1 Okt 2024", time.Date(2024, 10, 1, 0, 0, 0, 0, WIB)},
+		{"1 November 2024", time.Date(2024, 11, 1, 0, 0, 0, 0, WIB)},
+		{"1 Nop 2024", time.Date(2024, 11, 1, 0, 0, 0, 0, WIB)},
+		{"1 Des 2024", time.Date(2024, 12, 1, 0, 0, 0, 0, WIB)},
+		// english month names
+		{"Monday, 12 Aug 2024 10:30 WIB", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		{"12 October 2024", time.Date(2024, 10, 12, 0, 0, 0, 0, WIB)},
+		// numeric dates
+		{"12-08-2024 10:30", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		{"12.08.2024 10.30", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		{"2024-08-12 10:30:15", time.Date(2024, 8, 12, 10, 30, 15, 0, WIB)},
+		{"2024/08/12", time.Date(2024, 8, 12, 0, 0, 0, 0, WIB)},
+		{"12/08/24 10:30", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		// 12-hour clock
+		{"12 Agu 2024 10:30 PM", time.Date(2024, 8, 12, 22, 30, 0, 0, WIB)},
+		{"12 Agu 2024 12:15 am", time.Date(2024, 8, 12, 0, 15, 0, 0, WIB)},
+		// UTC and GMT offsets
+		{"12 Agu 2024 03:30 UTC", time.Date(2024, 8, 12, 3, 30, 0, 0, time.UTC)},
+		{"12 Agu 2024 10:30 GMT+7", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		{"12 Agu 2024 10:30 GMT+07:00", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		// ISO 8601, RFC 1123 and unix
+		{"2024-08-12T10:30:00+07:00", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		{"2024-08-12T03:30:00Z", time.Date(2024, 8, 12, 3, 30, 0, 0, time.UTC)},
+		{"2024-08-12T10:30:00.123+07:00", time.Date(2024, 8, 12, 10, 30, 0, 123000000, WIB)},
+		{"2024-08-12T10:30:00", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		{"Mon, 12 Aug 2024 03:30:00 GMT", time.Date(2024, 8, 12, 3, 30, 0, 0, time.UTC)},
+		{"1723433400", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		{"1723433400000", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		// without year, the latest date that is not in the future
+		{"12 Agu 10:30", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		{"25 Des 08:00", time.Date(2023, 12, 25, 8, 0, 0, 0, WIB)},
+		{"Jumat, 9 Agu", time.Date(2024, 8, 9, 0, 0, 0, 0, WIB)},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAt(tt.in, ref)
+		if err != nil {
+			t.Errorf("%q: unexpected error %s", tt.in, err.Error())
+			continue
+		}
+
+		if !got.Equal(tt.want) {
+			t.Errorf("%q: expecting %s, got %s", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestParseRelative(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"baru saja", ref},
+		{"Barusan", ref},
+		{"tadi", ref},
+		{"30 detik yang lalu", ref.Add(-30 * time.Second)},
+		{"5 menit lalu", ref.Add(-5 * time.Minute)},
+		{"2 jam yang lalu", ref.Add(-2 * time.Hour)},
+		{"2 Jam yang Lalu", ref.Add(-2 * time.Hour)},
+		{"2jam lalu", ref.Add(-2 * time.Hour)},
+		{"sejam yang lalu", ref.Add(-time.Hour)},
+		{"sekitar 3 jam yang lalu", ref.Add(-3 * time.Hour)},
+		{"beberapa menit yang lalu", ref.Add(-3 * time.Minute)},
+		{"3 hari yang lalu", time.Date(2024, 8, 9, 15, 0, 0, 0, WIB)},
+		{"sehari lalu", time.Date(2024, 8, 11, 15, 0, 0, 0, WIB)},
+		{"2 minggu lalu", time.Date(2024, 7, 29, 15, 0, 0, 0, WIB)},
+		{"sepekan lalu", time.Date(2024, 8, 5, 15, 0, 0, 0, WIB)},
+		{"sebulan yang lalu", time.Date(2024, 7, 12, 15, 0, 0, 0, WIB)},
+		{"2 tahun silam", time.Date(2022, 8, 12, 15, 0, 0, 0, WIB)},
+		{"hari ini", time.Date(2024, 8, 12, 0, 0, 0, 0, WIB)},
+		{"hari ini, 10:30 WIB", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		{"tadi pukul 07.00", time.Date(2024, 8, 12, 7, 0, 0, 0, WIB)},
+		{"kemarin", time.Date(2024, 8, 11, 0, 0, 0, 0, WIB)},
+		{"Kemarin pukul 19.00", time.Date(2024, 8, 11, 19, 0, 0, 0, WIB)},
+		{"kemarin, 20:15 WITA", time.Date(2024, 8, 11, 20, 15, 0, 0, WITA)},
+		{"kemarin lusa 08:00", time.Date(2024, 8, 10, 8, 0, 0, 0, WIB)},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAt(tt.in, ref)
+		if err != nil {
+			t.Errorf("%q: unexpected error %s", tt.in, err.Error())
+			continue
+		}
+
+		if !got.Equal(tt.want) {
+			t.Errorf("%q: expecting %s, got %s", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"   ",
+		"bukan tanggal",
+		"31 Februari 2024",
+		"30/02/2024",
+		"12/13/2024",
+		"12 Foo 2024",
+		"12 Agu 2024 25:00",
+		"12 Agu 2024 10:61",
+		"12/08-2024",
+		"2 jam lagi",
+	}
+
+	for _, in := range tests {
+		if got, err := ParseAt(in, ref); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%q: expecting ErrInvalid, got %s, %v", in, got, err)
+		}
+	}
+}
+
+func TestParserLocation(t *testing.T) {
+	p := Parser{
+		Location: WITA,
+		Now:      func() time.Time { return ref },
+	}
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"12 Agu 2024 10:30", time.Date(2024, 8, 12, 10, 30, 0, 0, WITA)},
+		{"12 Agu 2024 10:30 WIB", time.Date(2024, 8, 12, 10, 30, 0, 0, WIB)},
+		{"kemarin 10:30", time.Date(2024, 8, 11, 10, 30, 0, 0, WITA)},
+	}
+
+	for _, tt := range tests {
+		got, err := p.Parse(tt.in)
+		if err != nil {
+			t.Errorf("%q: unexpected error %s", tt.in, err.Error())
+			continue
+		}
+
+		if !got.Equal(tt.want) {
+			t.Errorf("%q: expecting %s, got %s", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestMonth(t *testing.T) {
+	tests := map[string]time.Month{
+		"Januari":  time.January,
+		"FEB":      time.February,
+		"Agu.":     time.August,
+		"desember": time.December,
+	}
+
+	for in, want := range tests {
+		if got, ok := Month(in); !ok || got != want {
+			t.Errorf("%q: expecting %s, got %s", in, want, got)
+		}
+	}
+
+	if _, ok := Month("senin"); ok {
+		t.Error("expecting senin to be invalid month")
+	}
+}
@@ -1,15 +1,14 @@
 package liputan6
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/tamboto2000/ivosight-crawler/pkg/htmlutil"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idtime"
+	"github.com/tamboto2000/ivosight-crawler/pkg/metadata"
 	"golang.org/x/net/html"
 )
 
@@ -36,13 +35,26 @@ type ArticleContent struct {
 	Data any
 }
 
+// String gets the sanitized HTML of a text block
 func (ct ArticleContent) String() string {
-	str, ok := ct.Data.(string)
+	switch data := ct.Data.(type) {
+	case string:
+		return data
+
+	case htmlutil.ContentText:
+		return data.HTML
+	}
+
+	panic("not a string")
+}
+
+func (ct ArticleContent) Text() htmlutil.ContentText {
+	text, ok := ct.Data.(htmlutil.ContentText)
 	if !ok {
-		panic("not a string")
+		panic("not a text")
 	}
 
-	return str
+	return text
 }
 
 func (ct ArticleContent) Image() ContentImage {
@@ -54,11 +66,13 @@ func (ct ArticleContent) Image() ContentImage {
 	return img
 }
 
-type jsonScript struct {
-	Author *struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"author,omitempty"`
+func (ct ArticleContent) Video() ContentVideo {
+	vid, ok := ct.Data.(ContentVideo)
+	if !ok {
+		panic("not a video")
+	}
+
+	return vid
 }
 
 type ArticleAuthor struct {
@@ -75,12 +89,26 @@ type ContentImage struct {
 	Height  int
 }
 
+type ContentVideo struct {
+	URL          string
+	EmbeddedURL  string
+	Title        string
+	Description  string
+	Duration     int64
+	ThumbnailURL string
+}
+
 type RelatedArticle struct {
 	Title       string
 	ArticleLink string
 	Thumbnail   ContentImage
 }
 
+type Tag struct {
+	Name string
+	Link string
+}
+
 type Article struct {
 	Type            ArticleType
 	Link            string
@@ -91,8 +119,25 @@ type Article struct {
 	Author          ArticleAuthor
 	Contents        []ArticleContent
 	RelatedArticles []RelatedArticle
+	Tags            []Tag
+	// Categories is the category path of the article, taken
+	// from the page breadcrumb
+	Categories []string
+	Metadata   metadata.Metadata
 }
 
+// Selectors of the article page blocks
+var (
+	articleMainSel     = htmlutil.MustCompile("article.hentry.main")
+	mainContentSel     = htmlutil.MustCompile("div.read-page--content")
+	topImageSel        = htmlutil.MustCompile("div.read-page--top-media")
+	mainContentBodySel = htmlutil.MustCompile("div.article-content-body")
+	relatedArticlesSel = htmlutil.MustCompile("div#related-news")
+	photoSliderSel     = htmlutil.MustCompile("div.read-page--photo-tag--slider__top")
+	tagsSel            = htmlutil.MustCompile("div[class^=tags--snippet]")
+	publishDateSel     = htmlutil.MustCompile("time.read-page--header--author__datetime")
+)
+
 type articleParser struct {
 	article Article
 }
@@ -124,6 +169,15 @@ func (parser *articleParser) parseArticle(ctx context.Context, lpt6 *Liputan6, i
 			return false, true
 		}
 
+		if parser.isPublishDate(node) {
+			parser.parsePublishDate(node)
+			return false, true
+		}
+
+		if parser.parseTags(node) {
+			return false, true
+		}
+
 		if parser.article.Type == "" {
 			parser.parseArticleType(node)
 			return true, true
@@ -137,7 +191,7 @@ func (parser *articleParser) parseArticle(ctx context.Context, lpt6 *Liputan6, i
 		}
 
 		switch parser.article.Type {
-		case TextArticle:
+		case TextArticle, VideoArticle:
 			if parser.parseArticleMain(node) {
 				return false, true
 			}
@@ -148,13 +202,12 @@ func (parser *articleParser) parseArticle(ctx context.Context, lpt6 *Liputan6, i
 			}
 		}
 
-		if parser.parseJsonScript(node) {
-			return false, true
-		}
-
 		return true, true
 	})
 
+	parser.article.Metadata = metadata.Extract(node)
+	parser.applyMetadata()
+
 	return parser.article, nil
 }
 
@@ -213,49 +266,76 @@ func (parser *articleParser) parseArticlePublishedDatetime(node *html.Node) bool
 		return false
 	}
 
+	dt, ok := parseMetaDatetime(node, "article:published_time")
+	if ok && !dt.IsZero() {
+		parser.article.PublishedAt = dt
+	}
+
+	return ok
+}
+
+func (parser *articleParser) parseArticleUpdatedDatetime(node *html.Node) bool {
+	if node.Type != html.ElementNode && node.Data != "meta" {
+		return false
+	}
+
+	dt, ok := parseMetaDatetime(node, "article:modified_time")
+	if ok && !dt.IsZero() {
+		parser.article.UpdatedAt = dt
+	}
+
+	return ok
+}
+
+// parseMetaDatetime parses the content of the meta tag when its
+// property is prop. The datetime is usually RFC 3339, but the
+// Indonesian format is accepted as well
+func parseMetaDatetime(node *html.Node, prop string) (time.Time, bool) {
 	isDatetime := false
+	var content string
 	for _, attr := range node.Attr {
 		switch attr.Key {
 		case "property":
-			if attr.Val == "article:published_time" {
-				isDatetime = true
-			}
+			isDatetime = attr.Val == prop
 
 		case "content":
-			dt, err := time.Parse(time.RFC3339, attr.Val)
-			if err != nil {
-				return false
-			}
-
-			parser.article.PublishedAt = dt
+			content = attr.Val
 		}
 	}
 
-	return isDatetime
+	if !isDatetime {
+		return time.Time{}, false
+	}
+
+	dt, _ := idtime.Parse(content)
+
+	return dt, true
 }
 
-func (parser *articleParser) parseArticleUpdatedDatetime(node *html.Node) bool {
-	if node.Type != html.ElementNode && node.Data != "meta" {
-		return false
+func (parser *articleParser) isPublishDate(node *html.Node) bool {
+	return publishDateSel.IsMatch(node)
+}
+
+// parsePublishDate parses the publish date shown on the article
+// header, like "12 Agu 2024, 10:30 WIB". It is only a fallback for
+// the pages without the meta tag
+func (parser *articleParser) parsePublishDate(node *html.Node) {
+	if !parser.article.PublishedAt.IsZero() {
+		return
 	}
 
-	isDatetime := false
 	for _, attr := range node.Attr {
-		if attr.Key == "property" && attr.Val == "article:modified_time" {
-			isDatetime = true
-		}
-
-		if attr.Key == "content" {
-			dt, err := time.Parse(time.RFC3339, attr.Val)
-			if err != nil {
-				return false
+		if attr.Key == "datetime" {
+			if dt, err := idtime.Parse(attr.Val); err == nil {
+				parser.article.PublishedAt = dt
+				return
 			}
-
-			parser.article.UpdatedAt = dt
 		}
 	}
 
-	return isDatetime
+	if dt, err := idtime.Parse(htmlutil.Text(node)); err == nil {
+		parser.article.PublishedAt = dt
+	}
 }
 
 func (parser *articleParser) parseArticleType(node *html.Node) bool {
@@ -270,6 +350,8 @@ func (parser *articleParser) parseArticleType(node *html.Node) bool {
 				parser.article.Type = TextArticle
 			case "articles show category-photo immersive":
 				parser.article.Type = PhotoArticle
+			case "articles show category-video immersive":
+				parser.article.Type = VideoArticle
 			}
 
 			return true
@@ -280,19 +362,7 @@ func (parser *articleParser) parseArticleType(node *html.Node) bool {
 }
 
 func (parser *articleParser) parseArticleMain(node *html.Node) bool {
-	if node.Type != html.ElementNode && node.Data != "article" {
-		return false
-	}
-
-	isMain := false
-	for _, attr := range node.Attr {
-		if attr.Key == "class" && attr.Val == "hentry main" {
-			isMain = true
-			break
-		}
-	}
-
-	if !isMain {
+	if !articleMainSel.IsMatch(node) {
 		return false
 	}
 
@@ -301,6 +371,10 @@ func (parser *articleParser) parseArticleMain(node *html.Node) bool {
 			return false, true
 		}
 
+		if parser.parseTags(node) {
+			return false, true
+		}
+
 		if parser.parseArticleMainContent(node) {
 			return false, true
 		}
@@ -312,19 +386,7 @@ func (parser *articleParser) parseArticleMain(node *html.Node) bool {
 }
 
 func (parser *articleParser) parseArticleMainContent(node *html.Node) bool {
-	if node.Type != html.ElementNode && node.Data != "div" {
-		return false
-	}
-
-	isMainContent := false
-	for _, attr := range node.Attr {
-		if attr.Key == "class" && attr.Val == "read-page--content" {
-			isMainContent = true
-			break
-		}
-	}
-
-	if !isMainContent {
+	if !mainContentSel.IsMatch(node) {
 		return false
 	}
 
@@ -344,19 +406,7 @@ func (parser *articleParser) parseArticleMainContent(node *html.Node) bool {
 }
 
 func (parser *articleParser) parseTopImage(node *html.Node) bool {
-	if node.Type != html.ElementNode && node.Data != "div" {
-		return false
-	}
-
-	isTopImage := false
-	for _, attr := range node.Attr {
-		if attr.Key == "class" && attr.Val == "read-page--top-media" {
-			isTopImage = true
-			break
-		}
-	}
-
-	if !isTopImage {
+	if !topImageSel.IsMatch(node) {
 		return false
 	}
 
@@ -421,19 +471,7 @@ func (parser *articleParser) parseTopImage(node *html.Node) bool {
 }
 
 func (parser *articleParser) parseMainContentBody(node *html.Node) bool {
-	if node.Type != html.ElementNode && node.Data != "div" {
-		return false
-	}
-
-	isMainContentBody := false
-	for _, attr := range node.Attr {
-		if attr.Key == "class" && attr.Val == "article-content-body article-content-body_with-aside" {
-			isMainContentBody = true
-			break
-		}
-	}
-
-	if !isMainContentBody {
+	if !mainContentBodySel.IsMatch(node) {
 		return false
 	}
 
@@ -448,24 +486,23 @@ func (parser *articleParser) parseMainContentBody(node *html.Node) bool {
 	return true
 }
 
-var contentBodyPageRgx = regexp.MustCompile(`^article-content-body__item-page`)
+var (
+	contentBodyPageFilter = htmlutil.And(
+		htmlutil.Tag("div"),
+		htmlutil.AttrPrefix("class", "article-content-body__item-page"),
+	)
 
-func (parser *articleParser) parseMainContentBodyPageItem(node *html.Node) bool {
-	if node.Type != html.ElementNode && node.Data != "div" {
-		return false
-	}
+	// ad component and promo inside the article body
+	adFilter = htmlutil.Or(
+		htmlutil.HasClass("advertisement-text"),
+		htmlutil.HasClass("promo"),
+	)
 
-	isPageItem := false
-	for _, attr := range node.Attr {
-		if attr.Key == "class" {
-			if contentBodyPageRgx.MatchString(attr.Val) {
-				isPageItem = true
-				break
-			}
-		}
-	}
+	contentImageFilter = htmlutil.HasClass("article-content-body__item-media")
+)
 
-	if !isPageItem {
+func (parser *articleParser) parseMainContentBodyPageItem(node *html.Node) bool {
+	if !contentBodyPageFilter.IsMatch(node) {
 		return false
 	}
 
@@ -473,24 +510,12 @@ func (parser *articleParser) parseMainContentBodyPageItem(node *html.Node) bool
 		if node.Type == html.ElementNode {
 			switch node.Data {
 			case "div":
-				isContImg := false
-			LOOP:
-				for _, attr := range node.Attr {
-					if attr.Key == "class" {
-						switch attr.Val {
-						// skip ad component and promo
-						case "advertisement-text", "promo promo-above", "promo promo-below":
-							return false, true
-
-						case "article-content-body__item-media":
-							isContImg = true
-							break LOOP
-						}
-					}
+				if adFilter.IsMatch(node) {
+					return false, true
 				}
 
 				// Parse content image
-				if isContImg {
+				if contentImageFilter.IsMatch(node) {
 					node = htmlutil.FindNode(node, htmlutil.DefaultFilter{
 						Type: html.ElementNode,
 						Data: "figure",
@@ -560,20 +585,13 @@ func (parser *articleParser) parseMainContentBodyPageItem(node *html.Node) bool
 					return false, true
 				}
 
-				var sectionTitle string
-				htmlutil.WalkNodes(node, func(node *html.Node) bool {
-					if node.Type == html.TextNode {
-						sectionTitle += node.Data
-					}
-
-					return true
-				})
-
-				sectionTitle = strings.TrimSpace(sectionTitle)
-				parser.article.Contents = append(parser.article.Contents, ArticleContent{
-					Type: SectionTitle,
-					Data: sectionTitle,
-				})
+				sectionTitle := htmlutil.NewContentText(node)
+				if sectionTitle.Text != "" {
+					parser.article.Contents = append(parser.article.Contents, ArticleContent{
+						Type: SectionTitle,
+						Data: sectionTitle,
+					})
+				}
 
 				return false, true
 
@@ -583,30 +601,8 @@ func (parser *articleParser) parseMainContentBodyPageItem(node *html.Node) bool
 					return false, true
 				}
 
-				var paragraph string
-				htmlutil.WalkSkipNodes(node, func(node *html.Node) (bool, bool) {
-					switch node.Type {
-					case html.ElementNode:
-						if node.Data == "p" {
-							return true, true
-						}
-
-						var buff bytes.Buffer
-						html.Render(&buff, node)
-						paragraph += buff.String()
-
-						return false, true
-
-					case html.TextNode:
-						txt := node.Data
-						paragraph += txt
-					}
-
-					return true, true
-				})
-
-				paragraph = strings.ReplaceAll(paragraph, "\u00a0", " ")
-				if paragraph != "" && paragraph != " " {
+				paragraph := htmlutil.NewContentText(node)
+				if paragraph.Text != "" {
 					parser.article.Contents = append(parser.article.Contents, ArticleContent{
 						Type: ParagraphText,
 						Data: paragraph,
@@ -624,19 +620,7 @@ func (parser *articleParser) parseMainContentBodyPageItem(node *html.Node) bool
 }
 
 func (parser *articleParser) parseRelatedArticles(node *html.Node) bool {
-	if node.Type != html.ElementNode && node.Data != "div" {
-		return false
-	}
-
-	isRelatedArticles := false
-	for _, attr := range node.Attr {
-		if attr.Key == "id" && attr.Val == "related-news" {
-			isRelatedArticles = true
-			break
-		}
-	}
-
-	if !isRelatedArticles {
+	if !relatedArticlesSel.IsMatch(node) {
 		return false
 	}
 
@@ -710,20 +694,50 @@ func (parser *articleParser) parseRelatedArticles(node *html.Node) bool {
 	return true
 }
 
-func (parser *articleParser) parsePhotoArticleSlider(node *html.Node) bool {
-	if node.Type != html.ElementNode && node.Data != "div" {
+func (parser *articleParser) parseTags(node *html.Node) bool {
+	if !tagsSel.IsMatch(node) {
 		return false
 	}
 
-	isSlider := false
-	for _, attr := range node.Attr {
-		if attr.Key == "class" && attr.Val == "read-page--photo-tag--slider__top js-top-slider" {
-			isSlider = true
-			break
+	htmlutil.WalkSkipNodes(node, func(node *html.Node) (bool, bool) {
+		if node.Type != html.ElementNode || node.Data != "a" {
+			return true, true
+		}
+
+		var tag Tag
+		for _, attr := range node.Attr {
+			switch attr.Key {
+			case "href":
+				tag.Link = attr.Val
+
+			case "title":
+				tag.Name = attr.Val
+			}
+		}
+
+		if tag.Name == "" {
+			htmlutil.WalkNodes(node, func(node *html.Node) bool {
+				if node.Type == html.TextNode {
+					tag.Name += node.Data
+				}
+
+				return true
+			})
 		}
-	}
 
-	if !isSlider {
+		tag.Name = strings.TrimSpace(strings.ReplaceAll(tag.Name, "\u00a0", " "))
+		if tag.Name != "" {
+			parser.article.Tags = append(parser.article.Tags, tag)
+		}
+
+		return false, true
+	})
+
+	return true
+}
+
+func (parser *articleParser) parsePhotoArticleSlider(node *html.Node) bool {
+	if !photoSliderSel.IsMatch(node) {
 		return false
 	}
 
@@ -777,57 +791,42 @@ func (parser *articleParser) parsePhotoArticleSlider(node *html.Node) bool {
 	return true
 }
 
-func (parser *articleParser) parseJsonScript(node *html.Node) bool {
-	if node.Type != html.ElementNode && node.Data != "script" {
-		return false
-	}
-
-	checkId := false
-	checkType := false
-	for _, attr := range node.Attr {
-		switch attr.Key {
-		case "id":
-			if attr.Val != "rich-card" {
-				return false
-			}
-
-			checkId = true
+// applyMetadata fills the author, and the video for video article,
+// from the page metadata. Datetimes is only filled when the meta tags
+// is missing
+func (parser *articleParser) applyMetadata() {
+	md := parser.article.Metadata
+	parser.article.Categories = md.Categories()
 
-		case "type":
-			if attr.Val != "application/ld+json" {
-				return false
-			}
-
-			checkType = true
+	if len(md.Authors) != 0 {
+		parser.article.Author = ArticleAuthor{
+			Name:       md.Authors[0].Name,
+			ProfileURL: md.Authors[0].URL,
 		}
 	}
 
-	if !checkId || !checkType {
-		return false
+	if parser.article.PublishedAt.IsZero() {
+		parser.article.PublishedAt = md.PublishedAt
 	}
 
-	var scripts []jsonScript
-	var txt string
-	htmlutil.WalkNodes(node, func(node *html.Node) bool {
-		if node.Type == html.TextNode {
-			txt += node.Data
-		}
-
-		return true
-	})
-
-	if err := json.Unmarshal([]byte(txt), &scripts); err != nil {
-		return false
+	if parser.article.UpdatedAt.IsZero() {
+		parser.article.UpdatedAt = md.ModifiedAt
 	}
 
-	for _, s := range scripts {
-		if s.Author != nil {
-			parser.article.Author = ArticleAuthor{
-				Name:       s.Author.Name,
-				ProfileURL: s.Author.URL,
-			}
-		}
+	// The video is put as the first content, the rest of
+	// the contents is the video description paragraphs
+	if parser.article.Type == VideoArticle && len(md.Videos) != 0 {
+		vid := md.Videos[0]
+		parser.article.Contents = append([]ArticleContent{{
+			Type: Video,
+			Data: ContentVideo{
+				URL:          vid.ContentURL,
+				EmbeddedURL:  vid.EmbedURL,
+				Title:        vid.Name,
+				Description:  vid.Description,
+				Duration:     vid.Duration,
+				ThumbnailURL: vid.ThumbnailURL,
+			},
+		}}, parser.article.Contents...)
 	}
-
-	return true
 }
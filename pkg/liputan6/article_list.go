@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/tamboto2000/ivosight-crawler/pkg/htmlutil"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idtime"
 	"golang.org/x/net/html"
 )
 
@@ -168,12 +169,20 @@ func (parser *articleListParser) parseArticleItemThumbnail(node *html.Node) bool
 func (parse *articleListParser) parseArticleItemPublishedDatetime(node *html.Node) {
 	for _, attr := range node.Attr {
 		if attr.Key == "datetime" {
-			dt, _ := time.Parse(time.RFC3339, attr.Val)
-			parse.item.PublishedAt = dt
+			dt, err := time.Parse(time.RFC3339, attr.Val)
+			if err == nil {
+				parse.item.PublishedAt = dt
+				return
+			}
 
 			break
 		}
 	}
+
+	// the datetime attribute is missing or invalid, the
+	// shown text is used, like "2 jam yang lalu"
+	dt, _ := idtime.Parse(htmlutil.Text(node))
+	parse.item.PublishedAt = dt
 }
 
 func (parser *articleListParser) parseArticleItemTitle(node *html.Node) bool {
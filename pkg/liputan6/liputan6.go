@@ -3,9 +3,9 @@ package liputan6
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 
+	"github.com/tamboto2000/ivosight-crawler/pkg/httpstatus"
 	"golang.org/x/net/html"
 )
 
@@ -67,8 +67,8 @@ func (lpt6 *Liputan6) commonReq(ctx context.Context, url string) (*html.Node, er
 
 	defer res.Body.Close()
 
-	if res.StatusCode != 200 {
-		return nil, fmt.Errorf("error requesting resource: got %d status code", res.StatusCode)
+	if err := httpstatus.Check(res); err != nil {
+		return nil, err
 	}
 
 	return html.Parse(res.Body)
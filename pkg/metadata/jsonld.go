@@ -0,0 +1,327 @@
+package metadata
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ldObject is a decoded JSON-LD object. JSON-LD values are loosely
+// typed, a value can be a string, an object or a list of both, so
+// the object is kept as is and read with the helper methods below
+type ldObject map[string]any
+
+func (md *Metadata) parseJSONLD(script string) {
+	var val any
+	if err := json.Unmarshal([]byte(strings.TrimSpace(script)), &val); err != nil {
+		return
+	}
+
+	for _, obj := range flattenLD(val) {
+		switch {
+		case obj.isType("NewsArticle", "Article", "ReportageNewsArticle", "AnalysisNewsArticle", "BlogPosting"):
+			// only the first article is used, the rest is
+			// usually the related articles
+			if md.Article == nil {
+				md.Article = parseLDArticle(obj)
+				if md.Article.Video != nil {
+					md.Videos = append(md.Videos, *md.Article.Video)
+				}
+			}
+
+		case obj.isType("BreadcrumbList"):
+			if len(md.Breadcrumbs) == 0 {
+				md.Breadcrumbs = parseLDBreadcrumbs(obj)
+			}
+
+		case obj.isType("VideoObject"):
+			md.Videos = append(md.Videos, parseLDVideo(obj))
+
+		case obj.isType("Organization", "NewsMediaOrganization"):
+			md.Organizations = append(md.Organizations, parseLDOrganization(obj))
+		}
+	}
+}
+
+// flattenLD flattens list of objects and @graph into a single list
+func flattenLD(val any) []ldObject {
+	var objs []ldObject
+	switch v := val.(type) {
+	case []any:
+		for _, item := range v {
+			objs = append(objs, flattenLD(item)...)
+		}
+
+	case map[string]any:
+		obj := ldObject(v)
+		if graph, ok := obj["@graph"]; ok {
+			objs = append(objs, flattenLD(graph)...)
+		}
+
+		if _, ok := obj["@type"]; ok {
+			objs = append(objs, obj)
+		}
+	}
+
+	return objs
+}
+
+func parseLDArticle(obj ldObject) *NewsArticle {
+	art := &NewsArticle{
+		Type:           obj.strs("@type")[0],
+		Headline:       obj.str("headline"),
+		Description:    obj.str("description"),
+		ArticleSection: obj.str("articleSection"),
+		Keywords:       obj.keywords("keywords"),
+		WordCount:      obj.int("wordCount"),
+		DatePublished:  parseTime(obj.str("datePublished")),
+		DateModified:   parseTime(obj.str("dateModified")),
+	}
+
+	// mainEntityOfPage can be the page URL or a WebPage object
+	art.URL = obj.str("mainEntityOfPage")
+	if art.URL == "" {
+		if pages := obj.objs("mainEntityOfPage"); len(pages) != 0 {
+			art.URL = firstNonEmpty(pages[0].str("@id"), pages[0].str("url"))
+		}
+	}
+
+	if art.URL == "" {
+		art.URL = obj.str("url")
+	}
+
+	for _, author := range obj.objs("author") {
+		name := strings.TrimSpace(author.str("name"))
+		if name == "" {
+			continue
+		}
+
+		art.Authors = append(art.Authors, Author{
+			Type: author.str("@type"),
+			Name: name,
+			URL:  author.str("url"),
+		})
+	}
+
+	// author can also be just the name
+	for _, name := range obj.strs("author") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+
+		art.Authors = append(art.Authors, Author{Name: name})
+	}
+
+	if pubs := obj.objs("publisher"); len(pubs) != 0 {
+		art.Publisher = parseLDOrganization(pubs[0])
+	}
+
+	art.Images = obj.images("image")
+
+	if vids := obj.objs("video"); len(vids) != 0 {
+		vid := parseLDVideo(vids[0])
+		art.Video = &vid
+	}
+
+	return art
+}
+
+func parseLDBreadcrumbs(obj ldObject) []BreadcrumbItem {
+	var items []BreadcrumbItem
+	for _, elem := range obj.objs("itemListElement") {
+		item := BreadcrumbItem{
+			Position: elem.int("position"),
+			Name:     strings.TrimSpace(elem.str("name")),
+			URL:      elem.str("item"),
+		}
+
+		// item can be the URL or a Thing object
+		if things := elem.objs("item"); len(things) != 0 {
+			item.URL = firstNonEmpty(things[0].str("@id"), things[0].str("url"))
+			if item.Name == "" {
+				item.Name = strings.TrimSpace(things[0].str("name"))
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func parseLDVideo(obj ldObject) Video {
+	vid := Video{
+		Name:        strings.TrimSpace(obj.str("name")),
+		Description: strings.TrimSpace(obj.str("description")),
+		ContentURL:  obj.str("contentUrl"),
+		EmbedURL:    obj.str("embedUrl"),
+		Duration:    ParseISODuration(obj.str("duration")),
+		UploadDate:  parseTime(obj.str("uploadDate")),
+		Keywords:    obj.keywords("keywords"),
+	}
+
+	if thumbnails := obj.images("thumbnailUrl"); len(thumbnails) != 0 {
+		vid.ThumbnailURL = thumbnails[0].URL
+	}
+
+	if vid.ThumbnailURL == "" {
+		if thumbnails := obj.images("thumbnail"); len(thumbnails) != 0 {
+			vid.ThumbnailURL = thumbnails[0].URL
+		}
+	}
+
+	return vid
+}
+
+func parseLDOrganization(obj ldObject) Organization {
+	org := Organization{
+		Name: strings.TrimSpace(obj.str("name")),
+		URL:  obj.str("url"),
+	}
+
+	if logos := obj.images("logo"); len(logos) != 0 {
+		org.Logo = logos[0]
+	}
+
+	return org
+}
+
+func (obj ldObject) isType(types ...string) bool {
+	for _, t := range obj.strs("@type") {
+		for _, want := range types {
+			if t == want {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// str gets the value of key as string. If the value is a list,
+// the first string is returned
+func (obj ldObject) str(key string) string {
+	strs := obj.strs(key)
+	if len(strs) == 0 {
+		return ""
+	}
+
+	return strs[0]
+}
+
+// strs gets all string values of key, objects is ignored.
+// Returns a list with an empty string when nothing is found,
+// so it is always safe to index the first element
+func (obj ldObject) strs(key string) []string {
+	var strs []string
+	switch v := obj[key].(type) {
+	case string:
+		strs = append(strs, v)
+
+	case float64:
+		strs = append(strs, strconv.FormatFloat(v, 'f', -1, 64))
+
+	case []any:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				strs = append(strs, str)
+			}
+		}
+	}
+
+	if len(strs) == 0 {
+		return []string{""}
+	}
+
+	return strs
+}
+
+func (obj ldObject) int(key string) int {
+	switch v := obj[key].(type) {
+	case float64:
+		return int(v)
+
+	case string:
+		i, _ := strconv.Atoi(strings.TrimSpace(v))
+		return i
+	}
+
+	return 0
+}
+
+// objs gets all object values of key, strings is ignored
+func (obj ldObject) objs(key string) []ldObject {
+	var objs []ldObject
+	switch v := obj[key].(type) {
+	case map[string]any:
+		objs = append(objs, v)
+
+	case []any:
+		for _, item := range v {
+			if m, ok := item.(map[string]any); ok {
+				objs = append(objs, m)
+			}
+		}
+	}
+
+	return objs
+}
+
+// keywords gets keywords, which can be a comma delimited string
+// or a list of strings
+func (obj ldObject) keywords(key string) []string {
+	var keywords []string
+	for _, str := range obj.strs(key) {
+		keywords = append(keywords, splitKeywords(str)...)
+	}
+
+	return keywords
+}
+
+// images gets images, which can be the image URL, an ImageObject
+// or a list of both
+func (obj ldObject) images(key string) []Image {
+	var imgs []Image
+	for _, url := range obj.strs(key) {
+		if url != "" {
+			imgs = append(imgs, Image{URL: url})
+		}
+	}
+
+	for _, img := range obj.objs(key) {
+		imgs = append(imgs, Image{
+			URL:             firstNonEmpty(img.str("url"), img.str("contentUrl")),
+			Caption:         strings.TrimSpace(img.str("caption")),
+			ContentLocation: img.str("contentLocation"),
+			Width:           img.int("width"),
+			Height:          img.int("height"),
+		})
+	}
+
+	return imgs
+}
+
+var isoDurationRgx = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// ParseISODuration parses ISO 8601 duration, like PT1M30S,
+// into seconds. Invalid duration returns 0
+func ParseISODuration(str string) int64 {
+	match := isoDurationRgx.FindStringSubmatch(strings.TrimSpace(str))
+	if match == nil {
+		return 0
+	}
+
+	var secs float64
+	units := []float64{24 * 60 * 60, 60 * 60, 60, 1}
+	for i, unit := range units {
+		if match[i+1] == "" {
+			continue
+		}
+
+		n, _ := strconv.ParseFloat(match[i+1], 64)
+		secs += n * unit
+	}
+
+	return int64(secs)
+}
@@ -0,0 +1,366 @@
+// Package metadata extracts structured metadata of a news article page,
+// that is JSON-LD (https://json-ld.org), OpenGraph (https://ogp.me) and
+// Twitter card meta tags
+package metadata
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/pkg/htmlutil"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idtime"
+	"golang.org/x/net/html"
+)
+
+type Image struct {
+	URL             string
+	Caption         string
+	ContentLocation string
+	Width           int
+	Height          int
+}
+
+type Author struct {
+	Type string
+	Name string
+	URL  string
+}
+
+type Organization struct {
+	Name string
+	URL  string
+	Logo Image
+}
+
+type BreadcrumbItem struct {
+	Position int
+	Name     string
+	URL      string
+}
+
+type Video struct {
+	Name         string
+	Description  string
+	ThumbnailURL string
+	ContentURL   string
+	EmbedURL     string
+	// Duration is the video duration in seconds
+	Duration   int64
+	UploadDate time.Time
+	Keywords   []string
+}
+
+// NewsArticle is JSON-LD NewsArticle, or any of its related
+// types like Article and ReportageNewsArticle
+type NewsArticle struct {
+	Type           string
+	URL            string
+	Headline       string
+	Description    string
+	ArticleSection string
+	Keywords       []string
+	WordCount      int
+	DatePublished  time.Time
+	DateModified   time.Time
+	Authors        []Author
+	Publisher      Organization
+	Images         []Image
+	Video          *Video
+}
+
+// Properties holds meta tag properties. A property can have
+// multiple values, like article:tag
+type Properties map[string][]string
+
+// Get gets the first value of the property
+func (props Properties) Get(key string) string {
+	vals := props[key]
+	if len(vals) == 0 {
+		return ""
+	}
+
+	return vals[0]
+}
+
+func (props Properties) add(key, val string) {
+	props[key] = append(props[key], val)
+}
+
+// Metadata is the metadata of a page. Fields outside of the
+// raw JSON-LD objects and meta tags is resolved from the JSON-LD
+// first, then OpenGraph, Twitter card and lastly the plain meta tags
+type Metadata struct {
+	CanonicalURL string
+	Title        string
+	Description  string
+	Keywords     []string
+	Tags         []string
+	Section      string
+	Authors      []Author
+	Publisher    Organization
+	PublishedAt  time.Time
+	ModifiedAt   time.Time
+	WordCount    int
+	Image        Image
+
+	// JSON-LD objects
+	Article       *NewsArticle
+	Breadcrumbs   []BreadcrumbItem
+	Videos        []Video
+	Organizations []Organization
+
+	// Meta tags, grouped by its source
+	OpenGraph Properties
+	Twitter   Properties
+	Meta      Properties
+}
+
+// Extract extracts the metadata from a page
+func Extract(node *html.Node) Metadata {
+	md := Metadata{
+		OpenGraph: make(Properties),
+		Twitter:   make(Properties),
+		Meta:      make(Properties),
+	}
+
+	htmlutil.WalkSkipNodes(node, func(node *html.Node) (bool, bool) {
+		if node.Type != html.ElementNode {
+			return true, true
+		}
+
+		switch node.Data {
+		case "script":
+			if attrVal(node, "type") == "application/ld+json" {
+				md.parseJSONLD(nodeText(node))
+			}
+
+			return false, true
+
+		case "meta":
+			md.parseMeta(node)
+			return false, true
+
+		case "link":
+			if md.CanonicalURL == "" && hasToken(attrVal(node, "rel"), "canonical") {
+				md.CanonicalURL = strings.TrimSpace(attrVal(node, "href"))
+			}
+
+			return false, true
+		}
+
+		return true, true
+	})
+
+	md.resolve()
+
+	return md
+}
+
+// Categories returns the category path of the page from its
+// breadcrumb. The site homepage and the page itself is excluded,
+// so only the categories in between is returned
+func (md Metadata) Categories() []string {
+	var categories []string
+	for _, item := range md.Breadcrumbs {
+		if item.Name == "" || strings.EqualFold(item.Name, md.Title) {
+			continue
+		}
+
+		if u, err := url.Parse(item.URL); err == nil && item.URL != "" {
+			if strings.Trim(u.Path, "/") == "" && u.RawQuery == "" {
+				continue
+			}
+
+			if md.CanonicalURL != "" && item.URL == md.CanonicalURL {
+				continue
+			}
+		}
+
+		categories = append(categories, item.Name)
+	}
+
+	return categories
+}
+
+func (md *Metadata) parseMeta(node *html.Node) {
+	content := strings.TrimSpace(attrVal(node, "content"))
+	if content == "" {
+		return
+	}
+
+	// OpenGraph uses property attribute, but some sites
+	// put it in name attribute instead
+	key := strings.ToLower(attrVal(node, "property"))
+	if key == "" {
+		key = strings.ToLower(attrVal(node, "name"))
+	}
+
+	switch {
+	case key == "":
+		return
+
+	case strings.HasPrefix(key, "og:"), strings.HasPrefix(key, "article:"):
+		md.OpenGraph.add(key, content)
+
+	case strings.HasPrefix(key, "twitter:"):
+		md.Twitter.add(key, content)
+
+	default:
+		md.Meta.add(key, content)
+	}
+}
+
+func (md *Metadata) resolve() {
+	art := md.Article
+	if art == nil {
+		art = new(NewsArticle)
+	}
+
+	md.Title = firstNonEmpty(art.Headline, md.OpenGraph.Get("og:title"), md.Twitter.Get("twitter:title"))
+	md.Description = firstNonEmpty(art.Description, md.OpenGraph.Get("og:description"), md.Twitter.Get("twitter:description"), md.Meta.Get("description"))
+	md.CanonicalURL = firstNonEmpty(md.CanonicalURL, art.URL, md.OpenGraph.Get("og:url"))
+	md.Section = firstNonEmpty(art.ArticleSection, md.OpenGraph.Get("article:section"))
+	md.WordCount = art.WordCount
+	md.Authors = art.Authors
+	md.Publisher = art.Publisher
+
+	if md.Publisher.Name == "" {
+		if len(md.Organizations) != 0 {
+			md.Publisher = md.Organizations[0]
+		} else {
+			md.Publisher.Name = md.OpenGraph.Get("og:site_name")
+		}
+	}
+
+	md.Keywords = art.Keywords
+	md.Keywords = append(md.Keywords, splitKeywords(md.Meta.Get("news_keywords"))...)
+	md.Keywords = append(md.Keywords, splitKeywords(md.Meta.Get("keywords"))...)
+	md.Keywords = dedupe(md.Keywords)
+	md.Tags = dedupe(md.OpenGraph["article:tag"])
+
+	md.PublishedAt = art.DatePublished
+	if md.PublishedAt.IsZero() {
+		md.PublishedAt = parseTime(md.OpenGraph.Get("article:published_time"))
+	}
+
+	md.ModifiedAt = art.DateModified
+	if md.ModifiedAt.IsZero() {
+		md.ModifiedAt = parseTime(md.OpenGraph.Get("article:modified_time"))
+	}
+
+	if len(art.Images) != 0 {
+		md.Image = art.Images[0]
+	}
+
+	if md.Image.URL == "" {
+		md.Image.URL = firstNonEmpty(md.OpenGraph.Get("og:image"), md.Twitter.Get("twitter:image"))
+	}
+
+	if md.Image.URL != "" && md.Image.URL == md.OpenGraph.Get("og:image") {
+		if md.Image.Width == 0 {
+			md.Image.Width, _ = strconv.Atoi(md.OpenGraph.Get("og:image:width"))
+		}
+
+		if md.Image.Height == 0 {
+			md.Image.Height, _ = strconv.Atoi(md.OpenGraph.Get("og:image:height"))
+		}
+	}
+}
+
+func attrVal(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+
+	return ""
+}
+
+func nodeText(node *html.Node) string {
+	var text string
+	htmlutil.WalkNodes(node, func(node *html.Node) bool {
+		if node.Type == html.TextNode {
+			text += node.Data
+		}
+
+		return true
+	})
+
+	return text
+}
+
+func hasToken(str, token string) bool {
+	for _, t := range strings.Fields(str) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func firstNonEmpty(strs ...string) string {
+	for _, str := range strs {
+		if str = strings.TrimSpace(str); str != "" {
+			return str
+		}
+	}
+
+	return ""
+}
+
+func splitKeywords(str string) []string {
+	var keywords []string
+	for _, kw := range strings.Split(str, ",") {
+		kw = strings.TrimSpace(kw)
+		if kw != "" {
+			keywords = append(keywords, kw)
+		}
+	}
+
+	return keywords
+}
+
+// dedupe removes duplicate strings, case insensitively
+func dedupe(strs []string) []string {
+	var deduped []string
+	seen := make(map[string]bool)
+	for _, str := range strs {
+		key := strings.ToLower(str)
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		deduped = append(deduped, str)
+	}
+
+	return deduped
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseTime parses the ISO 8601 date time, some sites put
+// Indonesian date in it instead which is parsed as well
+func parseTime(str string) time.Time {
+	str = strings.TrimSpace(str)
+	for _, layout := range timeLayouts {
+		t, err := time.Parse(layout, str)
+		if err == nil {
+			return t
+		}
+	}
+
+	t, _ := idtime.Parse(str)
+
+	return t
+}
@@ -0,0 +1,138 @@
+package metadata
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const dummyHtml = `
+<!DOCTYPE html>
+<html>
+<head>
+	<link rel="canonical" href="https://news.detik.com/berita/d-1/judul">
+	<meta property="og:image" content="https://cdn.detik.net.id/image.jpg">
+	<meta property="og:image:width" content="1280">
+	<meta property="og:image:height" content="720">
+	<meta name="twitter:card" content="summary_large_image">
+	<meta name="news_keywords" content="Pemilu, KPU">
+	<meta property="article:tag" content="pemilu 2024">
+	<script type="application/ld+json">
+	{
+		"@context": "https://schema.org",
+		"@type": "NewsArticle",
+		"headline": "Judul Berita",
+		"articleSection": "Berita",
+		"keywords": "politik, pemilu",
+		"wordCount": "350",
+		"datePublished": "2024-08-12T10:30:00+07:00",
+		"author": [{"@type": "Person", "name": "Tim Detik"}],
+		"publisher": {"@type": "Organization", "name": "detikcom", "logo": {"@type": "ImageObject", "url": "https://detik.com/logo.png"}},
+		"image": {"@type": "ImageObject", "url": "https://cdn.detik.net.id/image.jpg"}
+	}
+	</script>
+	<script type="application/ld+json">
+	[{
+		"@type": "BreadcrumbList",
+		"itemListElement": [
+			{"@type": "ListItem", "position": 1, "name": "detikNews", "item": "https://news.detik.com"},
+			{"@type": "ListItem", "position": 2, "item": {"@id": "https://news.detik.com/berita", "name": "Berita"}}
+		]
+	}, {
+		"@type": "VideoObject",
+		"name": "Video",
+		"duration": "PT1M30S",
+		"thumbnailUrl": ["https://cdn.detik.net.id/thumb.jpg"]
+	}]
+	</script>
+</head>
+<body></body>
+</html>`
+
+func TestExtract(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(dummyHtml))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	md := Extract(doc)
+
+	if md.Title != "Judul Berita" {
+		t.Errorf("expect title Judul Berita, got %q", md.Title)
+	}
+
+	if md.CanonicalURL != "https://news.detik.com/berita/d-1/judul" {
+		t.Errorf("unexpected canonical URL %q", md.CanonicalURL)
+	}
+
+	if md.Section != "Berita" || md.WordCount != 350 {
+		t.Errorf("unexpected section %q or word count %d", md.Section, md.WordCount)
+	}
+
+	if len(md.Keywords) != 3 || md.Keywords[1] != "pemilu" || md.Keywords[2] != "KPU" {
+		t.Errorf("unexpected keywords %v", md.Keywords)
+	}
+
+	if md.Publisher.Name != "detikcom" || md.Publisher.Logo.URL == "" {
+		t.Errorf("unexpected publisher %#v", md.Publisher)
+	}
+
+	if md.Image.Width != 1280 || md.Image.Height != 720 {
+		t.Errorf("expect image dimension from og:image, got %dx%d", md.Image.Width, md.Image.Height)
+	}
+
+	if len(md.Breadcrumbs) != 2 || md.Breadcrumbs[1].Name != "Berita" {
+		t.Errorf("unexpected breadcrumbs %#v", md.Breadcrumbs)
+	}
+
+	if categories := md.Categories(); len(categories) != 1 || categories[0] != "Berita" {
+		t.Errorf("expect the homepage is excluded from categories, got %v", categories)
+	}
+
+	if len(md.Videos) != 1 || md.Videos[0].Duration != 90 || md.Videos[0].ThumbnailURL == "" {
+		t.Errorf("unexpected videos %#v", md.Videos)
+	}
+
+	if len(md.Tags) != 1 || md.Tags[0] != "pemilu 2024" {
+		t.Errorf("unexpected tags %v", md.Tags)
+	}
+
+	if md.Twitter.Get("twitter:card") != "summary_large_image" {
+		t.Errorf("twitter card is not extracted")
+	}
+}
+
+func TestExtractAuthors(t *testing.T) {
+	tests := []struct {
+		author   string
+		expected []string
+	}{
+		{`{"@type": "Person", "name": "Tim Detik"}`, []string{"Tim Detik"}},
+		{`[{"@type": "Person", "name": "Tim Detik"}, {"@type": "Person", "name": " "}]`, []string{"Tim Detik"}},
+		{`"Tim Detik"`, []string{"Tim Detik"}},
+		{`""`, nil},
+		{``, nil},
+	}
+
+	for _, tt := range tests {
+		ld := `{"@type": "NewsArticle", "headline": "Judul Berita"}`
+		if tt.author != "" {
+			ld = `{"@type": "NewsArticle", "headline": "Judul Berita", "author": ` + tt.author + `}`
+		}
+
+		doc, err := html.Parse(strings.NewReader(`<html><head><script type="application/ld+json">` + ld + `</script></head></html>`))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		var names []string
+		for _, author := range Extract(doc).Authors {
+			names = append(names, author.Name)
+		}
+
+		if strings.Join(names, "|") != strings.Join(tt.expected, "|") {
+			t.Errorf("expect authors %q for %s, got %q", tt.expected, tt.author, names)
+		}
+	}
+}
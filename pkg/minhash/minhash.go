@@ -0,0 +1,142 @@
+// Package minhash provides MinHash signatures of texts, to estimate
+// the Jaccard similarity of their shingle sets without keeping the
+// sets, and LSH bands to find the similar texts without comparing
+// every pair.
+package minhash
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Words splits text into lowercased words of letters and digits
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Shingles gets the k words shingles of the words. Words fewer
+// than k is a single shingle
+func Shingles(words []string, k int) []string {
+	if len(words) == 0 {
+		return nil
+	}
+
+	if len(words) <= k {
+		return []string{strings.Join(words, " ")}
+	}
+
+	shingles := make([]string, 0, len(words)-k+1)
+	for i := 0; i+k <= len(words); i++ {
+		shingles = append(shingles, strings.Join(words[i:i+k], " "))
+	}
+
+	return shingles
+}
+
+// Signature is the MinHash signature, the minimum hash of the
+// shingles for each hash function
+type Signature []uint32
+
+// Similarity estimates the Jaccard similarity of the shingle sets,
+// which is the fraction of the hash functions with equal minimum
+func (sig Signature) Similarity(other Signature) float64 {
+	if len(sig) == 0 || len(sig) != len(other) {
+		return 0
+	}
+
+	equal := 0
+	for i := range sig {
+		if sig[i] == other[i] {
+			equal++
+		}
+	}
+
+	return float64(equal) / float64(len(sig))
+}
+
+// Bands splits the signature into b bands and hashes each band
+// into a key. Two signatures sharing any band key is a candidate
+// pair. With r rows per band, the pairs with similarity s is found
+// with probability 1-(1-s^r)^b
+func (sig Signature) Bands(b int) []string {
+	if b <= 0 || len(sig) < b {
+		return nil
+	}
+
+	rows := len(sig) / b
+	keys := make([]string, 0, b)
+	buf := make([]byte, 4)
+	for i := 0; i < b; i++ {
+		h := fnv.New64a()
+		for _, v := range sig[i*rows : (i+1)*rows] {
+			binary.LittleEndian.PutUint32(buf, v)
+			h.Write(buf)
+		}
+
+		keys = append(keys, strconv.Itoa(i)+":"+strconv.FormatUint(h.Sum64(), 36))
+	}
+
+	return keys
+}
+
+// Hasher creates the signatures, signatures is only comparable
+// when created by hashers of the same size and seed
+type Hasher struct {
+	seeds []uint64
+}
+
+// NewHasher creates a hasher with n hash functions
+func NewHasher(n int, seed int64) *Hasher {
+	rnd := rand.New(rand.NewSource(seed))
+	seeds := make([]uint64, n)
+	for i := range seeds {
+		seeds[i] = rnd.Uint64()
+	}
+
+	return &Hasher{seeds: seeds}
+}
+
+// Signature creates the signature of the shingles, nil
+// when there is no shingle
+func (hasher *Hasher) Signature(shingles []string) Signature {
+	if len(shingles) == 0 {
+		return nil
+	}
+
+	sig := make(Signature, len(hasher.seeds))
+	for i := range sig {
+		sig[i] = ^uint32(0)
+	}
+
+	for _, shingle := range shingles {
+		h := fnv.New64a()
+		h.Write([]byte(shingle))
+		x := h.Sum64()
+
+		for i, seed := range hasher.seeds {
+			if v := uint32(mix(x ^ seed)); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+
+	return sig
+}
+
+// mix is the splitmix64 finalizer, which turns the shingle hash
+// xor the seed into an independent hash for each seed
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
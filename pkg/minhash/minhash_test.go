@@ -0,0 +1,100 @@
+package minhash
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const story = `Presiden Joko Widodo meresmikan jalan tol Semarang Demak seksi dua
+di Kabupaten Demak, Jawa Tengah, pada Jumat pagi. Jalan tol sepanjang enam belas
+kilometer itu diharapkan memangkas waktu tempuh dari Semarang ke Demak menjadi
+hanya dua puluh menit, sekaligus menjadi tanggul laut untuk mencegah banjir rob
+di pesisir utara Jawa Tengah. Presiden mengatakan pembangunan tol ini menelan
+biaya sekitar sepuluh triliun rupiah yang berasal dari APBN dan investasi badan
+usaha jalan tol. Ia berharap masyarakat dapat memanfaatkan jalan tol tersebut
+untuk mendorong pertumbuhan ekonomi di kawasan utara Jawa Tengah.`
+
+func jaccard(a, b []string) float64 {
+	set := make(map[string]int)
+	for _, s := range a {
+		set[s] |= 1
+	}
+
+	for _, s := range b {
+		set[s] |= 2
+	}
+
+	both := 0
+	for _, v := range set {
+		if v == 3 {
+			both++
+		}
+	}
+
+	return float64(both) / float64(len(set))
+}
+
+func TestSimilarity(t *testing.T) {
+	edited := strings.Replace(story, "pada Jumat pagi", "pada Jumat (12/8/2024) pagi", 1)
+	edited = strings.Replace(edited, "sekitar sepuluh triliun", "kurang lebih sepuluh triliun", 1)
+	other := `Tim nasional sepak bola Indonesia berhasil mengalahkan Vietnam dengan skor
+dua gol tanpa balas dalam laga kualifikasi Piala Dunia yang digelar di Stadion Utama
+Gelora Bung Karno, Jakarta, pada Selasa malam.`
+
+	hasher := NewHasher(256, 1)
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"same", story, story},
+		{"edited", story, edited},
+		{"different", story, other},
+	}
+
+	for _, tt := range tests {
+		sa := Shingles(Words(tt.a), 3)
+		sb := Shingles(Words(tt.b), 3)
+		want := jaccard(sa, sb)
+		got := hasher.Signature(sa).Similarity(hasher.Signature(sb))
+		if math.Abs(got-want) > 0.1 {
+			t.Errorf("%s: expecting similarity around %.2f, got %.2f", tt.name, want, got)
+		}
+	}
+}
+
+func TestBands(t *testing.T) {
+	hasher := NewHasher(128, 1)
+	sig := hasher.Signature(Shingles(Words(story), 3))
+	edited := hasher.Signature(Shingles(Words(strings.Replace(story, "Jumat pagi", "Jumat siang", 1)), 3))
+
+	bands := sig.Bands(16)
+	if len(bands) != 16 {
+		t.Fatalf("expecting 16 bands, got %d", len(bands))
+	}
+
+	shared := 0
+	for i, band := range edited.Bands(16) {
+		if band == bands[i] {
+			shared++
+		}
+	}
+
+	if shared == 0 {
+		t.Error("expecting near-duplicate to share a band")
+	}
+
+	if len(Signature(nil).Bands(16)) != 0 {
+		t.Error("expecting no bands of empty signature")
+	}
+}
+
+func TestShingles(t *testing.T) {
+	if got := Shingles(Words("Satu, dua!"), 3); len(got) != 1 || got[0] != "satu dua" {
+		t.Errorf("unexpected shingles %q", got)
+	}
+
+	if got := Shingles(Words("a b c d"), 3); len(got) != 2 || got[1] != "b c d" {
+		t.Errorf("unexpected shingles %q", got)
+	}
+}
@@ -0,0 +1,125 @@
+package siterules
+
+import (
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/pkg/htmlutil"
+	"github.com/tamboto2000/ivosight-crawler/pkg/metadata"
+)
+
+// ArticleContentType is the type of a content block, it is
+// also the block type of the rules
+type ArticleContentType string
+
+const (
+	SectionTitle          ArticleContentType = "section-title"
+	ParagraphText         ArticleContentType = "paragraph-text"
+	Image                 ArticleContentType = "image"
+	Video                 ArticleContentType = "video"
+	ReferencedArticleLink ArticleContentType = "referenced-article-link"
+)
+
+type ContentImage struct {
+	URL     string
+	Title   string
+	Caption string
+	Alt     string
+	Width   int
+	Height  int
+}
+
+type ContentVideo struct {
+	EmbeddedURL string
+	Title       string
+}
+
+type ReferencedArticle struct {
+	Headline    string
+	ArticleLink string
+}
+
+type RelatedArticle struct {
+	Title        string
+	ArticleLink  string
+	ThumbnailURL string
+}
+
+type Author struct {
+	Name string
+	URL  string
+}
+
+type Article struct {
+	// Source is the article source of the site
+	Source      string
+	Link        string
+	Headline    string
+	Description string
+	Author      Author
+	PublishedAt time.Time
+	UpdatedAt   time.Time
+	// Image is the lead image, taken from the rules or
+	// the metadata
+	Image           ContentImage
+	Contents        []ArticleContent
+	RelatedArticles []RelatedArticle
+	Tags            []string
+	// Categories is the category path of the article, taken
+	// from the page breadcrumb
+	Categories []string
+	WordCount  int
+	Metadata   metadata.Metadata
+}
+
+// Paragraphs gets the plain text of the paragraphs
+func (art Article) Paragraphs() []string {
+	var paragraphs []string
+	for _, content := range art.Contents {
+		if content.Type == ParagraphText {
+			paragraphs = append(paragraphs, content.Text().Text)
+		}
+	}
+
+	return paragraphs
+}
+
+type ArticleContent struct {
+	Type ArticleContentType
+	Data any
+}
+
+func (ct ArticleContent) Text() htmlutil.ContentText {
+	text, ok := ct.Data.(htmlutil.ContentText)
+	if !ok {
+		panic("not a text")
+	}
+
+	return text
+}
+
+func (ct ArticleContent) Image() ContentImage {
+	img, ok := ct.Data.(ContentImage)
+	if !ok {
+		panic("not an image")
+	}
+
+	return img
+}
+
+func (ct ArticleContent) Video() ContentVideo {
+	vid, ok := ct.Data.(ContentVideo)
+	if !ok {
+		panic("not a video")
+	}
+
+	return vid
+}
+
+func (ct ArticleContent) ReferencedArticle() ReferencedArticle {
+	ref, ok := ct.Data.(ReferencedArticle)
+	if !ok {
+		panic("not a referenced article")
+	}
+
+	return ref
+}
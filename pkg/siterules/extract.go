@@ -0,0 +1,502 @@
+package siterules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/pkg/htmlutil"
+	"github.com/tamboto2000/ivosight-crawler/pkg/httpreq"
+	"github.com/tamboto2000/ivosight-crawler/pkg/httpstatus"
+	"github.com/tamboto2000/ivosight-crawler/pkg/idtime"
+	"github.com/tamboto2000/ivosight-crawler/pkg/metadata"
+	"golang.org/x/net/html"
+)
+
+var (
+	ErrNoBody    = errors.New("article body is not found")
+	ErrNoContent = errors.New("no article content is found")
+)
+
+type IndexItem struct {
+	Title       string
+	Link        string
+	Channel     string
+	PublishedAt time.Time
+}
+
+// Fetch requests the page and parses it
+func (site *Site) Fetch(ctx context.Context, cl *http.Client, link string) (*html.Node, error) {
+	if cl == nil {
+		cl = http.DefaultClient
+	}
+
+	req, err := httpreq.NewPage(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+
+	if site.UserAgent != "" {
+		req.Header.Set("user-agent", site.UserAgent)
+	}
+
+	res, err := cl.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if err := httpstatus.Check(res); err != nil {
+		return nil, err
+	}
+
+	return html.Parse(res.Body)
+}
+
+// ArticleListFromIndex fetches all index pages of the site
+// and extracts the article list
+func (site *Site) ArticleListFromIndex(ctx context.Context, cl *http.Client) ([]IndexItem, error) {
+	var list []IndexItem
+	for _, page := range site.Index.Pages {
+		node, err := site.Fetch(ctx, cl, page.URL)
+		if err != nil {
+			return list, fmt.Errorf("%s: %w", site.Name, err)
+		}
+
+		items, err := site.ExtractIndex(node, page)
+		if err != nil {
+			return list, err
+		}
+
+		list = append(list, items...)
+	}
+
+	return list, nil
+}
+
+// ExtractIndex extracts the article list from a parsed index page
+func (site *Site) ExtractIndex(node *html.Node, page IndexPage) ([]IndexItem, error) {
+	base, err := url.Parse(page.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := site.Index
+	if idx.item == nil {
+		return nil, nil
+	}
+
+	var list []IndexItem
+	for _, itemNode := range idx.item.Select(node) {
+		item := IndexItem{
+			Channel: page.Channel,
+			Title:   idx.Title.text(itemNode),
+		}
+
+		if idx.Link.IsZero() {
+			if a := htmlutil.SelectOne(itemNode, "a[href]"); a != nil {
+				item.Link = attrVal(a, "href")
+			} else if itemNode.Data == "a" {
+				item.Link = attrVal(itemNode, "href")
+			}
+		} else {
+			item.Link = idx.Link.value(itemNode)
+		}
+
+		if item.Link == "" {
+			continue
+		}
+
+		item.Link = resolve(base, item.Link)
+		item.PublishedAt = idx.Date.time(itemNode)
+		list = append(list, item)
+	}
+
+	return list, nil
+}
+
+// ArticleFromLink fetches the article page and extracts the article
+func (site *Site) ArticleFromLink(ctx context.Context, cl *http.Client, link string) (Article, error) {
+	node, err := site.Fetch(ctx, cl, link)
+	if err != nil {
+		return Article{}, fmt.Errorf("%s: %w", site.Name, err)
+	}
+
+	return site.ExtractArticle(node, link)
+}
+
+// ExtractArticle extracts the article from a parsed article page
+func (site *Site) ExtractArticle(node *html.Node, link string) (Article, error) {
+	base, err := url.Parse(link)
+	if err != nil {
+		return Article{}, err
+	}
+
+	ext := extractor{
+		site: site,
+		base: base,
+	}
+
+	return ext.extract(node)
+}
+
+type extractor struct {
+	site *Site
+	base *url.URL
+	art  Article
+}
+
+func (ext *extractor) extract(node *html.Node) (Article, error) {
+	rules := ext.site.Article
+	art := &ext.art
+	art.Source = ext.site.Source
+	art.Link = ext.base.String()
+
+	body := rules.body.SelectOne(node)
+	if body == nil {
+		return Article{}, fmt.Errorf("%s: %w", ext.site.Name, ErrNoBody)
+	}
+
+	ext.parseBody(body)
+	if len(art.Contents) == 0 {
+		return Article{}, fmt.Errorf("%s: %w", ext.site.Name, ErrNoContent)
+	}
+
+	art.Headline = rules.Title.text(node)
+	art.Description = rules.Description.text(node)
+	art.Author.Name = rules.Author.text(node)
+	art.PublishedAt = rules.Date.time(node)
+	art.UpdatedAt = rules.UpdatedDate.time(node)
+
+	if src := rules.Image.value(node); src != "" {
+		art.Image.URL = resolve(ext.base, src)
+	}
+
+	if !rules.Tags.IsZero() {
+		art.Tags = rules.Tags.values(node)
+	}
+
+	ext.parseRelated(node)
+	ext.applyMetadata(metadata.Extract(node))
+
+	return ext.art, nil
+}
+
+func (ext *extractor) parseBody(body *html.Node) {
+	rules := ext.site.Article
+	htmlutil.WalkSkipNodes(body, func(node *html.Node) (bool, bool) {
+		if node.Type != html.ElementNode {
+			return false, true
+		}
+
+		for _, sel := range rules.exclude {
+			if sel.IsMatch(node) {
+				return false, true
+			}
+		}
+
+		for _, block := range rules.Blocks {
+			if block.sel.IsMatch(node) {
+				ext.parseBlock(block, node)
+				return false, true
+			}
+		}
+
+		return true, true
+	})
+}
+
+func (ext *extractor) parseBlock(block BlockRule, node *html.Node) {
+	switch block.Type {
+	case SectionTitle, ParagraphText:
+		text := htmlutil.NewContentText(node)
+		if text.Text != "" {
+			ext.appendContent(block.Type, text)
+		}
+
+	case Image:
+		imgNode := node
+		if node.Data != "img" {
+			imgNode = htmlutil.SelectOne(node, "img")
+		}
+
+		src := block.Src.value(node)
+		if block.Src.IsZero() && imgNode != nil {
+			src = firstNonEmpty(attrVal(imgNode, "data-src"), attrVal(imgNode, "src"))
+		}
+
+		if src == "" || strings.HasPrefix(src, "data:") {
+			return
+		}
+
+		img := ContentImage{
+			URL:     resolve(ext.base, src),
+			Caption: block.Caption.text(node),
+		}
+
+		if imgNode != nil {
+			img.Title = attrVal(imgNode, "title")
+			img.Alt = attrVal(imgNode, "alt")
+			img.Width, _ = strconv.Atoi(attrVal(imgNode, "width"))
+			img.Height, _ = strconv.Atoi(attrVal(imgNode, "height"))
+		}
+
+		ext.appendContent(block.Type, img)
+
+	case Video:
+		src := block.Src.value(node)
+		if block.Src.IsZero() {
+			if vidNode := htmlutil.SelectOne(node, "iframe, video, source"); vidNode != nil {
+				src = firstNonEmpty(attrVal(vidNode, "data-src"), attrVal(vidNode, "src"))
+			} else {
+				src = firstNonEmpty(attrVal(node, "data-src"), attrVal(node, "src"))
+			}
+		}
+
+		if src == "" {
+			return
+		}
+
+		ext.appendContent(block.Type, ContentVideo{
+			EmbeddedURL: resolve(ext.base, src),
+			Title:       block.Title.text(node),
+		})
+
+	case ReferencedArticleLink:
+		ref := ReferencedArticle{
+			Headline:    block.Title.text(node),
+			ArticleLink: block.Link.value(node),
+		}
+
+		if block.Link.IsZero() {
+			if a := htmlutil.SelectOne(node, "a[href]"); a != nil {
+				ref.ArticleLink = attrVal(a, "href")
+				if ref.Headline == "" {
+					ref.Headline = htmlutil.Text(a)
+				}
+			}
+		}
+
+		if ref.ArticleLink == "" {
+			return
+		}
+
+		ref.ArticleLink = resolve(ext.base, ref.ArticleLink)
+		ext.appendContent(block.Type, ref)
+	}
+}
+
+func (ext *extractor) parseRelated(node *html.Node) {
+	rules := ext.site.Article.Related
+	if rules.item == nil {
+		return
+	}
+
+	for _, itemNode := range rules.item.Select(node) {
+		rel := RelatedArticle{
+			Title:       rules.Title.text(itemNode),
+			ArticleLink: rules.Link.value(itemNode),
+		}
+
+		if rules.Link.IsZero() {
+			if a := htmlutil.SelectOne(itemNode, "a[href]"); a != nil {
+				rel.ArticleLink = attrVal(a, "href")
+			} else if itemNode.Data == "a" {
+				rel.ArticleLink = attrVal(itemNode, "href")
+			}
+		}
+
+		if rel.ArticleLink == "" {
+			continue
+		}
+
+		rel.ArticleLink = resolve(ext.base, rel.ArticleLink)
+		if src := rules.Image.value(itemNode); src != "" {
+			rel.ThumbnailURL = resolve(ext.base, src)
+		}
+
+		ext.art.RelatedArticles = append(ext.art.RelatedArticles, rel)
+	}
+}
+
+func (ext *extractor) appendContent(contentType ArticleContentType, data any) {
+	ext.art.Contents = append(ext.art.Contents, ArticleContent{
+		Type: contentType,
+		Data: data,
+	})
+}
+
+// applyMetadata fills the fields that is not found by
+// the rules from the page metadata. The rest of the metadata
+// is kept on the article as is
+func (ext *extractor) applyMetadata(md metadata.Metadata) {
+	art := &ext.art
+
+	art.Headline = firstNonEmpty(art.Headline, md.Title)
+	art.Description = firstNonEmpty(art.Description, md.Description)
+	art.Categories = md.Categories()
+	art.WordCount = md.WordCount
+	art.Metadata = md
+
+	if art.PublishedAt.IsZero() {
+		art.PublishedAt = md.PublishedAt
+	}
+
+	if art.UpdatedAt.IsZero() {
+		art.UpdatedAt = md.ModifiedAt
+	}
+
+	if art.Author.Name == "" {
+		if len(md.Authors) != 0 {
+			art.Author = Author{
+				Name: md.Authors[0].Name,
+				URL:  md.Authors[0].URL,
+			}
+		} else {
+			art.Author.Name = md.Meta.Get("author")
+		}
+	}
+
+	if art.Image.URL == "" {
+		art.Image = ContentImage{
+			URL:     md.Image.URL,
+			Caption: md.Image.Caption,
+			Width:   md.Image.Width,
+			Height:  md.Image.Height,
+		}
+	}
+
+	if art.WordCount == 0 {
+		for _, paragraph := range art.Paragraphs() {
+			art.WordCount += len(strings.Fields(paragraph))
+		}
+	}
+}
+
+// node finds the node of the field, relative to node
+func (f Field) node(node *html.Node) *html.Node {
+	if f.sel == nil {
+		return node
+	}
+
+	return f.sel.SelectOne(node)
+}
+
+// value gets the raw value of the field, it is empty
+// when the field is not defined or not found
+func (f Field) value(node *html.Node) string {
+	if f.IsZero() {
+		return ""
+	}
+
+	found := f.node(node)
+	if found == nil {
+		return ""
+	}
+
+	return f.extract(found)
+}
+
+// values gets the value of every node that matches the field
+func (f Field) values(node *html.Node) []string {
+	if f.IsZero() {
+		return nil
+	}
+
+	nodes := []*html.Node{node}
+	if f.sel != nil {
+		nodes = f.sel.Select(node)
+	}
+
+	var vals []string
+	for _, found := range nodes {
+		if val := f.extract(found); val != "" {
+			vals = append(vals, val)
+		}
+	}
+
+	return vals
+}
+
+func (f Field) extract(node *html.Node) string {
+	var val string
+	if f.Attr != "" {
+		val = attrVal(node, f.Attr)
+	} else {
+		val = htmlutil.Text(node)
+	}
+
+	if f.rgx != nil {
+		match := f.rgx.FindStringSubmatch(val)
+		switch {
+		case match == nil:
+			val = ""
+		case len(match) > 1:
+			val = match[1]
+		default:
+			val = match[0]
+		}
+	}
+
+	return strings.TrimSpace(val)
+}
+
+// text is like value, but with the whitespaces normalized
+func (f Field) text(node *html.Node) string {
+	return htmlutil.NormalizeText(f.value(node))
+}
+
+// time parses the value of the field with its formats. The
+// Indonesian date formats is always tried last
+func (f Field) time(node *html.Node) time.Time {
+	val := f.text(node)
+	if val == "" {
+		return time.Time{}
+	}
+
+	for _, layout := range f.Formats {
+		t, err := time.ParseInLocation(layout, val, f.loc)
+		if err == nil {
+			return t
+		}
+	}
+
+	t, _ := idtime.ParseInLocation(val, f.loc)
+
+	return t
+}
+
+func resolve(base *url.URL, ref string) string {
+	u, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
+	}
+
+	return u.String()
+}
+
+func attrVal(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+
+	return ""
+}
+
+func firstNonEmpty(strs ...string) string {
+	for _, str := range strs {
+		if str = strings.TrimSpace(str); str != "" {
+			return str
+		}
+	}
+
+	return ""
+}
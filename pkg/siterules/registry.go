@@ -0,0 +1,195 @@
+package siterules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Registry holds the sites loaded from a directory of rule files.
+// The rules can be reloaded while the crawler is running, so a
+// broken site can be hot-fixed by editing its rule file
+type Registry struct {
+	dir     string
+	rwmx    sync.RWMutex
+	sites   []*Site
+	modTime time.Time
+}
+
+func NewRegistry(dir string) *Registry {
+	return &Registry{dir: dir}
+}
+
+// Load loads all *.yaml and *.yml rule files in the directory.
+// An invalid file is skipped and its previously loaded rules is
+// kept, so a bad edit never takes down the other sites. The errors
+// of the skipped files is returned
+func (reg *Registry) Load() error {
+	files, modTime, err := reg.ruleFiles()
+	if err != nil {
+		return err
+	}
+
+	prevSites := reg.Sites()
+
+	var sites []*Site
+	var errs []error
+	names := make(map[string]string)
+	for _, file := range files {
+		site, err := ParseFile(file)
+		if err != nil {
+			errs = append(errs, err)
+
+			site = findSiteByFile(prevSites, file)
+			if site == nil {
+				continue
+			}
+		}
+
+		if prev, ok := names[site.Name]; ok {
+			errs = append(errs, fmt.Errorf("%s: site %s is already defined in %s", file, site.Name, prev))
+			continue
+		}
+
+		names[site.Name] = file
+		sites = append(sites, site)
+	}
+
+	reg.rwmx.Lock()
+	reg.sites = sites
+	reg.modTime = modTime
+	reg.rwmx.Unlock()
+
+	return errors.Join(errs...)
+}
+
+// Watch reloads the rules every interval when any rule file is
+// changed, added or removed, until ctx is cancelled
+func (reg *Registry) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	reg.rwmx.RLock()
+	lastModTime := reg.modTime
+	reg.rwmx.RUnlock()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		// a failed reload is not retried until the files
+		// is changed again
+		_, modTime, err := reg.ruleFiles()
+		if err != nil || modTime.Equal(lastModTime) {
+			continue
+		}
+
+		lastModTime = modTime
+		if err := reg.Load(); err != nil {
+			slog.Error("some site rules is skipped, keeping their previous rules", "dir", reg.dir, "error", err.Error())
+		}
+
+		slog.Info("site rules reloaded", "dir", reg.dir, "sites", len(reg.Sites()))
+	}
+}
+
+// Sites returns the loaded sites
+func (reg *Registry) Sites() []*Site {
+	reg.rwmx.RLock()
+	defer reg.rwmx.RUnlock()
+
+	return append([]*Site(nil), reg.sites...)
+}
+
+// Site finds the site by its name or source
+func (reg *Registry) Site(name string) (*Site, bool) {
+	reg.rwmx.RLock()
+	defer reg.rwmx.RUnlock()
+
+	for _, site := range reg.sites {
+		if site.Name == name || site.Source == name {
+			return site, true
+		}
+	}
+
+	return nil, false
+}
+
+// SiteForLink finds the site of an article link by its host
+func (reg *Registry) SiteForLink(link string) (*Site, bool) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, false
+	}
+
+	reg.rwmx.RLock()
+	defer reg.rwmx.RUnlock()
+
+	for _, site := range reg.sites {
+		if site.MatchHost(u.Hostname()) {
+			return site, true
+		}
+	}
+
+	return nil, false
+}
+
+func findSiteByFile(sites []*Site, file string) *Site {
+	for _, site := range sites {
+		if site.file == file {
+			return site
+		}
+	}
+
+	return nil
+}
+
+// ruleFiles lists the rule files and gets the latest modification
+// time of the files and the directory itself, which changes
+// when a file is added or removed
+func (reg *Registry) ruleFiles() ([]string, time.Time, error) {
+	info, err := os.Stat(reg.dir)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	modTime := info.ModTime()
+
+	entries, err := os.ReadDir(reg.dir)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+
+		files = append(files, filepath.Join(reg.dir, entry.Name()))
+	}
+
+	sort.Strings(files)
+
+	return files, modTime, nil
+}
@@ -0,0 +1,330 @@
+// Package siterules provides declarative per-site extraction rules.
+// A site is defined in a YAML file declaring the selectors of its
+// index page and article page, which is executed by a generic parser.
+// This way a new news portal can be added, or a broken one can be
+// fixed, by editing the rule file instead of writing a site specific
+// parser.
+package siterules
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tamboto2000/ivosight-crawler/pkg/htmlutil"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultTimezone = "Asia/Jakarta"
+
+// Field is a rule to get a value from the page. In YAML a field can
+// be written as just the selector, or as a mapping:
+//
+//	date:
+//	  selector: div.read__time
+//	  regex: '(\d{2}/\d{2}/\d{4}, \d{2}:\d{2})'
+//	  format: 02/01/2006, 15:04
+type Field struct {
+	// Selector finds the node, relative to the current node. When
+	// empty, the current node itself is used
+	Selector string `yaml:"selector"`
+	// Attr is the attribute to get the value from, the text of
+	// the node is used when empty
+	Attr string `yaml:"attr"`
+	// Regex extracts the value with its first capture group, or the
+	// whole match when it has no group
+	Regex string `yaml:"regex"`
+	// Format and Formats is the Go time layouts of a date field.
+	// Common Indonesian dates, like "Senin, 12 Agu 2024 10:30 WIB"
+	// and "2 jam yang lalu", is parsed without any format
+	Format  string   `yaml:"format"`
+	Formats []string `yaml:"formats"`
+	// Timezone is the location of a date without zone,
+	// defaults to Asia/Jakarta
+	Timezone string `yaml:"timezone"`
+
+	sel *htmlutil.Selector
+	rgx *regexp.Regexp
+	loc *time.Location
+}
+
+// UnmarshalYAML allows the field to be written as just the selector
+func (f *Field) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		f.Selector = value.Value
+		return nil
+	}
+
+	type field Field
+	return value.Decode((*field)(f))
+}
+
+// IsZero reports whether the field is not defined
+func (f Field) IsZero() bool {
+	return f.Selector == "" && f.Attr == "" && f.Regex == ""
+}
+
+func (f *Field) compile() error {
+	var err error
+	if f.Selector != "" {
+		if f.sel, err = htmlutil.Compile(f.Selector); err != nil {
+			return err
+		}
+	}
+
+	if f.Regex != "" {
+		if f.rgx, err = regexp.Compile(f.Regex); err != nil {
+			return err
+		}
+	}
+
+	if f.Format != "" {
+		f.Formats = append([]string{f.Format}, f.Formats...)
+		f.Format = ""
+	}
+
+	tz := f.Timezone
+	if tz == "" {
+		tz = defaultTimezone
+	}
+
+	if f.loc, err = time.LoadLocation(tz); err != nil {
+		// tzdata might not be available in minimal images
+		f.loc = time.FixedZone("WIB", 7*60*60)
+	}
+
+	return nil
+}
+
+// IndexPage is a page that lists the latest articles
+type IndexPage struct {
+	URL     string `yaml:"url"`
+	Channel string `yaml:"channel"`
+}
+
+type IndexRules struct {
+	Pages []IndexPage `yaml:"pages"`
+	// Item is the selector of each article in the list
+	Item string `yaml:"item"`
+	// Link defaults to the href of the first link in the item
+	Link  Field `yaml:"link"`
+	Title Field `yaml:"title"`
+	Date  Field `yaml:"date"`
+
+	item *htmlutil.Selector
+}
+
+// BlockRule is a rule of a content block. The blocks is matched
+// in document order, so a node that matches a block rule is not
+// looked into further
+type BlockRule struct {
+	// Type is the content type of the block, like paragraph-text
+	Type     ArticleContentType `yaml:"type"`
+	Selector string             `yaml:"selector"`
+	// Src is the image or video URL, defaults to the src or
+	// data-src of the first img, video or iframe
+	Src Field `yaml:"src"`
+	// Caption is the image caption
+	Caption Field `yaml:"caption"`
+	// Link and Title is the referenced article link and headline
+	Link  Field `yaml:"link"`
+	Title Field `yaml:"title"`
+
+	sel *htmlutil.Selector
+}
+
+type RelatedRules struct {
+	Item  string `yaml:"item"`
+	Link  Field  `yaml:"link"`
+	Title Field  `yaml:"title"`
+	Image Field  `yaml:"image"`
+
+	item *htmlutil.Selector
+}
+
+// ArticleRules is the rules of the article page. Undefined fields
+// falls back to the page metadata
+type ArticleRules struct {
+	Title       Field `yaml:"title"`
+	Description Field `yaml:"description"`
+	Author      Field `yaml:"author"`
+	Date        Field `yaml:"date"`
+	UpdatedDate Field `yaml:"updated_date"`
+	Image       Field `yaml:"image"`
+	// Tags gets the value of every matched node
+	Tags Field `yaml:"tags"`
+	// Body is the selector of the main content
+	Body string `yaml:"body"`
+	// Exclude is the selectors of nodes inside the body that
+	// is skipped, like ads and "read also" boxes
+	Exclude []string     `yaml:"exclude"`
+	Blocks  []BlockRule  `yaml:"blocks"`
+	Related RelatedRules `yaml:"related"`
+
+	body    *htmlutil.Selector
+	exclude []*htmlutil.Selector
+}
+
+// Site is the extraction rules of a news portal
+type Site struct {
+	Name string `yaml:"name"`
+	// Source is the article source, defaults to Name
+	Source string `yaml:"source"`
+	// Hosts is the hostnames of the site, used to find the
+	// site of an article link
+	Hosts     []string     `yaml:"hosts"`
+	UserAgent string       `yaml:"user_agent"`
+	Index     IndexRules   `yaml:"index"`
+	Article   ArticleRules `yaml:"article"`
+
+	// file is the rule file the site is loaded from
+	file string
+}
+
+var blockTypes = map[ArticleContentType]bool{
+	SectionTitle:          true,
+	ParagraphText:         true,
+	Image:                 true,
+	Video:                 true,
+	ReferencedArticleLink: true,
+}
+
+// Parse parses and validates the rules of a site
+func Parse(data []byte) (*Site, error) {
+	site := new(Site)
+	if err := yaml.Unmarshal(data, site); err != nil {
+		return nil, err
+	}
+
+	if err := site.compile(); err != nil {
+		return nil, err
+	}
+
+	return site, nil
+}
+
+// ParseFile parses the rules of a site from a YAML file
+func ParseFile(path string) (*Site, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	site, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	site.file = path
+
+	return site, nil
+}
+
+func (site *Site) compile() error {
+	if site.Name == "" {
+		return errors.New("site name is required")
+	}
+
+	if site.Source == "" {
+		site.Source = site.Name
+	}
+
+	for i, host := range site.Hosts {
+		site.Hosts[i] = strings.TrimPrefix(strings.ToLower(host), "www.")
+	}
+
+	var errs []error
+	check := func(name string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	compileSel := func(name, sel string) *htmlutil.Selector {
+		if sel == "" {
+			return nil
+		}
+
+		s, err := htmlutil.Compile(sel)
+		check(name, err)
+
+		return s
+	}
+
+	idx := &site.Index
+	if len(idx.Pages) != 0 && idx.Item == "" {
+		check("index.item", errors.New("selector is required"))
+	}
+
+	idx.item = compileSel("index.item", idx.Item)
+	check("index.link", idx.Link.compile())
+	check("index.title", idx.Title.compile())
+	check("index.date", idx.Date.compile())
+
+	art := &site.Article
+	check("article.title", art.Title.compile())
+	check("article.description", art.Description.compile())
+	check("article.author", art.Author.compile())
+	check("article.date", art.Date.compile())
+	check("article.updated_date", art.UpdatedDate.compile())
+	check("article.image", art.Image.compile())
+	check("article.tags", art.Tags.compile())
+
+	if art.Body == "" {
+		check("article.body", errors.New("selector is required"))
+	}
+
+	art.body = compileSel("article.body", art.Body)
+	for i, sel := range art.Exclude {
+		art.exclude = append(art.exclude, compileSel(fmt.Sprintf("article.exclude[%d]", i), sel))
+	}
+
+	if len(art.Blocks) == 0 {
+		check("article.blocks", errors.New("at least one block is required"))
+	}
+
+	for i := range art.Blocks {
+		block := &art.Blocks[i]
+		name := fmt.Sprintf("article.blocks[%d]", i)
+		if !blockTypes[block.Type] {
+			check(name, fmt.Errorf("unknown block type %q", block.Type))
+		}
+
+		if block.Selector == "" {
+			check(name, errors.New("selector is required"))
+		}
+
+		block.sel = compileSel(name, block.Selector)
+		check(name+".src", block.Src.compile())
+		check(name+".caption", block.Caption.compile())
+		check(name+".link", block.Link.compile())
+		check(name+".title", block.Title.compile())
+	}
+
+	rel := &art.Related
+	rel.item = compileSel("article.related.item", rel.Item)
+	check("article.related.link", rel.Link.compile())
+	check("article.related.title", rel.Title.compile())
+	check("article.related.image", rel.Image.compile())
+
+	if len(errs) != 0 {
+		return fmt.Errorf("site %s: %w", site.Name, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// MatchHost reports whether the host belongs to the site
+func (site *Site) MatchHost(host string) bool {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	for _, h := range site.Hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,256 @@
+package siterules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const dummyRules = `
+name: dummy
+source: Dummy.com
+hosts: [www.dummy.com]
+
+index:
+  pages:
+    - url: https://www.dummy.com/indeks
+      channel: news
+  item: li.item
+  title: h2
+  date:
+    selector: span.date
+    format: 02/01/2006 15:04
+
+article:
+  title: h1
+  author:
+    selector: div.author
+    regex: 'Oleh: (.+)'
+  date:
+    selector: time
+    attr: datetime
+  body: div.content
+  exclude: [div.ads]
+  blocks:
+    - type: section-title
+      selector: h2
+    - type: paragraph-text
+      selector: p
+    - type: image
+      selector: figure
+      caption: figcaption
+    - type: referenced-article-link
+      selector: div.baca-juga
+  tags: div.tags a
+`
+
+const dummyIndexHtml = `
+<ul>
+	<li class="item"><a href="/berita/1"><h2>Berita  Satu</h2></a><span class="date">12/08/2024 10:30</span></li>
+	<li class="item"><a href="https://www.dummy.com/berita/2"><h2>Berita Dua</h2></a></li>
+	<li class="item"><h2>Tanpa Link</h2></li>
+</ul>`
+
+const dummyArticleHtml = `
+<html>
+<head><meta property="og:description" content="Deskripsi"></head>
+<body>
+	<h1>Judul Berita</h1>
+	<div class="author">Oleh: Tim Dummy</div>
+	<time datetime="2024-08-12T10:30:00+07:00">12 Agustus 2024</time>
+	<div class="content">
+		<p>Paragraf <b>pertama</b>.</p>
+		<div class="ads"><p>Iklan</p></div>
+		<h2>Subjudul</h2>
+		<figure><img src="/img/1.jpg" alt="gambar"><figcaption>Keterangan</figcaption></figure>
+		<div class="baca-juga"><a href="/berita/3">Berita Tiga</a></div>
+		<p>Paragraf kedua.</p>
+	</div>
+	<div class="tags"><a>Pemilu</a><a>KPU</a></div>
+</body>
+</html>`
+
+func parseHtml(t *testing.T, src string) *html.Node {
+	node, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return node
+}
+
+func TestExtractIndex(t *testing.T) {
+	site, err := Parse([]byte(dummyRules))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	list, err := site.ExtractIndex(parseHtml(t, dummyIndexHtml), site.Index.Pages[0])
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(list) != 2 {
+		t.Fatalf("expecting 2 items, got %d", len(list))
+	}
+
+	item := list[0]
+	if item.Link != "https://www.dummy.com/berita/1" || item.Title != "Berita Satu" || item.Channel != "news" {
+		t.Errorf("unexpected item: %+v", item)
+	}
+
+	want := time.Date(2024, 8, 12, 10, 30, 0, 0, time.UTC).Add(-7 * time.Hour)
+	if !item.PublishedAt.Equal(want) {
+		t.Errorf("expecting published at %s, got %s", want, item.PublishedAt)
+	}
+
+	if !list[1].PublishedAt.IsZero() {
+		t.Errorf("expecting zero published at, got %s", list[1].PublishedAt)
+	}
+}
+
+func TestExtractArticle(t *testing.T) {
+	site, err := Parse([]byte(dummyRules))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	art, err := site.ExtractArticle(parseHtml(t, dummyArticleHtml), "https://www.dummy.com/berita/1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if art.Source != "Dummy.com" || art.Headline != "Judul Berita" || art.Author.Name != "Tim Dummy" || art.Description != "Deskripsi" {
+		t.Errorf("unexpected article: %+v", art)
+	}
+
+	if art.PublishedAt.IsZero() {
+		t.Error("expecting published at")
+	}
+
+	wantTypes := []ArticleContentType{
+		ParagraphText,
+		SectionTitle,
+		Image,
+		ReferencedArticleLink,
+		ParagraphText,
+	}
+
+	if len(art.Contents) != len(wantTypes) {
+		t.Fatalf("expecting %d contents, got %d", len(wantTypes), len(art.Contents))
+	}
+
+	for i, content := range art.Contents {
+		if content.Type != wantTypes[i] {
+			t.Errorf("content %d: expecting %s, got %s", i, wantTypes[i], content.Type)
+		}
+	}
+
+	if paragraphs := art.Paragraphs(); paragraphs[0] != "Paragraf pertama." {
+		t.Errorf("unexpected paragraph %q", paragraphs[0])
+	}
+
+	if img := art.Contents[2].Image(); img.URL != "https://www.dummy.com/img/1.jpg" || img.Caption != "Keterangan" {
+		t.Errorf("unexpected image %+v", img)
+	}
+
+	if ref := art.Contents[3].ReferencedArticle(); ref.ArticleLink != "https://www.dummy.com/berita/3" {
+		t.Errorf("unexpected referenced article %+v", ref)
+	}
+
+	if len(art.Tags) != 2 || art.Tags[0] != "Pemilu" {
+		t.Errorf("unexpected tags %v", art.Tags)
+	}
+
+	if art.WordCount != 4 {
+		t.Errorf("expecting 4 words, got %d", art.WordCount)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := map[string]string{
+		"no name":        "article: {body: div, blocks: [{type: paragraph-text, selector: p}]}",
+		"no body":        "name: a\narticle: {blocks: [{type: paragraph-text, selector: p}]}",
+		"bad selector":   "name: a\narticle: {body: 'div[', blocks: [{type: paragraph-text, selector: p}]}",
+		"bad block type": "name: a\narticle: {body: div, blocks: [{type: table, selector: table}]}",
+		"bad regex":      "name: a\narticle: {body: div, title: {regex: '('}, blocks: [{type: paragraph-text, selector: p}]}",
+	}
+
+	for name, rules := range tests {
+		if _, err := Parse([]byte(rules)); err == nil {
+			t.Errorf("%s: expecting error", name)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "dummy.yaml"), []byte(dummyRules), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	reg := NewRegistry(dir)
+	if err := reg.Load(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, ok := reg.SiteForLink("https://dummy.com/berita/1"); !ok {
+		t.Error("expecting site for link")
+	}
+
+	// invalid rules keeps the previous rules
+	if err := os.WriteFile(filepath.Join(dir, "broken.yml"), []byte("name: broken"), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := reg.Load(); err == nil {
+		t.Error("expecting error")
+	}
+
+	if _, ok := reg.Site("Dummy.com"); !ok {
+		t.Error("expecting previous rules to be kept")
+	}
+
+	// a bad edit keeps the previous rules of the file
+	if err := os.WriteFile(filepath.Join(dir, "dummy.yaml"), []byte("name: dummy"), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := reg.Load(); err == nil {
+		t.Error("expecting error")
+	}
+
+	if _, ok := reg.Site("Dummy.com"); !ok {
+		t.Error("expecting previous rules of the edited file to be kept")
+	}
+
+	// the invalid files is skipped on the first load
+	reg = NewRegistry(dir)
+	if err := os.WriteFile(filepath.Join(dir, "dummy.yaml"), []byte(dummyRules), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := reg.Load(); err == nil {
+		t.Error("expecting error")
+	}
+
+	if sites := reg.Sites(); len(sites) != 1 || sites[0].Name != "dummy" {
+		t.Errorf("expecting only the valid site to be loaded, got %d sites", len(sites))
+	}
+}
+
+// TestRuleFiles makes sure the bundled rule files is valid
+func TestRuleFiles(t *testing.T) {
+	reg := NewRegistry("../../rules")
+	if err := reg.Load(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(reg.Sites()) == 0 {
+		t.Error("expecting bundled sites")
+	}
+}
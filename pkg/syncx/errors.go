@@ -2,7 +2,13 @@ package syncx
 
 import "sync"
 
+// maxErrors is how much errors is kept by Errors, the older
+// errors is dropped so a long running Routines does not grow
+// forever
+const maxErrors = 100
+
 // Errors is a simple concurrent-safe error collector.
+// Only the last maxErrors errors is kept.
 type Errors struct {
 	errs []error
 	mx   sync.Mutex
@@ -12,10 +18,13 @@ func (es *Errors) Add(err error) {
 	es.mx.Lock()
 	defer es.mx.Unlock()
 	es.errs = append(es.errs, err)
+	if len(es.errs) > maxErrors {
+		es.errs = append(es.errs[:0], es.errs[len(es.errs)-maxErrors:]...)
+	}
 }
 
 func (es *Errors) Errors() []error {
 	es.mx.Lock()
 	defer es.mx.Unlock()
-	return es.errs
+	return append([]error(nil), es.errs...)
 }
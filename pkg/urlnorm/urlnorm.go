@@ -0,0 +1,251 @@
+// Package urlnorm normalizes article URLs, so the same article found
+// under different URLs, like with tracking query params, or its AMP
+// and mobile page, is stored once. The URL is normalized statically
+// by [Normalize], and by [Resolver] which follows the redirects and
+// the <link rel=canonical> of the page.
+package urlnorm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tamboto2000/ivosight-crawler/pkg/httpreq"
+	"github.com/tamboto2000/ivosight-crawler/pkg/httpstatus"
+	"github.com/tamboto2000/ivosight-crawler/pkg/metadata"
+	"golang.org/x/net/html"
+)
+
+var ErrInvalidURL = errors.New("invalid article URL")
+
+// trackingParams is the query params that does not change the page,
+// the params with utm_ prefix is stripped as well
+var trackingParams = map[string]bool{
+	"tag_from":   true,
+	"fbclid":     true,
+	"gclid":      true,
+	"dclid":      true,
+	"gbraid":     true,
+	"wbraid":     true,
+	"msclkid":    true,
+	"yclid":      true,
+	"igshid":     true,
+	"mc_cid":     true,
+	"mc_eid":     true,
+	"_ga":        true,
+	"_gl":        true,
+	"ref_src":    true,
+	"amp":        true,
+	"outputtype": true,
+	"_branch":    true,
+	"from_wa":    true,
+}
+
+// Normalize normalizes an article URL:
+//   - the host is lowercased, without the default port of the
+//     scheme. The scheme is kept, as not every site serves https
+//   - the mobile host is replaced with the desktop host, like
+//     m.detik.com/news/... to news.detik.com/...
+//   - the AMP host, trailing /amp segment and amp params is removed
+//   - the tracking params, like utm_* and tag_from, and the fragment
+//     is removed, the other params is sorted
+//   - the duplicate and trailing slashes of the path is removed, the
+//     escaped path is kept as is, so %2F is not decoded to a slash
+func Normalize(link string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return "", err
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", ErrInvalidURL
+	}
+
+	u.User = nil
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+
+	segs := pathSegments(u.EscapedPath())
+	host, segs = desktopHost(host, segs)
+
+	if n := len(segs); n != 0 && strings.EqualFold(segs[n-1], "amp") {
+		segs = segs[:n-1]
+	}
+
+	u.Host = host
+	if port != "" {
+		u.Host += ":" + port
+	}
+
+	u.RawPath = "/" + strings.Join(segs, "/")
+	u.Path, err = url.PathUnescape(u.RawPath)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	for key := range query {
+		lkey := strings.ToLower(key)
+		if trackingParams[lkey] || strings.HasPrefix(lkey, "utm_") {
+			query.Del(key)
+		}
+	}
+
+	u.RawQuery = query.Encode()
+	u.ForceQuery = false
+
+	return u.String(), nil
+}
+
+// MustNormalize is like [Normalize], but returns the link as is
+// when it can not be normalized
+func MustNormalize(link string) string {
+	norm, err := Normalize(link)
+	if err != nil {
+		return link
+	}
+
+	return norm
+}
+
+// desktopHost replaces the AMP and mobile host. The mobile page of
+// detik is under m.detik.com with the subdomain as the first segment
+func desktopHost(host string, segs []string) (string, []string) {
+	host = strings.TrimPrefix(host, "amp.")
+
+	mobile := ""
+	for _, prefix := range []string{"m.", "mobile."} {
+		if strings.HasPrefix(host, prefix) {
+			mobile = strings.TrimPrefix(host, prefix)
+			break
+		}
+	}
+
+	switch {
+	case mobile == "":
+		return host, segs
+
+	case mobile == "detik.com" && len(segs) > 1:
+		return segs[0] + ".detik.com", segs[1:]
+
+	case mobile == "detik.com":
+		return "www.detik.com", segs
+
+	default:
+		return "www." + mobile, segs
+	}
+}
+
+func pathSegments(p string) []string {
+	var segs []string
+	for _, seg := range strings.Split(p, "/") {
+		if seg != "" {
+			segs = append(segs, seg)
+		}
+	}
+
+	return segs
+}
+
+// Canonical gets the canonical URL of the article at link, which is
+// the <link rel=canonical> of the page when it is a valid article URL
+// of the same site, or link itself otherwise. Both is normalized
+func Canonical(link, canonical string) string {
+	norm := MustNormalize(link)
+	if canonical == "" {
+		return norm
+	}
+
+	base, err := url.Parse(link)
+	if err != nil {
+		return norm
+	}
+
+	ref, err := base.Parse(strings.TrimSpace(canonical))
+	if err != nil {
+		return norm
+	}
+
+	// some pages has the homepage or another site as the canonical
+	if strings.Trim(ref.Path, "/") == "" || SiteDomain(ref.Hostname()) != SiteDomain(base.Hostname()) {
+		return norm
+	}
+
+	normCanonical, err := Normalize(ref.String())
+	if err != nil {
+		return norm
+	}
+
+	return normCanonical
+}
+
+// SiteDomain gets the registered domain of host, like detik.com
+// of news.detik.com, or tempo.co.id of www.tempo.co.id
+func SiteDomain(host string) string {
+	labels := strings.Split(strings.TrimSuffix(strings.ToLower(host), "."), ".")
+	n := 2
+	if len(labels) > 2 {
+		switch labels[len(labels)-2] {
+		case "co", "or", "ac", "go", "web", "my", "sch", "net":
+			n = 3
+		}
+	}
+
+	if len(labels) <= n {
+		return strings.Join(labels, ".")
+	}
+
+	return strings.Join(labels[len(labels)-n:], ".")
+}
+
+// Resolver resolves the canonical URL of an article by requesting it
+type Resolver struct {
+	cl *http.Client
+}
+
+func NewResolver(cl *http.Client) *Resolver {
+	if cl == nil {
+		cl = http.DefaultClient
+	}
+
+	return &Resolver{cl: cl}
+}
+
+// Resolve follows the redirects of link, and gets the canonical URL
+// of the page it ends up on
+func (res *Resolver) Resolve(ctx context.Context, link string) (string, error) {
+	req, err := httpreq.NewPage(ctx, link)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := res.cl.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if err := httpstatus.Check(resp); err != nil {
+		return "", err
+	}
+
+	final := resp.Request.URL.String()
+
+	node, err := html.Parse(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	md := metadata.Extract(node)
+
+	return Canonical(final, md.CanonicalURL), nil
+}
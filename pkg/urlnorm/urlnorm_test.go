@@ -0,0 +1,120 @@
+package urlnorm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{
+			"https://news.detik.com/berita/d-7483712/judul-berita?tag_from=wp_nhl_1&utm_source=twitter",
+			"https://news.detik.com/berita/d-7483712/judul-berita",
+		},
+		{
+			"https://NEWS.Detik.com:443/berita/d-7483712/judul-berita/#comment",
+			"https://news.detik.com/berita/d-7483712/judul-berita",
+		},
+		{
+			"http://www.beritadaerah.id:80/berita/judul?utm_source=wa",
+			"http://www.beritadaerah.id/berita/judul",
+		},
+		{
+			"https://www.dummy.com/tag/a%2Fb//judul/",
+			"https://www.dummy.com/tag/a%2Fb/judul",
+		},
+		{
+			"https://m.detik.com/news/berita/d-7483712/judul-berita",
+			"https://news.detik.com/berita/d-7483712/judul-berita",
+		},
+		{
+			"https://news.detik.com/berita/d-7483712/judul-berita/amp",
+			"https://news.detik.com/berita/d-7483712/judul-berita",
+		},
+		{
+			"https://m.liputan6.com/news/read/5678901/judul-berita?amp=1",
+			"https://www.liputan6.com/news/read/5678901/judul-berita",
+		},
+		{
+			"https://amp.kompas.com/nasional/read/2024/08/12/judul",
+			"https://kompas.com/nasional/read/2024/08/12/judul",
+		},
+		{
+			"https://www.kompas.com//nasional/read/2024/08/12/judul?page=all&fbclid=abc&UTM_Medium=x",
+			"https://www.kompas.com/nasional/read/2024/08/12/judul?page=all",
+		},
+		{
+			"https://www.cnnindonesia.com/nasional/123?b=2&a=1",
+			"https://www.cnnindonesia.com/nasional/123?a=1&b=2",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := Normalize(tt.link)
+		if err != nil {
+			t.Errorf("%s: %v", tt.link, err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: expecting %s, got %s", tt.link, tt.want, got)
+		}
+	}
+
+	for _, link := range []string{"", "/berita/d-1", "ftp://detik.com/a", "javascript:void(0)"} {
+		if _, err := Normalize(link); err == nil {
+			t.Errorf("%q: expecting error", link)
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	link := "https://m.detik.com/news/berita/d-7483712/judul?tag_from=wp"
+	tests := []struct {
+		canonical string
+		want      string
+	}{
+		{"", "https://news.detik.com/berita/d-7483712/judul"},
+		{"https://news.detik.com/berita/d-7483712/judul-lengkap", "https://news.detik.com/berita/d-7483712/judul-lengkap"},
+		{"/news/berita/d-7483712/judul-lengkap", "https://news.detik.com/berita/d-7483712/judul-lengkap"},
+		{"https://www.detik.com/", "https://news.detik.com/berita/d-7483712/judul"},
+		{"https://www.liputan6.com/news/read/1/judul", "https://news.detik.com/berita/d-7483712/judul"},
+	}
+
+	for _, tt := range tests {
+		if got := Canonical(link, tt.canonical); got != tt.want {
+			t.Errorf("%q: expecting %s, got %s", tt.canonical, tt.want, got)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/s/abc", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/amp/berita/123?utm_source=wa", http.StatusMovedPermanently)
+	})
+
+	mux.HandleFunc("/amp/berita/123", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><head><link rel="canonical" href="%s/berita/123/judul"></head></html>`, srv.URL)
+	})
+
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	got, err := NewResolver(srv.Client()).Resolve(context.Background(), srv.URL+"/s/abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := srv.URL + "/berita/123/judul"
+	if got != want {
+		t.Errorf("expecting %s, got %s", want, got)
+	}
+}